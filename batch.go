@@ -0,0 +1,433 @@
+package smt
+
+import (
+	"bytes"
+	"sort"
+)
+
+// batchOp is a single path (and value, for updates) that is part of a batched
+// trie operation. The index tracks the position of the operation in the
+// caller provided keys so results can be returned in the original order.
+type batchOp struct {
+	path  []byte
+	value []byte
+	index int
+}
+
+// UpdateBatch inserts the `values` for the given `keys` into the SMT.
+//
+// The operations are sorted by path and applied in a single recursive descent
+// of the trie, so nodes along shared path prefixes are only resolved and
+// rehashed once. If the same key is provided more than once, the last value
+// wins, just as it would with sequential calls to `Update`. All nodes replaced
+// by the batch are tracked as a single orphan set.
+func (smt *SMT) UpdateBatch(keys, values [][]byte) error {
+	if len(keys) != len(values) {
+		return ErrBatchLengthMismatch
+	}
+	ops := make([]batchOp, len(keys))
+	for i := range keys {
+		ops[i] = batchOp{
+			path:  smt.ph.Path(keys[i]),
+			value: smt.valueHash(values[i]),
+			index: i,
+		}
+	}
+	ops = dedupeBatchOps(sortBatchOps(ops))
+
+	// Make room for the dirty nodes of the batch before modifying the trie
+	if err := smt.flushDirtyNodes(); err != nil {
+		return err
+	}
+	// Resolve the nodes along all the paths before modifying any of them
+	if err := smt.resolvePaths(&smt.root, 0, ops, false); err != nil {
+		return err
+	}
+	if err := smt.trackChanges(ops); err != nil {
+		return err
+	}
+
+	var orphans orphanNodes
+	newRoot, err := smt.updateBatch(smt.root, 0, ops, &orphans)
+	if err != nil {
+		return err
+	}
+	smt.root = newRoot
+	smt.recordChanges(ops, false)
+	smt.recordOrphans(orphans)
+	smt.evictCachedNodes()
+	return nil
+}
+
+// Internal helper to the `UpdateBatch` method. All operations provided must
+// share the path bits above the given depth and be sorted by path.
+func (smt *SMT) updateBatch(
+	node trieNode,
+	depth int,
+	ops []batchOp,
+	orphans *orphanNodes,
+) (trieNode, error) {
+	if len(ops) == 0 {
+		return node, nil
+	}
+	if len(ops) == 1 {
+		return smt.update(node, depth, ops[0].path, ops[0].value, orphans)
+	}
+	node, err := smt.resolveLazy(node)
+	if err != nil {
+		return node, err
+	}
+	smt.dirtyNodes++
+
+	switch n := node.(type) {
+	case nil, *leafNode:
+		leaf, _ := node.(*leafNode)
+		return smt.insertBatch(leaf, depth, ops, orphans)
+	case *extensionNode:
+		smt.addOrphan(orphans, n)
+		// Find the shallowest bit at which any of the paths diverges from the
+		// extension node, since that is where the extension must be split.
+		minMatchLen, splitPath := n.length(), []byte(nil)
+		for _, op := range ops {
+			matchLen, fullMatch := n.boundsMatch(op.path, depth)
+			if !fullMatch && matchLen < minMatchLen {
+				minMatchLen, splitPath = matchLen, op.path
+			}
+		}
+		n.setDirty()
+		if splitPath != nil {
+			// After the split every path fully matches the (possibly empty)
+			// head of the extension, so we simply retry from the new head.
+			head, _, _ := n.split(splitPath)
+			return smt.updateBatch(head, depth, ops, orphans)
+		}
+		n.child, err = smt.updateBatch(n.child, depth+n.length(), ops, orphans)
+		if err != nil {
+			return node, err
+		}
+		return node, nil
+	}
+
+	inner := node.(*innerNode)
+	smt.addOrphan(orphans, inner)
+	split := splitBatchOps(ops, depth)
+	inner.leftChild, err = smt.updateBatch(inner.leftChild, depth+1, ops[:split], orphans)
+	if err != nil {
+		return node, err
+	}
+	inner.rightChild, err = smt.updateBatch(inner.rightChild, depth+1, ops[split:], orphans)
+	if err != nil {
+		return node, err
+	}
+	inner.setDirty()
+	return node, nil
+}
+
+// insertBatch builds the subtrie holding the paths of at least two operations
+// and of the leaf provided, if any, in place of that leaf or of an empty
+// subtrie. The paths all diverge at the first bit they do not share, so a
+// single inner node is created at that depth, below an extension node if it
+// is deeper than the current one, and the operations are split between its
+// children like they are for an existing inner node.
+func (smt *SMT) insertBatch(
+	leaf *leafNode,
+	depth int,
+	ops []batchOp,
+	orphans *orphanNodes,
+) (trieNode, error) {
+	var node trieNode
+	if leaf != nil {
+		node = leaf
+	}
+	// The operations are sorted, so the bits shared by all of their paths are
+	// the bits shared by the first and last ones
+	prefixLen := countCommonPrefixBits(ops[0].path, ops[len(ops)-1].path, depth)
+	if leaf != nil {
+		prefixLen = min(prefixLen, countCommonPrefixBits(ops[0].path, leaf.path, depth))
+	}
+	// Extension nodes cannot start below the depth their bounds can hold
+	if depth < prefixLen {
+		if depth > 0xff {
+			return node, ErrInvalidDepth
+		}
+	}
+
+	inner := &innerNode{}
+	if leaf != nil {
+		// The leaf is either kept on its side or replaced by the operation
+		// with the same path once the operations have been split
+		if getPathBit(leaf.path, prefixLen) == leftChildBit {
+			inner.leftChild = leaf
+		} else {
+			inner.rightChild = leaf
+		}
+	}
+	split := splitBatchOps(ops, prefixLen)
+	left, err := smt.updateBatch(inner.leftChild, prefixLen+1, ops[:split], orphans)
+	if err != nil {
+		return node, err
+	}
+	right, err := smt.updateBatch(inner.rightChild, prefixLen+1, ops[split:], orphans)
+	if err != nil {
+		return node, err
+	}
+	inner.leftChild, inner.rightChild = left, right
+	if depth == prefixLen {
+		return inner, nil
+	}
+	// Copy the path to avoid retaining the slice of the operation
+	pathCopy := make([]byte, len(ops[0].path))
+	copy(pathCopy, ops[0].path)
+	return &extensionNode{
+		child: inner,
+		path:  pathCopy,
+		pathBounds: [2]byte{
+			byte(depth), byte(prefixLen),
+		},
+	}, nil
+}
+
+// DeleteBatch removes the nodes at the paths corresponding to the given keys.
+//
+// Like `UpdateBatch`, the deletions are applied in a single recursive descent
+// of the trie and produce a single orphan set. If any of the keys is not
+// present in the trie ErrKeyNotFound is returned and the trie is left
+// untouched.
+func (smt *SMT) DeleteBatch(keys [][]byte) error {
+	ops := make([]batchOp, len(keys))
+	for i := range keys {
+		ops[i] = batchOp{path: smt.ph.Path(keys[i]), index: i}
+	}
+	ops = dedupeBatchOps(sortBatchOps(ops))
+
+	// Ensure every key is present before mutating the trie
+	leaves := make([]*leafNode, len(keys))
+	if err := smt.getBatch(&smt.root, 0, ops, leaves); err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if leaves[op.index] == nil {
+			return ErrKeyNotFound
+		}
+	}
+	// Make room for the dirty nodes of the batch before modifying the trie
+	if err := smt.flushDirtyNodes(); err != nil {
+		return err
+	}
+	// Resolve the siblings of the nodes along the paths, which may replace
+	// their parents, before modifying any of them
+	if err := smt.resolvePaths(&smt.root, 0, ops, true); err != nil {
+		return err
+	}
+	if err := smt.trackChanges(ops); err != nil {
+		return err
+	}
+
+	var orphans orphanNodes
+	newRoot, err := smt.deleteBatch(smt.root, 0, ops, &orphans)
+	if err != nil {
+		return err
+	}
+	smt.root = newRoot
+	smt.recordChanges(ops, true)
+	smt.recordOrphans(orphans)
+	smt.evictCachedNodes()
+	return nil
+}
+
+// Internal helper to the `DeleteBatch` method. All operations provided must
+// share the path bits above the given depth and be sorted by path.
+func (smt *SMT) deleteBatch(
+	node trieNode,
+	depth int,
+	ops []batchOp,
+	orphans *orphanNodes,
+) (trieNode, error) {
+	if len(ops) == 0 {
+		return node, nil
+	}
+	if len(ops) == 1 {
+		return smt.delete(node, depth, ops[0].path, orphans)
+	}
+	node, err := smt.resolveLazy(node)
+	if err != nil {
+		return node, err
+	}
+	smt.dirtyNodes++
+
+	switch n := node.(type) {
+	case nil, *leafNode:
+		// Several distinct paths can never be present below a single leaf
+		return node, ErrKeyNotFound
+	case *extensionNode:
+		for _, op := range ops {
+			if _, fullMatch := n.boundsMatch(op.path, depth); !fullMatch {
+				return node, ErrKeyNotFound
+			}
+		}
+		smt.addOrphan(orphans, n)
+		n.child, err = smt.deleteBatch(n.child, depth+n.length(), ops, orphans)
+		if err != nil {
+			return node, err
+		}
+		n.setDirty()
+		switch child := n.child.(type) {
+		case nil:
+			return nil, nil
+		case *leafNode:
+			return child, nil
+		case *extensionNode:
+			// Join this extension with the child
+			smt.addOrphan(orphans, child)
+			child.pathBounds[0] = n.pathBounds[0]
+			child.setDirty()
+			return child, nil
+		}
+		return node, nil
+	}
+
+	inner := node.(*innerNode)
+	smt.addOrphan(orphans, inner)
+	split := splitBatchOps(ops, depth)
+	inner.leftChild, err = smt.deleteBatch(inner.leftChild, depth+1, ops[:split], orphans)
+	if err != nil {
+		return node, err
+	}
+	inner.rightChild, err = smt.deleteBatch(inner.rightChild, depth+1, ops[split:], orphans)
+	if err != nil {
+		return node, err
+	}
+	if inner.leftChild, err = smt.resolveLazy(inner.leftChild); err != nil {
+		return node, err
+	}
+	if inner.rightChild, err = smt.resolveLazy(inner.rightChild); err != nil {
+		return node, err
+	}
+	// Handle replacement of this node, depending on the new child states.
+	// Note that inner nodes exist at a fixed depth, and can't be moved.
+	children := [2]*trieNode{&inner.leftChild, &inner.rightChild}
+	for i := 0; i < 2; i++ {
+		if *children[i] == nil {
+			switch n := (*children[1-i]).(type) {
+			case nil:
+				return nil, nil
+			case *leafNode:
+				return n, nil
+			case *extensionNode:
+				// "Absorb" this node into the extension by prepending
+				smt.addOrphan(orphans, n)
+				n.pathBounds[0]--
+				n.setDirty()
+				return n, nil
+			}
+		}
+	}
+	inner.setDirty()
+	return node, nil
+}
+
+// GetMany returns the hashes (i.e. digests) of the leaf values stored at the
+// given keys, in the same order as the keys provided. Keys that are not
+// present in the trie have the default empty value.
+//
+// The lookups are sorted by path and resolved in a single descent of the trie.
+func (smt *SMT) GetMany(keys [][]byte) ([][]byte, error) {
+	ops := make([]batchOp, len(keys))
+	for i := range keys {
+		ops[i] = batchOp{path: smt.ph.Path(keys[i]), index: i}
+	}
+	ops = sortBatchOps(ops)
+	defer smt.evictCachedNodes()
+
+	leaves := make([]*leafNode, len(keys))
+	if err := smt.getBatch(&smt.root, 0, ops, leaves); err != nil {
+		return nil, err
+	}
+	values := make([][]byte, len(keys))
+	for i, leaf := range leaves {
+		if leaf == nil {
+			values[i] = defaultEmptyValue
+			continue
+		}
+		values[i] = leaf.valueHash
+	}
+	return values, nil
+}
+
+// getBatch finds the leaves matching the paths of the operations provided,
+// storing them in `leaves` at the index of the corresponding operation.
+// Resolved nodes are cached in the trie, just as they are by `Get`.
+func (smt *SMT) getBatch(
+	node *trieNode,
+	depth int,
+	ops []batchOp,
+	leaves []*leafNode,
+) (err error) {
+	if len(ops) == 0 {
+		return nil
+	}
+	*node, err = smt.resolveLazy(*node)
+	if err != nil {
+		return err
+	}
+
+	switch n := (*node).(type) {
+	case nil:
+		return nil
+	case *leafNode:
+		for _, op := range ops {
+			if bytes.Equal(op.path, n.path) {
+				leaves[op.index] = n
+			}
+		}
+		return nil
+	case *extensionNode:
+		// Only the paths running through the entire extension can reach a leaf
+		matched := make([]batchOp, 0, len(ops))
+		for _, op := range ops {
+			if _, fullMatch := n.boundsMatch(op.path, depth); fullMatch {
+				matched = append(matched, op)
+			}
+		}
+		return smt.getBatch(&n.child, depth+n.length(), matched, leaves)
+	}
+
+	inner := (*node).(*innerNode)
+	split := splitBatchOps(ops, depth)
+	if err = smt.getBatch(&inner.leftChild, depth+1, ops[:split], leaves); err != nil {
+		return err
+	}
+	return smt.getBatch(&inner.rightChild, depth+1, ops[split:], leaves)
+}
+
+// sortBatchOps sorts the operations by path, preserving the relative order of
+// operations with equal paths.
+func sortBatchOps(ops []batchOp) []batchOp {
+	sort.SliceStable(ops, func(i, j int) bool {
+		return bytes.Compare(ops[i].path, ops[j].path) < 0
+	})
+	return ops
+}
+
+// dedupeBatchOps drops all but the last of any sorted operations sharing the
+// same path, so a batch behaves like the equivalent sequential calls.
+func dedupeBatchOps(ops []batchOp) []batchOp {
+	deduped := ops[:0]
+	for i, op := range ops {
+		if i+1 < len(ops) && bytes.Equal(op.path, ops[i+1].path) {
+			continue
+		}
+		deduped = append(deduped, op)
+	}
+	return deduped
+}
+
+// splitBatchOps returns the index of the first operation whose path bit at
+// the given depth is a right child bit. Since the operations are sorted and
+// share all path bits above the depth, every operation before the index goes
+// left and every operation from the index onwards goes right.
+func splitBatchOps(ops []batchOp, depth int) int {
+	return sort.Search(len(ops), func(i int) bool {
+		return getPathBit(ops[i].path, depth) != leftChildBit
+	})
+}
@@ -0,0 +1,235 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_UpdateBatch(t *testing.T) {
+	keys, values := randomBatch(t, 500, 1)
+
+	sequential := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := range keys {
+		require.NoError(t, sequential.Update(keys[i], values[i]))
+	}
+	require.NoError(t, sequential.Commit())
+
+	nodes := simplemap.NewSimpleMap()
+	batched := NewSparseMerkleTrie(nodes, sha256.New())
+	require.NoError(t, batched.UpdateBatch(keys, values))
+	require.Equal(t, sequential.Root(), batched.Root())
+	require.NoError(t, batched.Commit())
+
+	// Overwrite half of the keys and insert new ones on top of a lazily
+	// loaded trie so that extension and inner nodes need to be resolved
+	newKeys, newValues := randomBatch(t, 250, 2)
+	keys = append(keys[:250:250], newKeys...)
+	values = append(newValues[:250:250], newValues...)
+	for i := range keys {
+		require.NoError(t, sequential.Update(keys[i], values[i]))
+	}
+	require.NoError(t, sequential.Commit())
+
+	batched = ImportSparseMerkleTrie(nodes, sha256.New(), batched.Root())
+	require.NoError(t, batched.UpdateBatch(keys, values))
+	require.Len(t, batched.orphans, 1)
+	require.Equal(t, sequential.Root(), batched.Root())
+	require.NoError(t, batched.Commit())
+
+	// Every value must be retrievable from a trie loaded from the store
+	imported := ImportSparseMerkleTrie(nodes, sha256.New(), batched.Root())
+	for i := range keys {
+		value, err := imported.Get(keys[i])
+		require.NoError(t, err)
+		require.Equal(t, imported.valueHash(values[i]), value)
+	}
+}
+
+func TestSMT_UpdateBatchBelowLeaf(t *testing.T) {
+	// One byte paths sharing their first bits, so that the batches inserted
+	// below a single leaf need extension nodes
+	leafPath := []byte{0b00000000}
+	batches := map[string][][]byte{
+		"empty subtrie":  {{0b00010000}, {0b00010100}, {0b00011100}},
+		"keeping leaf":   {{0b00000011}, {0b00000010}, {0b10000000}},
+		"replacing leaf": {{0b00000001}, {0b00000000}, {0b00000101}},
+		"leaf diverging": {{0b01000001}, {0b01000000}},
+	}
+	for desc, keys := range batches {
+		t.Run(desc, func(t *testing.T) {
+			values := make([][]byte, len(keys))
+			for i := range keys {
+				values[i] = []byte(fmt.Sprintf("value-%d", i))
+			}
+			nodes := simplemap.NewSimpleMap()
+			sequential := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithPathHasher(newNilPathHasher(1)))
+			batched := NewSparseMerkleTrie(nodes, sha256.New(), WithPathHasher(newNilPathHasher(1)))
+			if desc != "empty subtrie" {
+				require.NoError(t, sequential.Update(leafPath, []byte("leaf")))
+				require.NoError(t, batched.Update(leafPath, []byte("leaf")))
+				require.NoError(t, batched.Commit())
+				batched = ImportSparseMerkleTrie(nodes, sha256.New(), batched.Root(), WithPathHasher(newNilPathHasher(1)))
+			}
+			for i := range keys {
+				require.NoError(t, sequential.Update(keys[i], values[i]))
+			}
+			require.NoError(t, batched.UpdateBatch(keys, values))
+			require.Equal(t, sequential.Root(), batched.Root())
+
+			// Only the replaced leaf is orphaned
+			if desc == "replacing leaf" {
+				require.Len(t, batched.orphans, 1)
+				require.Len(t, batched.orphans[0], 1)
+			} else {
+				require.Empty(t, batched.orphans)
+			}
+			require.NoError(t, batched.Commit())
+			stats, err := batched.Stats()
+			require.NoError(t, err)
+			stored, err := nodes.Len()
+			require.NoError(t, err)
+			require.Equal(t, stored, stats.LeafNodes+stats.InnerNodes+stats.ExtensionNodes)
+		})
+	}
+}
+
+func TestSMT_UpdateBatchDuplicateKeys(t *testing.T) {
+	smt := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	keys := [][]byte{[]byte("foo"), []byte("bar"), []byte("foo")}
+	values := [][]byte{[]byte("first"), []byte("bar"), []byte("last")}
+	require.NoError(t, smt.UpdateBatch(keys, values))
+
+	value, err := smt.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, smt.valueHash([]byte("last")), value)
+
+	err = smt.UpdateBatch(keys, values[:1])
+	require.ErrorIs(t, err, ErrBatchLengthMismatch)
+}
+
+func TestSMT_DeleteBatch(t *testing.T) {
+	keys, values := randomBatch(t, 500, 3)
+
+	nodes := simplemap.NewSimpleMap()
+	smt := NewSparseMerkleTrie(nodes, sha256.New())
+	require.NoError(t, smt.UpdateBatch(keys, values))
+	require.NoError(t, smt.Commit())
+
+	sequential := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, sequential.UpdateBatch(keys, values))
+	for _, key := range keys[:300] {
+		require.NoError(t, sequential.Delete(key))
+	}
+
+	// A missing key must leave the trie untouched
+	root := smt.Root()
+	err := smt.DeleteBatch(append([][]byte{[]byte("missing")}, keys[:300]...))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.Equal(t, root, smt.Root())
+
+	smt = ImportSparseMerkleTrie(nodes, sha256.New(), root)
+	require.NoError(t, smt.DeleteBatch(keys[:300]))
+	require.Len(t, smt.orphans, 1)
+	require.Equal(t, sequential.Root(), smt.Root())
+	require.NoError(t, smt.Commit())
+
+	for i, key := range keys {
+		value, err := smt.Get(key)
+		require.NoError(t, err)
+		if i < 300 {
+			require.Equal(t, defaultEmptyValue, value)
+		} else {
+			require.Equal(t, smt.valueHash(values[i]), value)
+		}
+	}
+
+	// Deleting the remaining keys must result in an empty trie
+	require.NoError(t, smt.DeleteBatch(keys[300:]))
+	require.Equal(t, smt.placeholder(), []byte(smt.Root()))
+	require.NoError(t, smt.Commit())
+	length, err := nodes.Len()
+	require.NoError(t, err)
+	require.Equal(t, 0, length)
+}
+
+func TestSMT_GetMany(t *testing.T) {
+	keys, values := randomBatch(t, 200, 4)
+
+	nodes := simplemap.NewSimpleMap()
+	smt := NewSparseMerkleTrie(nodes, sha256.New())
+	require.NoError(t, smt.UpdateBatch(keys[:150], values[:150]))
+	require.NoError(t, smt.Commit())
+
+	// Include duplicates and keys that are not in the trie
+	lookups := append([][]byte{keys[0]}, keys...)
+	smt = ImportSparseMerkleTrie(nodes, sha256.New(), smt.Root())
+	got, err := smt.GetMany(lookups)
+	require.NoError(t, err)
+	require.Len(t, got, len(lookups))
+	for i, key := range lookups {
+		want, err := smt.Get(key)
+		require.NoError(t, err)
+		require.Equal(t, want, got[i])
+	}
+}
+
+func TestSMST_Batch(t *testing.T) {
+	keys, values := randomBatch(t, 300, 5)
+	weights := make([]uint64, len(keys))
+	for i := range weights {
+		weights[i] = uint64(i + 1)
+	}
+
+	sequential := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := range keys {
+		require.NoError(t, sequential.Update(keys[i], values[i], weights[i]))
+	}
+
+	nodes := simplemap.NewSimpleMap()
+	smst := NewSparseMerkleSumTrie(nodes, sha256.New())
+	require.NoError(t, smst.UpdateBatch(keys, values, weights))
+	require.Equal(t, sequential.Root(), smst.Root())
+	require.Equal(t, sequential.MustSum(), smst.MustSum())
+	require.Equal(t, uint64(len(keys)), smst.MustCount())
+	require.NoError(t, smst.Commit())
+
+	err := smst.UpdateBatch(keys, values, weights[1:])
+	require.ErrorIs(t, err, ErrBatchLengthMismatch)
+
+	smst = ImportSparseMerkleSumTrie(nodes, sha256.New(), smst.Root())
+	valueDigests, gotWeights, err := smst.GetMany(append(keys, []byte("missing")))
+	require.NoError(t, err)
+	for i := range keys {
+		require.Equal(t, smst.valueHash(values[i]), valueDigests[i])
+		require.Equal(t, weights[i], gotWeights[i])
+	}
+	require.Equal(t, defaultEmptyValue, valueDigests[len(keys)])
+	require.Equal(t, uint64(0), gotWeights[len(keys)])
+
+	for _, key := range keys[:100] {
+		require.NoError(t, sequential.Delete(key))
+	}
+	require.NoError(t, smst.DeleteBatch(keys[:100]))
+	require.Equal(t, sequential.Root(), smst.Root())
+	require.Equal(t, uint64(len(keys)-100), smst.MustCount())
+}
+
+// randomBatch returns n random keys and values generated from the seed provided
+func randomBatch(t *testing.T, n int, seed int64) (keys, values [][]byte) {
+	t.Helper()
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < n; i++ {
+		key := make([]byte, 32)
+		_, err := r.Read(key)
+		require.NoError(t, err)
+		keys = append(keys, key)
+		values = append(values, []byte(fmt.Sprintf("value-%d-%d", seed, i)))
+	}
+	return keys, values
+}
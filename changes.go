@@ -0,0 +1,207 @@
+package smt
+
+import (
+	"bytes"
+	"sort"
+)
+
+// ChangeKind describes how a leaf was changed between two commits
+type ChangeKind string
+
+const (
+	// LeafInserted is the kind of changes adding a leaf to the trie
+	LeafInserted ChangeKind = "insert"
+	// LeafUpdated is the kind of changes replacing the value of a leaf
+	LeafUpdated ChangeKind = "update"
+	// LeafDeleted is the kind of changes removing a leaf from the trie
+	LeafDeleted ChangeKind = "delete"
+)
+
+// LeafChange describes the net change made to a single leaf of the trie
+// between two commits
+type LeafChange struct {
+	// Kind describes whether the leaf was inserted, updated or deleted
+	Kind ChangeKind
+	// Path is the path of the leaf
+	Path []byte
+	// OldValueHash is the value hash of the leaf at the previous commit, nil
+	// if the leaf was inserted
+	OldValueHash []byte
+	// NewValueHash is the value hash of the leaf at this commit, nil if the
+	// leaf was deleted
+	NewValueHash []byte
+	// OldWeight is the weight of the leaf at the previous commit, always zero
+	// for SMTs and inserted leaves
+	OldWeight uint64
+	// NewWeight is the weight of the leaf at this commit, always zero for SMTs
+	// and deleted leaves
+	NewWeight uint64
+}
+
+// CommitChanges describes the leaves changed by a commit
+type CommitChanges struct {
+	// OldRoot is the root of the trie at the previous commit
+	OldRoot []byte
+	// NewRoot is the root of the trie at this commit
+	NewRoot []byte
+	// Changes holds the net change made to every modified leaf, ordered by
+	// path. Leaves modified and then restored to their previous value are not
+	// included.
+	Changes []LeafChange
+}
+
+// pendingChange tracks the state of a leaf at the previous commit along with
+// its current state
+type pendingChange struct {
+	oldExists, newExists bool
+	oldValue, newValue   []byte
+}
+
+// Subscribe registers a function called with the leaves changed by every
+// subsequent successful commit of the trie, and returns a function removing
+// the subscription.
+//
+// Changes are only tracked while the trie has subscribers, so subscribers
+// should be registered before the trie is modified: changes made while there
+// were none are not reported. Subscribers are called synchronously at the end
+// of the commit, in the order they were registered, and must not modify the
+// trie or the changes provided.
+func (smt *SMT) Subscribe(subscriber func(CommitChanges)) (unsubscribe func()) {
+	if smt.subscribers == nil {
+		smt.subscribers = make(map[uint64]func(CommitChanges))
+	}
+	id := smt.nextSubscriberID
+	smt.nextSubscriberID++
+	smt.subscribers[id] = subscriber
+	return func() {
+		delete(smt.subscribers, id)
+		if len(smt.subscribers) == 0 {
+			smt.pendingChanges = nil
+		}
+	}
+}
+
+// trackChanges records the current state of the leaves at the paths of the
+// operations provided which were not modified since the last commit, before
+// they are modified. The nodes along the paths must already be resolved.
+func (smt *SMT) trackChanges(ops []batchOp) error {
+	if len(smt.subscribers) == 0 {
+		return nil
+	}
+	if smt.pendingChanges == nil {
+		smt.pendingChanges = make(map[string]*pendingChange)
+	}
+	untracked := make([]batchOp, 0, len(ops))
+	for i, op := range ops {
+		if _, ok := smt.pendingChanges[string(op.path)]; !ok {
+			untracked = append(untracked, batchOp{path: op.path, index: i})
+		}
+	}
+	if len(untracked) == 0 {
+		return nil
+	}
+	leaves := make([]*leafNode, len(ops))
+	if err := smt.getBatch(&smt.root, 0, untracked, leaves); err != nil {
+		return err
+	}
+	for _, op := range untracked {
+		change := &pendingChange{}
+		if leaf := leaves[op.index]; leaf != nil {
+			change.oldExists = true
+			change.oldValue = append([]byte{}, leaf.valueHash...)
+		}
+		change.newExists, change.newValue = change.oldExists, change.oldValue
+		smt.pendingChanges[string(op.path)] = change
+	}
+	return nil
+}
+
+// recordChanges records the new state of the leaves at the paths of the
+// operations provided, once they were successfully applied
+func (smt *SMT) recordChanges(ops []batchOp, deleted bool) {
+	if len(smt.subscribers) == 0 {
+		return
+	}
+	for _, op := range ops {
+		change, ok := smt.pendingChanges[string(op.path)]
+		if !ok {
+			continue
+		}
+		change.newExists = !deleted
+		change.newValue = nil
+		if !deleted {
+			change.newValue = append([]byte{}, op.value...)
+		}
+	}
+}
+
+// publishChanges delivers the changes made since the previous commit to the
+// subscribers and starts tracking changes afresh
+func (smt *SMT) publishChanges(oldRoot []byte) {
+	if len(smt.subscribers) == 0 {
+		return
+	}
+	paths := make([]string, 0, len(smt.pendingChanges))
+	for path := range smt.pendingChanges {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	changes := make([]LeafChange, 0, len(paths))
+	for _, path := range paths {
+		pending := smt.pendingChanges[path]
+		change := LeafChange{Path: []byte(path)}
+		switch {
+		case !pending.oldExists && pending.newExists:
+			change.Kind = LeafInserted
+		case pending.oldExists && !pending.newExists:
+			change.Kind = LeafDeleted
+		case pending.oldExists && !bytes.Equal(pending.oldValue, pending.newValue):
+			change.Kind = LeafUpdated
+		default:
+			continue
+		}
+		if pending.oldExists {
+			change.OldValueHash, change.OldWeight = smt.decodeLeafValue(pending.oldValue)
+		}
+		if pending.newExists {
+			change.NewValueHash, change.NewWeight = smt.decodeLeafValue(pending.newValue)
+		}
+		changes = append(changes, change)
+	}
+	smt.pendingChanges = nil
+	if oldRoot == nil {
+		// The trie was never committed
+		oldRoot = smt.placeholder()
+	}
+
+	event := CommitChanges{
+		OldRoot: oldRoot,
+		NewRoot: smt.rootHash,
+		Changes: changes,
+	}
+	ids := make([]uint64, 0, len(smt.subscribers))
+	for id := range smt.subscribers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if subscriber, ok := smt.subscribers[id]; ok {
+			subscriber(event)
+		}
+	}
+}
+
+// decodeLeafValue splits the value stored in a leaf into its value hash and
+// weight, which is always zero for SMTs. Values which are not valid sum leaf
+// values, as can only be inserted through the SMT of an SMST, are reported
+// as is.
+func (smt *SMT) decodeLeafValue(value []byte) (valueHash []byte, weight uint64) {
+	if !smt.sumTrie {
+		return value, 0
+	}
+	valueHash, weight, err := decodeSumLeafValue(value)
+	if err != nil {
+		return value, 0
+	}
+	return append([]byte{}, valueHash...), weight
+}
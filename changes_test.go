@@ -0,0 +1,205 @@
+package smt_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_Subscribe(t *testing.T) {
+	trie := smt.NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	var events []smt.CommitChanges
+	unsubscribe := trie.Subscribe(func(changes smt.CommitChanges) { events = append(events, changes) })
+
+	valueHash := func(value string) []byte {
+		digest := sha256.Sum256([]byte(value))
+		return digest[:]
+	}
+	emptyRoot := trie.Root()
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v1")))
+	require.NoError(t, trie.Update([]byte("bar"), []byte("v1")))
+	require.NoError(t, trie.Update([]byte("baz"), []byte("v1")))
+	// Leaves inserted and deleted before the commit are not reported
+	require.NoError(t, trie.Update([]byte("tmp"), []byte("v1")))
+	require.NoError(t, trie.Delete([]byte("tmp")))
+	require.NoError(t, trie.Commit())
+	require.Len(t, events, 1)
+	require.Equal(t, emptyRoot, smt.MerkleRoot(events[0].OldRoot))
+	require.Equal(t, trie.Root(), smt.MerkleRoot(events[0].NewRoot))
+	requireChanges(t, events[0].Changes, map[string]smt.LeafChange{
+		"foo": {Kind: smt.LeafInserted, NewValueHash: valueHash("v1")},
+		"bar": {Kind: smt.LeafInserted, NewValueHash: valueHash("v1")},
+		"baz": {Kind: smt.LeafInserted, NewValueHash: valueHash("v1")},
+	})
+
+	oldRoot := trie.Root()
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v2")))
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v3")))
+	require.NoError(t, trie.DeleteBatch([][]byte{[]byte("bar")}))
+	// Leaves restored to their committed value are not reported
+	require.NoError(t, trie.UpdateBatch([][]byte{[]byte("baz"), []byte("qux")}, [][]byte{[]byte("v2"), []byte("v1")}))
+	require.NoError(t, trie.Update([]byte("baz"), []byte("v1")))
+	require.NoError(t, trie.Commit())
+	require.Len(t, events, 2)
+	require.Equal(t, oldRoot, smt.MerkleRoot(events[1].OldRoot))
+	requireChanges(t, events[1].Changes, map[string]smt.LeafChange{
+		"foo": {Kind: smt.LeafUpdated, OldValueHash: valueHash("v1"), NewValueHash: valueHash("v3")},
+		"bar": {Kind: smt.LeafDeleted, OldValueHash: valueHash("v1")},
+		"qux": {Kind: smt.LeafInserted, NewValueHash: valueHash("v1")},
+	})
+
+	// Every commit is reported, even without changes
+	require.NoError(t, trie.Commit())
+	require.Len(t, events, 3)
+	require.Empty(t, events[2].Changes)
+	require.Equal(t, events[2].OldRoot, events[2].NewRoot)
+
+	unsubscribe()
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v4")))
+	require.NoError(t, trie.Commit())
+	require.Len(t, events, 3)
+}
+
+func TestSMT_Subscribe_FailedCommit(t *testing.T) {
+	store := kvstoretest.NewFaultyMapStore(simplemap.NewSimpleMap())
+	trie := smt.NewSparseMerkleTrie(store, sha256.New())
+	var events []smt.CommitChanges
+	trie.Subscribe(func(changes smt.CommitChanges) { events = append(events, changes) })
+
+	// Failed mutations are not reported
+	require.ErrorIs(t, trie.Delete([]byte("foo")), smt.ErrKeyNotFound)
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v1")))
+	store.FailAfter(kvstoretest.OpSet, 0)
+	require.ErrorIs(t, trie.Commit(), kvstoretest.ErrInjectedFault)
+	require.Empty(t, events)
+
+	// The changes are reported by the commit which succeeds
+	store.Reset()
+	require.NoError(t, trie.Update([]byte("bar"), []byte("v1")))
+	require.NoError(t, trie.Commit())
+	require.Len(t, events, 1)
+	valueHash := sha256.Sum256([]byte("v1"))
+	requireChanges(t, events[0].Changes, map[string]smt.LeafChange{
+		"foo": {Kind: smt.LeafInserted, NewValueHash: valueHash[:]},
+		"bar": {Kind: smt.LeafInserted, NewValueHash: valueHash[:]},
+	})
+}
+
+func TestSMT_Subscribe_MatchesDiff(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	trie := smt.NewSparseMerkleTrie(nodes, sha256.New(), smt.WithMaxDirtyNodes(32))
+	var changes []smt.LeafChange
+	trie.Subscribe(func(event smt.CommitChanges) { changes = event.Changes })
+
+	rng := rand.New(rand.NewSource(1))
+	committed := make(map[string][]byte)
+	current := make(map[string][]byte)
+	for round := 0; round < 20; round++ {
+		for i := 0; i < 100; i++ {
+			key := []byte(fmt.Sprintf("key-%d", rng.Intn(200)))
+			path := sha256.Sum256(key)
+			if _, ok := current[string(path[:])]; ok && rng.Intn(3) == 0 {
+				require.NoError(t, trie.Delete(key))
+				delete(current, string(path[:]))
+				continue
+			}
+			value := []byte(fmt.Sprintf("value-%d", rng.Intn(3)))
+			require.NoError(t, trie.Update(key, value))
+			valueHash := sha256.Sum256(value)
+			current[string(path[:])] = valueHash[:]
+		}
+		require.NoError(t, trie.Commit())
+
+		// Diff the committed states of the trie
+		expected := make([]smt.LeafChange, 0)
+		for path, value := range current {
+			old, ok := committed[path]
+			if !ok {
+				expected = append(expected, smt.LeafChange{Kind: smt.LeafInserted, Path: []byte(path), NewValueHash: value})
+			} else if !bytes.Equal(old, value) {
+				expected = append(expected, smt.LeafChange{Kind: smt.LeafUpdated, Path: []byte(path), OldValueHash: old, NewValueHash: value})
+			}
+		}
+		for path, old := range committed {
+			if _, ok := current[path]; !ok {
+				expected = append(expected, smt.LeafChange{Kind: smt.LeafDeleted, Path: []byte(path), OldValueHash: old})
+			}
+		}
+		sort.Slice(expected, func(i, j int) bool { return bytes.Compare(expected[i].Path, expected[j].Path) < 0 })
+		require.Equal(t, expected, changes)
+
+		committed = make(map[string][]byte, len(current))
+		for path, value := range current {
+			committed[path] = value
+		}
+		// Keep resolving the trie from the node store between rounds
+		trie = importWithSubscriber(nodes, trie.Root(), &changes)
+	}
+}
+
+func TestSMST_Subscribe(t *testing.T) {
+	trie := smt.NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	var events []smt.CommitChanges
+	trie.Subscribe(func(changes smt.CommitChanges) { events = append(events, changes) })
+
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v1"), 10))
+	require.NoError(t, trie.Update([]byte("bar"), []byte("v1"), 20))
+	require.NoError(t, trie.Commit())
+	require.NoError(t, trie.Update([]byte("foo"), []byte("v1"), 15))
+	require.NoError(t, trie.Delete([]byte("bar")))
+	require.NoError(t, trie.Commit())
+
+	require.Len(t, events, 2)
+	require.Equal(t, trie.Root(), smt.MerkleSumRoot(events[1].NewRoot))
+	require.Equal(t, events[0].NewRoot, events[1].OldRoot)
+	valueHash := sha256.Sum256([]byte("v1"))
+	requireChanges(t, events[0].Changes, map[string]smt.LeafChange{
+		"foo": {Kind: smt.LeafInserted, NewValueHash: valueHash[:], NewWeight: 10},
+		"bar": {Kind: smt.LeafInserted, NewValueHash: valueHash[:], NewWeight: 20},
+	})
+	requireChanges(t, events[1].Changes, map[string]smt.LeafChange{
+		"foo": {
+			Kind:         smt.LeafUpdated,
+			OldValueHash: valueHash[:],
+			NewValueHash: valueHash[:],
+			OldWeight:    10,
+			NewWeight:    15,
+		},
+		"bar": {Kind: smt.LeafDeleted, OldValueHash: valueHash[:], OldWeight: 20},
+	})
+}
+
+// importWithSubscriber imports a trie recording the changes of its commits
+func importWithSubscriber(nodes simplemap.SimpleMap, root []byte, changes *[]smt.LeafChange) *smt.SMT {
+	trie := smt.ImportSparseMerkleTrie(nodes, sha256.New(), root)
+	trie.Subscribe(func(event smt.CommitChanges) { *changes = event.Changes })
+	return trie
+}
+
+// requireChanges checks that the changes are ordered by path and match the
+// expected changes, given by key
+func requireChanges(t *testing.T, changes []smt.LeafChange, expected map[string]smt.LeafChange) {
+	t.Helper()
+	require.Len(t, changes, len(expected))
+	require.True(t, sort.SliceIsSorted(changes, func(i, j int) bool {
+		return bytes.Compare(changes[i].Path, changes[j].Path) < 0
+	}))
+	byPath := make(map[string]smt.LeafChange, len(changes))
+	for _, change := range changes {
+		byPath[string(change.Path)] = change
+	}
+	for key, change := range expected {
+		path := sha256.Sum256([]byte(key))
+		change.Path = path[:]
+		require.Equal(t, change, byPath[string(change.Path)], key)
+	}
+}
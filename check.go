@@ -0,0 +1,271 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// IssueKind is the kind of a problem found in a trie by `Check`
+type IssueKind string
+
+const (
+	// IssueMissingNode is reported for a node referenced by the trie which
+	// has no record in the node store
+	IssueMissingNode IssueKind = "missing_node"
+	// IssueInvalidEncoding is reported for a node record which cannot be
+	// decoded, the nodes below it cannot be checked
+	IssueInvalidEncoding IssueKind = "invalid_encoding"
+	// IssueDigestMismatch is reported for a node record which does not hash
+	// to the digest it is stored under
+	IssueDigestMismatch IssueKind = "digest_mismatch"
+	// IssueInvalidPathBounds is reported for an extension node which does not
+	// start at its depth in the trie, the nodes below it cannot be checked
+	IssueInvalidPathBounds IssueKind = "invalid_path_bounds"
+	// IssueLeafPathMismatch is reported for a leaf whose path does not lead
+	// to its position in the trie
+	IssueLeafPathMismatch IssueKind = "leaf_path_mismatch"
+	// IssueUnexpectedNode is reported for a node which cannot be found at
+	// its position in the trie, e.g. the child of an extension node which is
+	// not an inner node
+	IssueUnexpectedNode IssueKind = "unexpected_node"
+	// IssueSumMismatch is reported for a sum trie node whose sum or count do
+	// not match the ones of its children, or the ones its parent holds for it
+	IssueSumMismatch IssueKind = "sum_mismatch"
+)
+
+// CheckIssue is a problem found in a trie by `Check`
+type CheckIssue struct {
+	// Kind is the kind of the problem
+	Kind IssueKind
+	// Digest is the digest the offending node is stored under
+	Digest []byte
+	// Depth is the depth of the offending node in the trie
+	Depth int
+	// Err describes the problem, it wraps ErrMissingNode or ErrCorruptNode
+	Err error
+}
+
+// CheckReport is the result of checking a trie with `Check`
+type CheckReport struct {
+	// Root is the root hash of the trie checked
+	Root []byte
+	// Nodes is the number of node records read from the node store
+	Nodes int
+	// Leaves is the number of leaves found in the trie
+	Leaves int
+	// Issues lists the problems found, in the order the trie was walked
+	Issues []CheckIssue
+}
+
+// OK returns true if no problem was found in the trie
+func (report *CheckReport) OK() bool {
+	return len(report.Issues) == 0
+}
+
+// Err returns nil if no problem was found in the trie, and otherwise the
+// problems found joined together as NodeErrors
+func (report *CheckReport) Err() error {
+	errs := make([]error, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		errs = append(errs, &NodeError{Digest: issue.Digest, Err: issue.Err})
+	}
+	return errors.Join(errs...)
+}
+
+// CheckOption configures `Check`
+type CheckOption func(*checkConfig)
+
+type checkConfig struct {
+	stopAtFirstIssue bool
+}
+
+// WithStopAtFirstIssue stops the check as soon as a problem is found, rather
+// than walking the entire trie
+func WithStopAtFirstIssue() CheckOption {
+	return func(cfg *checkConfig) { cfg.stopAtFirstIssue = true }
+}
+
+// Check walks every node of the committed trie with the given root in the
+// node store, and reports any missing node records, node records which do not
+// hash to the digest they are stored under or cannot be decoded, extension
+// nodes whose path bounds do not match their position, leaves whose path does
+// not lead to their position and, for sum tries, sums and counts which do not
+// match the ones of their children.
+//
+// The spec must match the spec the trie was created with. An error is only
+// returned if the node store fails, along with the report of the nodes checked
+// until then.
+func Check(nodes kvstore.MapStore, spec *TrieSpec, root []byte, opts ...CheckOption) (*CheckReport, error) {
+	c := newTrieChecker(nodes, spec)
+	for _, opt := range opts {
+		opt(&c.cfg)
+	}
+	return c.run(root)
+}
+
+// trieChecker walks a committed trie depth first, checking every node
+type trieChecker struct {
+	spec   *TrieSpec
+	nodes  kvstore.MapStore
+	cfg    checkConfig
+	report *CheckReport
+	// The path leading to the node being checked, only the bits above its
+	// depth are set
+	position []byte
+	stopped  bool
+	// visit is called with the digest of every non-empty node before it is
+	// checked, the node is skipped if it returns false
+	visit func(digest []byte) bool
+}
+
+// newTrieChecker returns a trieChecker for the tries stored in the node store
+func newTrieChecker(nodes kvstore.MapStore, spec *TrieSpec) *trieChecker {
+	return &trieChecker{
+		spec:     spec,
+		nodes:    nodes,
+		position: make([]byte, spec.ph.PathSize()),
+	}
+}
+
+// run checks the trie with the given root and returns its report
+func (c *trieChecker) run(root []byte) (*CheckReport, error) {
+	c.report = &CheckReport{Root: root}
+	c.stopped = false
+	if err := c.check(root, 0, false); err != nil {
+		return c.report, err
+	}
+	return c.report, nil
+}
+
+// check checks the node stored under the digest provided at the given depth,
+// extChild is true if the node is the child of an extension node
+func (c *trieChecker) check(digest []byte, depth int, extChild bool) error {
+	if c.stopped {
+		return nil
+	}
+	if bytes.Equal(digest, c.spec.placeholder()) {
+		if extChild {
+			c.issue(IssueUnexpectedNode, digest, depth, "empty child of an extension node")
+		}
+		return nil
+	}
+	if c.visit != nil && !c.visit(digest) {
+		return nil
+	}
+
+	data, err := c.spec.storeGet(c.nodes, digest)
+	if errors.Is(err, kvstore.ErrKeyNotFound) {
+		c.addIssue(IssueMissingNode, digest, depth, fmt.Errorf("%w: %w", ErrMissingNode, err))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.report.Nodes++
+
+	if !c.spec.validLayout(data) {
+		c.addIssue(IssueInvalidEncoding, digest, depth, ErrInvalidNodeEncoding)
+		return nil
+	}
+	c.checkDigest(data, digest, depth)
+	if !c.spec.validSumMeta(data) {
+		c.issue(IssueSumMismatch, digest, depth, "node sum or count does not match the node")
+	}
+
+	pathSize := c.spec.ph.PathSize()
+	switch {
+	case isLeafNode(data):
+		c.report.Leaves++
+		if extChild {
+			c.issue(IssueUnexpectedNode, digest, depth, "leaf child of an extension node")
+		}
+		path := data[prefixLen : prefixLen+pathSize]
+		if match, _ := equalPrefixBits(path, c.position, 0, depth); !match {
+			c.issue(IssueLeafPathMismatch, digest, depth, "leaf path does not lead to its position")
+		}
+		return nil
+
+	case isExtNode(data):
+		if extChild {
+			c.issue(IssueUnexpectedNode, digest, depth, "extension child of an extension node")
+		}
+		start, end := int(data[prefixLen]), int(data[prefixLen+1])
+		if start != depth {
+			c.issue(IssueInvalidPathBounds, digest, depth,
+				fmt.Sprintf("extension node starting at depth %d", start))
+			return nil
+		}
+		path := data[prefixLen+2 : prefixLen+2+pathSize]
+		for i := start; i < end; i++ {
+			c.setPositionBit(i, getPathBit(path, i))
+		}
+		child := data[prefixLen+2+pathSize : prefixLen+2+pathSize+c.spec.hashSize()]
+		return c.check(child, end, true)
+
+	default:
+		if depth >= c.spec.depth() {
+			c.issue(IssueUnexpectedNode, digest, depth, "inner node below the maximum depth")
+			return nil
+		}
+		hashSize := c.spec.hashSize()
+		left := data[prefixLen : prefixLen+hashSize]
+		right := data[prefixLen+hashSize : prefixLen+2*hashSize]
+		if c.spec.sumTrie {
+			node := MerkleSumRoot(data)
+			leftSum, rightSum := MerkleSumRoot(left), MerkleSumRoot(right)
+			if node.sum() != leftSum.sum()+rightSum.sum() || node.count() != leftSum.count()+rightSum.count() {
+				c.issue(IssueSumMismatch, digest, depth, "inner node sum or count does not match its children")
+			}
+		}
+		c.setPositionBit(depth, leftChildBit)
+		if err := c.check(left, depth+1, false); err != nil {
+			return err
+		}
+		c.setPositionBit(depth, 1-leftChildBit)
+		return c.check(right, depth+1, false)
+	}
+}
+
+// checkDigest checks that the node record provided hashes to its digest. The
+// sum and count of a sum trie node are checked separately, as they are not
+// hashed but only copied from the node record.
+func (c *trieChecker) checkDigest(data, digest []byte, depth int) {
+	hash := c.spec.hashPreimage(data)
+	hashSize := c.spec.th.hashSize()
+	if len(digest) != len(hash) || !bytes.Equal(hash[:hashSize], digest[:hashSize]) {
+		c.issue(IssueDigestMismatch, digest, depth, "node does not hash to its digest")
+		return
+	}
+	if !bytes.Equal(hash[hashSize:], digest[hashSize:]) {
+		c.issue(IssueSumMismatch, digest, depth, "node sum or count does not match its parent")
+	}
+}
+
+// setPositionBit sets the bit of the position at the given depth
+func (c *trieChecker) setPositionBit(depth, bit int) {
+	if getPathBit(c.position, depth) != bit {
+		flipPathBit(c.position, depth)
+	}
+}
+
+// issue records a corrupt node
+func (c *trieChecker) issue(kind IssueKind, digest []byte, depth int, msg string) {
+	c.addIssue(kind, digest, depth, fmt.Errorf("%w: %s", ErrCorruptNode, msg))
+}
+
+// addIssue records a problem, stopping the check if configured to
+func (c *trieChecker) addIssue(kind IssueKind, digest []byte, depth int, err error) {
+	if c.stopped {
+		return
+	}
+	c.report.Issues = append(c.report.Issues, CheckIssue{
+		Kind:   kind,
+		Digest: append([]byte{}, digest...),
+		Depth:  depth,
+		Err:    err,
+	})
+	c.stopped = c.cfg.stopAtFirstIssue
+}
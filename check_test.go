@@ -0,0 +1,249 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// issueKinds returns the kinds of the issues of the report
+func issueKinds(report *CheckReport) []IssueKind {
+	kinds := make([]IssueKind, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		kinds = append(kinds, issue.Kind)
+	}
+	return kinds
+}
+
+// requireIssue checks that the report holds an issue of the given kind for
+// the node stored under the digest provided
+func requireIssue(t *testing.T, report *CheckReport, kind IssueKind, digest []byte) {
+	t.Helper()
+	for _, issue := range report.Issues {
+		if issue.Kind == kind && string(issue.Digest) == string(digest) {
+			return
+		}
+	}
+	require.Failf(t, "missing issue", "no %s issue for node %x in %v", kind, digest, issueKinds(report))
+}
+
+// nodesOfType returns the digests and records of the stored nodes for which
+// the predicate returns true
+func (tt *verifyingTestTrie) nodesOfType(t *testing.T, is func([]byte) bool) (digests, nodes [][]byte) {
+	t.Helper()
+	allDigests, allNodes := tt.storedNodes(t)
+	for i, data := range allNodes {
+		if is(data) {
+			digests = append(digests, allDigests[i])
+			nodes = append(nodes, allNodes[i])
+		}
+	}
+	return digests, nodes
+}
+
+func TestCheck_HealthyTrie(t *testing.T) {
+	for _, sumTrie := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sumTrie=%t", sumTrie), func(t *testing.T) {
+			tt := newVerifyingTestTrie(t, sumTrie)
+			report, err := Check(tt.nodes, tt.spec, tt.root)
+			require.NoError(t, err)
+			require.True(t, report.OK(), "%v", issueKinds(report))
+			require.NoError(t, report.Err())
+			require.Equal(t, tt.root, report.Root)
+			require.Equal(t, len(tt.keys), report.Leaves)
+			stored, err := tt.nodes.Len()
+			require.NoError(t, err)
+			require.Equal(t, stored, report.Nodes)
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		spec := NewTrieSpec(sha256.New(), false)
+		trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+		require.NoError(t, trie.Commit())
+		report, err := Check(simplemap.NewSimpleMap(), &spec, trie.Root())
+		require.NoError(t, err)
+		require.True(t, report.OK())
+		require.Zero(t, report.Nodes)
+	})
+}
+
+func TestCheck_MissingNode(t *testing.T) {
+	tt := newVerifyingTestTrie(t, false)
+	digests, _ := tt.nodesOfType(t, isLeafNode)
+	require.NoError(t, tt.nodes.Delete(digests[0]))
+
+	report, err := Check(tt.nodes, tt.spec, tt.root)
+	require.NoError(t, err)
+	require.Equal(t, []IssueKind{IssueMissingNode}, issueKinds(report))
+	require.Equal(t, digests[0], report.Issues[0].Digest)
+	require.ErrorIs(t, report.Issues[0].Err, ErrMissingNode)
+	require.ErrorIs(t, report.Issues[0].Err, kvstore.ErrKeyNotFound)
+	require.Equal(t, len(tt.keys)-1, report.Leaves)
+
+	var nodeErr *NodeError
+	require.ErrorAs(t, report.Err(), &nodeErr)
+	require.Equal(t, digests[0], nodeErr.Digest)
+}
+
+func TestCheck_InvalidEncoding(t *testing.T) {
+	for _, sumTrie := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sumTrie=%t", sumTrie), func(t *testing.T) {
+			tt := newVerifyingTestTrie(t, sumTrie)
+			digests, nodes := tt.nodesOfType(t, isInnerNode)
+			require.NoError(t, tt.nodes.Set(digests[0], nodes[0][:len(nodes[0])-1]))
+
+			report, err := Check(tt.nodes, tt.spec, tt.root)
+			require.NoError(t, err)
+			require.Equal(t, []IssueKind{IssueInvalidEncoding}, issueKinds(report))
+			requireIssue(t, report, IssueInvalidEncoding, digests[0])
+			require.ErrorIs(t, report.Err(), ErrInvalidNodeEncoding)
+			// The leaves below the node cannot be reached
+			require.Less(t, report.Leaves, len(tt.keys))
+		})
+	}
+}
+
+func TestCheck_DigestMismatch(t *testing.T) {
+	tt := newVerifyingTestTrie(t, false)
+	digests, nodes := tt.nodesOfType(t, isLeafNode)
+	path, _ := tt.spec.parseLeafNode(nodes[0])
+	require.NoError(t, tt.nodes.Set(digests[0], encodeLeafNode(path, []byte("forged"))))
+
+	report, err := Check(tt.nodes, tt.spec, tt.root)
+	require.NoError(t, err)
+	require.Equal(t, []IssueKind{IssueDigestMismatch}, issueKinds(report))
+	requireIssue(t, report, IssueDigestMismatch, digests[0])
+	require.ErrorIs(t, report.Err(), ErrCorruptNode)
+}
+
+func TestCheck_InvalidPathBounds(t *testing.T) {
+	tt := newVerifyingTestTrie(t, false)
+	digests, nodes := tt.nodesOfType(t, isExtNode)
+	for i, data := range nodes {
+		if data[prefixLen]+1 >= data[prefixLen+1] {
+			continue
+		}
+		bad := append([]byte{}, data...)
+		bad[prefixLen]++
+		require.NoError(t, tt.nodes.Set(digests[i], bad))
+
+		report, err := Check(tt.nodes, tt.spec, tt.root)
+		require.NoError(t, err)
+		// The path bounds are part of the digest of the node
+		require.Equal(t, []IssueKind{IssueDigestMismatch, IssueInvalidPathBounds}, issueKinds(report))
+		requireIssue(t, report, IssueInvalidPathBounds, digests[i])
+		return
+	}
+	t.Fatal("no extension node to corrupt")
+}
+
+func TestCheck_LeafPathMismatch(t *testing.T) {
+	tt := newVerifyingTestTrie(t, false)
+	digests, nodes := tt.nodesOfType(t, isLeafNode)
+
+	// Store a leaf in place of a leaf on the other side of the root
+	first, _ := tt.spec.parseLeafNode(nodes[0])
+	for i := 1; i < len(nodes); i++ {
+		path, _ := tt.spec.parseLeafNode(nodes[i])
+		if getPathBit(path, 0) == getPathBit(first, 0) {
+			continue
+		}
+		require.NoError(t, tt.nodes.Set(digests[0], nodes[i]))
+
+		report, err := Check(tt.nodes, tt.spec, tt.root)
+		require.NoError(t, err)
+		require.Equal(t, []IssueKind{IssueDigestMismatch, IssueLeafPathMismatch}, issueKinds(report))
+		requireIssue(t, report, IssueLeafPathMismatch, digests[0])
+		return
+	}
+	t.Fatal("no leaf on the other side of the root")
+}
+
+func TestCheck_UnexpectedNode(t *testing.T) {
+	tt := newVerifyingTestTrie(t, false)
+	leaves, _ := tt.nodesOfType(t, isLeafNode)
+	digests, nodes := tt.nodesOfType(t, isExtNode)
+
+	// An extension node whose child is a leaf
+	bad := append([]byte{}, nodes[0]...)
+	copy(bad[prefixLen+2+tt.spec.ph.PathSize():], leaves[0])
+	require.NoError(t, tt.nodes.Set(digests[0], bad))
+
+	report, err := Check(tt.nodes, tt.spec, tt.root)
+	require.NoError(t, err)
+	requireIssue(t, report, IssueDigestMismatch, digests[0])
+	requireIssue(t, report, IssueUnexpectedNode, leaves[0])
+}
+
+func TestCheck_SumMismatch(t *testing.T) {
+	t.Run("inner node", func(t *testing.T) {
+		tt := newVerifyingTestTrie(t, true)
+		digests, nodes := tt.nodesOfType(t, isInnerNode)
+		// Inflate the sum of an inner node
+		bad := append([]byte{}, nodes[0]...)
+		bad[len(bad)-countSizeBytes-1]++
+		require.NoError(t, tt.nodes.Set(digests[0], bad))
+
+		report, err := Check(tt.nodes, tt.spec, tt.root)
+		require.NoError(t, err)
+		require.Equal(t, []IssueKind{IssueDigestMismatch, IssueSumMismatch}, issueKinds(report))
+		requireIssue(t, report, IssueSumMismatch, digests[0])
+	})
+
+	t.Run("extension node", func(t *testing.T) {
+		tt := newVerifyingTestTrie(t, true)
+		digests, nodes := tt.nodesOfType(t, isExtNode)
+		// The sum of an extension node is not part of its digest, but must
+		// match the one of its child
+		bad := append([]byte{}, nodes[0]...)
+		bad[len(bad)-countSizeBytes-1]++
+		require.NoError(t, tt.nodes.Set(digests[0], bad))
+
+		report, err := Check(tt.nodes, tt.spec, tt.root)
+		require.NoError(t, err)
+		require.Equal(t, []IssueKind{IssueSumMismatch}, issueKinds(report))
+		requireIssue(t, report, IssueSumMismatch, digests[0])
+	})
+}
+
+func TestCheck_StopAtFirstIssue(t *testing.T) {
+	tt := newVerifyingTestTrie(t, false)
+	digests, _ := tt.nodesOfType(t, isLeafNode)
+	for _, digest := range digests[:3] {
+		require.NoError(t, tt.nodes.Delete(digest))
+	}
+
+	report, err := Check(tt.nodes, tt.spec, tt.root)
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 3)
+
+	report, err = Check(tt.nodes, tt.spec, tt.root, WithStopAtFirstIssue())
+	require.NoError(t, err)
+	require.Equal(t, []IssueKind{IssueMissingNode}, issueKinds(report))
+}
+
+// failingGetStore fails every `Get`
+type failingGetStore struct {
+	kvstore.MapStore
+}
+
+var errFailingGet = errors.New("get failed")
+
+func (store *failingGetStore) Get(key []byte) ([]byte, error) {
+	return nil, errFailingGet
+}
+
+func TestCheck_StoreError(t *testing.T) {
+	tt := newVerifyingTestTrie(t, false)
+	report, err := Check(&failingGetStore{tt.nodes}, tt.spec, tt.root)
+	require.ErrorIs(t, err, errFailingGet)
+	require.NotNil(t, report)
+	require.True(t, report.OK())
+}
@@ -0,0 +1,88 @@
+package smt
+
+import (
+	"context"
+)
+
+// The ...Ctx methods of the tries are variants of the trie operations which
+// can be cancelled. The context is checked before every node is resolved from
+// or written to the node store, so an operation against a slow store stops at
+// the first such store access following the cancellation and returns the
+// error of the context.
+//
+// Nodes are always resolved, and dirty nodes flushed early, before the trie is
+// modified, so a cancelled mutation leaves the trie untouched. A commit can
+// only be cancelled while it writes the dirty nodes, before any orphaned node
+// is deleted, so a cancelled commit leaves the last committed trie intact and
+// can be retried like any other failed commit.
+
+// GetCtx is a variant of Get which can be cancelled with the context provided
+func (smt *SMT) GetCtx(ctx context.Context, key []byte) (value []byte, err error) {
+	err = smt.withContext(ctx, func() error {
+		value, err = smt.Get(key)
+		return err
+	})
+	return value, err
+}
+
+// UpdateCtx is a variant of Update which can be cancelled with the context
+// provided
+func (smt *SMT) UpdateCtx(ctx context.Context, key, value []byte) error {
+	return smt.withContext(ctx, func() error { return smt.Update(key, value) })
+}
+
+// DeleteCtx is a variant of Delete which can be cancelled with the context
+// provided
+func (smt *SMT) DeleteCtx(ctx context.Context, key []byte) error {
+	return smt.withContext(ctx, func() error { return smt.Delete(key) })
+}
+
+// ProveCtx is a variant of Prove which can be cancelled with the context
+// provided
+func (smt *SMT) ProveCtx(ctx context.Context, key []byte) (proof *SparseMerkleProof, err error) {
+	err = smt.withContext(ctx, func() error {
+		proof, err = smt.Prove(key)
+		return err
+	})
+	return proof, err
+}
+
+// ProveClosestCtx is a variant of ProveClosest which can be cancelled with
+// the context provided
+func (smt *SMT) ProveClosestCtx(ctx context.Context, path []byte) (proof *SparseMerkleClosestProof, err error) {
+	err = smt.withContext(ctx, func() error {
+		proof, err = smt.ProveClosest(path)
+		return err
+	})
+	return proof, err
+}
+
+// CommitCtx is a variant of Commit which can be cancelled with the context
+// provided until all dirty nodes are written. A cancelled commit leaves the
+// nodes it did not write dirty and deletes no orphaned node, so it can be
+// retried. Once every dirty node is written, the orphaned nodes are always
+// deleted to completion, however long it takes: the context is no longer
+// checked, so the deletion cannot be cancelled.
+func (smt *SMT) CommitCtx(ctx context.Context) error {
+	return smt.withContext(ctx, smt.Commit)
+}
+
+// withContext runs the operation provided checking the context before every
+// node store access
+func (smt *SMT) withContext(ctx context.Context, operation func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	smt.ctx = ctx
+	defer func() { smt.ctx = nil }()
+	return operation()
+}
+
+// checkContext returns the error of the context of the current operation, if
+// any
+func (smt *SMT) checkContext() error {
+	if smt.ctx == nil {
+		return nil
+	}
+	return smt.ctx.Err()
+}
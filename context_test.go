@@ -0,0 +1,244 @@
+package smt_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// cancellingStore cancels a context once a number of operations were made on
+// the store it wraps, or on the first deletion
+type cancellingStore struct {
+	kvstore.MapStore
+	remaining int
+	// Whether to cancel the context on the first deletion
+	cancelOnDelete bool
+	cancel         context.CancelFunc
+}
+
+func (s *cancellingStore) countdown() {
+	s.remaining--
+	if s.remaining == 0 {
+		s.cancel()
+	}
+}
+
+func (s *cancellingStore) Get(key []byte) ([]byte, error) {
+	s.countdown()
+	return s.MapStore.Get(key)
+}
+
+func (s *cancellingStore) Set(key, value []byte) error {
+	s.countdown()
+	return s.MapStore.Set(key, value)
+}
+
+func (s *cancellingStore) Delete(key []byte) error {
+	s.countdown()
+	if s.cancelOnDelete {
+		s.cancel()
+	}
+	return s.MapStore.Delete(key)
+}
+
+// newCancellingTrieStore returns a store holding a committed trie with the
+// first `faultTestKeys` keys, along with its root and a context cancelled
+// after the given number of store operations
+func newCancellingTrieStore(t *testing.T, operations int) (*cancellingStore, []byte, context.Context) {
+	t.Helper()
+	store, root := newFaultyTrieStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &cancellingStore{MapStore: store, remaining: operations, cancel: cancel}, root, ctx
+}
+
+func TestSMT_Context_Cancelled(t *testing.T) {
+	store, root, _ := newCancellingTrieStore(t, -1)
+	trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := trie.GetCtx(ctx, faultTestKey(0))
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorIs(t, trie.UpdateCtx(ctx, faultTestKey(0), []byte("other")), context.Canceled)
+	require.ErrorIs(t, trie.DeleteCtx(ctx, faultTestKey(0)), context.Canceled)
+	_, err = trie.ProveCtx(ctx, faultTestKey(0))
+	require.ErrorIs(t, err, context.Canceled)
+	path := sha256.Sum256(faultTestKey(0))
+	_, err = trie.ProveClosestCtx(ctx, path[:])
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorIs(t, trie.CommitCtx(ctx), context.Canceled)
+	// The node store is never accessed once the context is done
+	require.Equal(t, -1, store.remaining)
+
+	// The context only applies to the operation it was provided to
+	value, err := trie.Get(faultTestKey(0))
+	require.NoError(t, err)
+	require.NotNil(t, value)
+}
+
+func TestSMT_Context_Traversal(t *testing.T) {
+	tests := []struct {
+		desc    string
+		operate func(ctx context.Context, trie *smt.SMT) error
+	}{
+		{
+			desc: "GetCtx",
+			operate: func(ctx context.Context, trie *smt.SMT) error {
+				_, err := trie.GetCtx(ctx, faultTestKey(0))
+				return err
+			},
+		},
+		{
+			desc: "ProveCtx",
+			operate: func(ctx context.Context, trie *smt.SMT) error {
+				_, err := trie.ProveCtx(ctx, faultTestKey(0))
+				return err
+			},
+		},
+		{
+			desc: "ProveClosestCtx",
+			operate: func(ctx context.Context, trie *smt.SMT) error {
+				path := sha256.Sum256(faultTestKey(0))
+				_, err := trie.ProveClosestCtx(ctx, path[:])
+				return err
+			},
+		},
+		{
+			desc: "UpdateCtx",
+			operate: func(ctx context.Context, trie *smt.SMT) error {
+				return trie.UpdateCtx(ctx, faultTestKey(0), []byte("other"))
+			},
+		},
+		{
+			desc: "DeleteCtx",
+			operate: func(ctx context.Context, trie *smt.SMT) error {
+				return trie.DeleteCtx(ctx, faultTestKey(0))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			// The context is cancelled while the first node is read
+			store, root, ctx := newCancellingTrieStore(t, 1)
+			trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+			require.ErrorIs(t, tt.operate(ctx, trie), context.Canceled)
+			require.Equal(t, 0, store.remaining)
+			require.Equal(t, root, []byte(trie.Root()))
+
+			// The trie is left untouched and can be used again
+			require.NoError(t, tt.operate(context.Background(), trie))
+		})
+	}
+}
+
+func TestSMT_Context_Commit(t *testing.T) {
+	store, root, ctx := newCancellingTrieStore(t, -1)
+	trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+	for i := faultTestKeys; i < 2*faultTestKeys; i++ {
+		require.NoError(t, trie.Update(faultTestKey(i), []byte("value")))
+	}
+	require.NoError(t, trie.Delete(faultTestKey(0)))
+
+	// Cancel the commit after a few of its writes
+	store.remaining = 10
+	require.ErrorIs(t, trie.CommitCtx(ctx), context.Canceled)
+	require.Equal(t, 0, store.remaining)
+
+	// The commit can be retried once cancelled
+	require.NoError(t, trie.CommitCtx(context.Background()))
+	expected := expectedRoot(t, 1, 2*faultTestKeys)
+	require.Equal(t, expected, []byte(trie.Root()))
+	requireTrieInStore(t, store.MapStore.(*kvstoretest.FaultyMapStore), expected)
+}
+
+func TestSMT_Context_CommitKeepsCommittedRoot(t *testing.T) {
+	for _, operations := range []int{1, 10} {
+		store, root, ctx := newCancellingTrieStore(t, -1)
+		trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+		for i := 0; i < faultTestKeys; i++ {
+			require.NoError(t, trie.Update(faultTestKey(i), []byte("other")))
+		}
+
+		// Nothing is deleted by a commit cancelled while writing nodes, so the
+		// last committed trie can still be reopened
+		store.remaining = operations
+		require.ErrorIs(t, trie.CommitCtx(ctx), context.Canceled)
+		requireTrieInStore(t, store.MapStore.(*kvstoretest.FaultyMapStore), root)
+	}
+}
+
+func TestSMT_Context_CommitDeletions(t *testing.T) {
+	store, root, ctx := newCancellingTrieStore(t, -1)
+	trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+	require.NoError(t, trie.Delete(faultTestKey(0)))
+	// An update back to the same value writes the orphaned nodes again
+	require.NoError(t, trie.Update(faultTestKey(1), []byte("other")))
+	require.NoError(t, trie.Update(faultTestKey(1), []byte("value")))
+
+	// The deletion of the orphans is not interrupted by the context
+	store.cancelOnDelete = true
+	require.NoError(t, trie.CommitCtx(ctx))
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+	expected := expectedRoot(t, 1, faultTestKeys)
+	require.Equal(t, expected, []byte(trie.Root()))
+	requireTrieInStore(t, store.MapStore.(*kvstoretest.FaultyMapStore), expected)
+
+	// Every orphan was deleted, except those written again
+	report, err := smt.Check(store, trie.Spec(), expected)
+	require.NoError(t, err)
+	require.True(t, report.OK())
+	stored, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, report.Nodes, stored)
+}
+
+func TestSMT_Context_DirtyFlush(t *testing.T) {
+	store, root, ctx := newCancellingTrieStore(t, -1)
+	trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root, smt.WithMaxDirtyNodes(16))
+	for i := faultTestKeys; i < faultTestKeys+8; i++ {
+		require.NoError(t, trie.Update(faultTestKey(i), []byte("value")))
+	}
+
+	// A mutation cancelled while flushing the dirty nodes ahead of it is not
+	// applied
+	store.remaining = 1
+	before := trie.Root()
+	require.ErrorIs(t, trie.UpdateCtx(ctx, faultTestKey(0), []byte("other")), context.Canceled)
+	require.Equal(t, before, trie.Root())
+	value, err := trie.Get(faultTestKey(0))
+	require.NoError(t, err)
+	valueHash := sha256.Sum256([]byte("value"))
+	require.Equal(t, valueHash[:], value)
+}
+
+func TestSMST_Context(t *testing.T) {
+	trie := smt.NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	ctx := context.Background()
+	require.NoError(t, trie.UpdateCtx(ctx, []byte("foo"), []byte("bar"), 10))
+	require.NoError(t, trie.CommitCtx(ctx))
+	valueHash, weight, err := trie.GetCtx(ctx, []byte("foo"))
+	require.NoError(t, err)
+	expected := sha256.Sum256([]byte("bar"))
+	require.Equal(t, expected[:], valueHash)
+	require.Equal(t, uint64(10), weight)
+	proof, err := trie.ProveCtx(ctx, []byte("foo"))
+	require.NoError(t, err)
+	valid, err := smt.VerifySumProof(proof, trie.Root(), []byte("foo"), []byte("bar"), 10, 1, trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+	require.NoError(t, trie.DeleteCtx(ctx, []byte("foo")))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, trie.CommitCtx(cancelled), context.Canceled)
+	require.NoError(t, trie.CommitCtx(ctx))
+	require.Equal(t, uint64(0), trie.MustCount())
+}
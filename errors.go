@@ -2,6 +2,7 @@ package smt
 
 import (
 	"errors"
+	"fmt"
 )
 
 var (
@@ -12,4 +13,61 @@ var (
 	// ErrInvalidClosestPath is returned when the path used in the ClosestProof
 	// method does not match the size of the trie's PathHasher
 	ErrInvalidClosestPath = errors.New("invalid path does not match path hasher size")
+	// ErrBatchLengthMismatch is returned when the number of keys provided to a
+	// batched operation does not match the number of values (or weights)
+	ErrBatchLengthMismatch = errors.New("batch keys and values have different lengths")
+	// ErrInvalidLeafPath is returned when a leaf provided to the bulk loader
+	// has a path that does not match the size of the trie's PathHasher
+	ErrInvalidLeafPath = errors.New("invalid leaf path does not match path hasher size")
+	// ErrUnsortedLeaves is returned when the leaves provided to the bulk loader
+	// are not strictly sorted by path
+	ErrUnsortedLeaves = errors.New("leaves are not strictly sorted by path")
+	// ErrInvalidSubtriePrefix is returned when the prefix of a subtrie is
+	// longer than the prefix bytes provided or the paths of the trie
+	ErrInvalidSubtriePrefix = errors.New("invalid subtrie prefix")
+	// ErrLeafOutsidePrefix is returned when a leaf provided to a subtrie does
+	// not share the subtrie's path prefix
+	ErrLeafOutsidePrefix = errors.New("leaf path is outside of the subtrie prefix")
+	// ErrOverlappingSubtries is returned when grafting subtries whose path
+	// prefixes are not disjoint
+	ErrOverlappingSubtries = errors.New("subtrie prefixes overlap")
+	// ErrIncompatibleSubtrie is returned when grafting a subtrie that was built
+	// for a different type of trie than the one it is being grafted into
+	ErrIncompatibleSubtrie = errors.New("subtrie does not match the trie spec")
+	// ErrCorruptNode is returned when a node read from the node store does
+	// not hash to the digest it is stored under, or is inconsistent with its
+	// position in the trie
+	ErrCorruptNode = errors.New("corrupt node")
+	// ErrInvalidNodeEncoding is returned when a node read from the node store
+	// cannot be decoded, it wraps ErrCorruptNode
+	ErrInvalidNodeEncoding = fmt.Errorf("%w: invalid encoding", ErrCorruptNode)
+	// ErrMissingNode is returned when a node referenced by the trie is not
+	// present in the node store
+	ErrMissingNode = errors.New("missing node")
+	// ErrIncompleteTrie is returned by the garbage collection when a live
+	// trie cannot be walked entirely, e.g. because of a missing node
+	ErrIncompleteTrie = errors.New("trie cannot be walked entirely")
+	// ErrInvalidDepth is returned when inserting a leaf would require an
+	// extension node deeper than its path bounds can encode
+	ErrInvalidDepth = errors.New("depth exceeds the maximum extension node depth")
 )
+
+// NodeError is returned when a node of the trie cannot be resolved from the
+// node store, it records the digest of the offending node and wraps one of
+// ErrCorruptNode, ErrInvalidNodeEncoding or ErrMissingNode.
+type NodeError struct {
+	// Digest is the digest the node is stored under
+	Digest []byte
+	// Err is the cause of the error
+	Err error
+}
+
+// Error satisfies the error interface
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("node %x: %v", e.Digest, e.Err)
+}
+
+// Unwrap returns the cause of the error
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,145 @@
+package smt
+
+import "sort"
+
+// touch records that the node was accessed by the current trie operation.
+// Access epochs are only tracked when the number of cached nodes is bounded.
+func (smt *SMT) touch(node trieNode) {
+	if smt.maxCachedNodes <= 0 {
+		return
+	}
+	switch n := node.(type) {
+	case *leafNode:
+		n.accessed = smt.epoch
+	case *innerNode:
+		n.accessed = smt.epoch
+	case *extensionNode:
+		n.accessed = smt.epoch
+	}
+}
+
+// flushDirtyNodes is called before a mutating trie operation modifies the
+// trie, so that a node store error leaves the trie untouched. It writes all
+// dirty nodes to the node store once their number exceeds the configured
+// maximum. The running count is only an estimate, as the same node may be
+// dirtied by several operations, so the dirty nodes are counted exactly before
+// deciding to flush them. Flushing only happens when more than half of the
+// budget is in use, so that the cost of counting is amortised over the
+// operations that follow.
+//
+// The orphaned nodes are not deleted, as they belong to the last committed
+// trie which must remain intact in the node store until the next commit.
+func (smt *SMT) flushDirtyNodes() error {
+	if smt.maxDirtyNodes <= 0 || smt.dirtyNodes <= smt.maxDirtyNodes {
+		return nil
+	}
+	smt.dirtyNodes = countDirtyNodes(smt.root)
+	if smt.dirtyNodes <= smt.maxDirtyNodes/2 {
+		return nil
+	}
+	return smt.writeDirtyNodes(&smt.flushedNodes)
+}
+
+// evictCachedNodes is called at the end of every trie operation. Once the
+// number of clean nodes held in memory exceeds the configured maximum, the
+// least recently accessed clean subtries are replaced by lazy nodes, leaving
+// at most half of the budget in use. The nodes accessed by the most recent
+// operation are never evicted.
+func (smt *SMT) evictCachedNodes() {
+	defer func() { smt.epoch++ }()
+	if smt.maxCachedNodes <= 0 || smt.cachedNodes <= smt.maxCachedNodes {
+		return
+	}
+	var epochs []uint64
+	walkCleanNodes(smt.root, func(accessed uint64) {
+		epochs = append(epochs, accessed)
+	})
+	keep := smt.maxCachedNodes / 2
+	if len(epochs) > keep {
+		// Evict every clean node accessed before the epoch of the most
+		// recently accessed node that does not fit in the budget
+		sort.Slice(epochs, func(i, j int) bool { return epochs[i] > epochs[j] })
+		cutoff := epochs[keep] + 1
+		if cutoff > smt.epoch {
+			cutoff = smt.epoch
+		}
+		smt.root = evictNodes(smt.root, 0, cutoff)
+	}
+	smt.cachedNodes = 0
+	walkCleanNodes(smt.root, func(uint64) { smt.cachedNodes++ })
+}
+
+// evictNodes replaces every clean node in the subtrie rooted at the given depth
+// last accessed before the cutoff epoch with a lazy node, and returns the new
+// root of the subtrie. Since dirty nodes can only have clean descendants, a
+// clean node can be evicted along with its entire subtrie.
+func evictNodes(node trieNode, depth int, cutoff uint64) trieNode {
+	if node == nil || isLazyNode(node) {
+		return node
+	}
+	if node.Persisted() && accessedEpoch(node) < cutoff {
+		return &lazyNode{digest: node.CachedDigest(), depth: depth}
+	}
+	switch n := node.(type) {
+	case *innerNode:
+		n.leftChild = evictNodes(n.leftChild, depth+1, cutoff)
+		n.rightChild = evictNodes(n.rightChild, depth+1, cutoff)
+	case *extensionNode:
+		n.child = evictNodes(n.child, n.pathEnd(), cutoff)
+	}
+	return node
+}
+
+// walkCleanNodes calls fn with the access epoch of every clean node held in
+// memory in the subtrie
+func walkCleanNodes(node trieNode, fn func(accessed uint64)) {
+	if node == nil || isLazyNode(node) {
+		return
+	}
+	if node.Persisted() {
+		fn(accessedEpoch(node))
+	}
+	switch n := node.(type) {
+	case *innerNode:
+		walkCleanNodes(n.leftChild, fn)
+		walkCleanNodes(n.rightChild, fn)
+	case *extensionNode:
+		walkCleanNodes(n.child, fn)
+	}
+}
+
+// countDirtyNodes returns the number of dirty nodes in the subtrie. Only the
+// dirty nodes need to be descended into, as clean nodes never have dirty
+// descendants.
+func countDirtyNodes(node trieNode) int {
+	if node == nil || node.Persisted() {
+		return 0
+	}
+	switch n := node.(type) {
+	case *innerNode:
+		return 1 + countDirtyNodes(n.leftChild) + countDirtyNodes(n.rightChild)
+	case *extensionNode:
+		return 1 + countDirtyNodes(n.child)
+	}
+	return 1
+}
+
+// accessedEpoch returns the epoch of the trie operation that last accessed
+// the node
+func accessedEpoch(node trieNode) uint64 {
+	switch n := node.(type) {
+	case *leafNode:
+		return n.accessed
+	case *innerNode:
+		return n.accessed
+	case *extensionNode:
+		return n.accessed
+	}
+	return 0
+}
+
+// isLazyNode returns true if the node has not been resolved from the node store
+func isLazyNode(node trieNode) bool {
+	_, ok := node.(*lazyNode)
+	return ok
+}
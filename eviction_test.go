@@ -0,0 +1,197 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestSMT_MaxCachedNodes(t *testing.T) {
+	keys, values := randomBatch(t, 1000, 6)
+
+	nodes := simplemap.NewSimpleMap()
+	smt := NewSparseMerkleTrie(nodes, sha256.New())
+	require.NoError(t, smt.UpdateBatch(keys, values))
+	require.NoError(t, smt.Commit())
+
+	const maxCachedNodes = 100
+	bounded := ImportSparseMerkleTrie(nodes, sha256.New(), smt.Root(), WithMaxCachedNodes(maxCachedNodes))
+	for i, key := range keys {
+		value, err := bounded.Get(key)
+		require.NoError(t, err)
+		require.Equal(t, bounded.valueHash(values[i]), value)
+
+		// The nodes of the last accessed path may exceed the budget
+		cached := 0
+		walkCleanNodes(bounded.root, func(uint64) { cached++ })
+		require.LessOrEqual(t, cached, maxCachedNodes+bounded.depth())
+		require.Equal(t, cached, bounded.cachedNodes)
+
+		// The leaf that was just accessed must still be resolved
+		leaf := findLeaf(bounded.root, bounded.ph.Path(key))
+		require.NotNil(t, leaf)
+	}
+
+	// Proofs generated from the bounded trie must still be valid
+	proof, err := bounded.Prove(keys[0])
+	require.NoError(t, err)
+	valid, err := VerifyProof(proof, smt.Root(), keys[0], values[0], &smt.TrieSpec)
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func TestSMT_MaxDirtyNodes(t *testing.T) {
+	keys, values := randomBatch(t, 500, 7)
+
+	unboundedNodes := simplemap.NewSimpleMap()
+	unbounded := NewSparseMerkleTrie(unboundedNodes, sha256.New())
+
+	nodes := simplemap.NewSimpleMap()
+	bounded := NewSparseMerkleTrie(nodes, sha256.New(),
+		WithMaxDirtyNodes(200), WithMaxCachedNodes(200))
+	for i := range keys {
+		require.NoError(t, unbounded.Update(keys[i], values[i]))
+		require.NoError(t, bounded.Update(keys[i], values[i]))
+		require.LessOrEqual(t, countDirtyNodes(bounded.root), 200+bounded.depth())
+	}
+	// Dirty nodes must have been written to the store ahead of the commit
+	length, err := nodes.Len()
+	require.NoError(t, err)
+	require.NotZero(t, length)
+	require.Equal(t, unbounded.Root(), bounded.Root())
+
+	// Deleting and overwriting flushed nodes must orphan them correctly
+	for i := range keys[:100] {
+		require.NoError(t, unbounded.Delete(keys[i]))
+		require.NoError(t, bounded.Delete(keys[i]))
+		require.NoError(t, unbounded.Update(keys[i+100], values[i]))
+		require.NoError(t, bounded.Update(keys[i+100], values[i]))
+	}
+	require.Equal(t, unbounded.Root(), bounded.Root())
+
+	require.NoError(t, unbounded.Commit())
+	require.NoError(t, bounded.Commit())
+	unboundedLength, err := unboundedNodes.Len()
+	require.NoError(t, err)
+	length, err = nodes.Len()
+	require.NoError(t, err)
+	require.Equal(t, unboundedLength, length)
+}
+
+func TestSMT_MaxDirtyNodes_CommittedRoot(t *testing.T) {
+	keys, values := randomBatch(t, 64, 9)
+	nodes := simplemap.NewSimpleMap()
+	trie := NewSparseMerkleTrie(nodes, sha256.New(), WithMaxDirtyNodes(8))
+	for i := range keys[:4] {
+		require.NoError(t, trie.Update(keys[i], values[i]))
+	}
+	require.NoError(t, trie.Commit())
+	committed := trie.Root()
+
+	// Flushing dirty nodes ahead of the commit must not delete the nodes of
+	// the last committed trie, which remains usable if the trie is abandoned
+	before, err := nodes.Len()
+	require.NoError(t, err)
+	for i := range keys {
+		require.NoError(t, trie.Update(keys[i], []byte("updated")))
+	}
+	after, err := nodes.Len()
+	require.NoError(t, err)
+	require.Greater(t, after, before)
+
+	reopened := ImportSparseMerkleTrie(nodes, sha256.New(), committed)
+	for i := range keys[:4] {
+		valueHash, err := reopened.Get(keys[i])
+		require.NoError(t, err)
+		require.Equal(t, reopened.valueHash(values[i]), valueHash)
+	}
+	spec := NewTrieSpec(sha256.New(), false)
+	report, err := Check(nodes, &spec, committed)
+	require.NoError(t, err)
+	require.True(t, report.OK())
+}
+
+func TestSMT_MaxDirtyNodes_RewrittenOrphans(t *testing.T) {
+	keys, values := randomBatch(t, 50, 10)
+	nodes := simplemap.NewSimpleMap()
+	// Every mutation flushes the nodes dirtied by the previous one
+	trie := NewSparseMerkleTrie(nodes, sha256.New(), WithMaxDirtyNodes(1))
+	require.NoError(t, trie.UpdateBatch(keys, values))
+	require.NoError(t, trie.Commit())
+	committed := trie.Root()
+
+	// Nodes orphaned then written again by a flush are part of the trie
+	// again, and must not be deleted by the commit
+	for i := range keys[:10] {
+		require.NoError(t, trie.Update(keys[i], []byte("updated")))
+		require.NoError(t, trie.Update(keys[i], values[i]))
+	}
+	require.NoError(t, trie.Delete(keys[10]))
+	require.NoError(t, trie.Update(keys[10], values[10]))
+	require.NoError(t, trie.Commit())
+	require.Equal(t, committed, trie.Root())
+
+	spec := NewTrieSpec(sha256.New(), false)
+	report, err := Check(nodes, &spec, committed)
+	require.NoError(t, err)
+	require.True(t, report.OK())
+	// The nodes flushed and orphaned in between were all deleted
+	stored, err := nodes.Len()
+	require.NoError(t, err)
+	require.Equal(t, report.Nodes, stored)
+}
+
+func TestSMST_MaxCachedNodes(t *testing.T) {
+	keys, values := randomBatch(t, 300, 8)
+
+	nodes := simplemap.NewSimpleMap()
+	smst := NewSparseMerkleSumTrie(nodes, sha256.New(),
+		WithMaxCachedNodes(50), WithMaxDirtyNodes(50))
+	unbounded := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := range keys {
+		require.NoError(t, smst.Update(keys[i], values[i], uint64(i)))
+		require.NoError(t, unbounded.Update(keys[i], values[i], uint64(i)))
+	}
+	require.NoError(t, smst.Commit())
+	require.Equal(t, unbounded.Root(), smst.Root())
+
+	for i, key := range keys {
+		valueHash, weight, err := smst.Get(key)
+		require.NoError(t, err)
+		require.Equal(t, smst.valueHash(values[i]), valueHash)
+		require.Equal(t, uint64(i), weight)
+	}
+	cached := 0
+	walkCleanNodes(smst.root, func(uint64) { cached++ })
+	require.LessOrEqual(t, cached, 50+smst.depth())
+}
+
+// findLeaf returns the resolved leaf at the given path, or nil if the leaf is
+// not held in memory
+func findLeaf(node trieNode, path []byte) *leafNode {
+	for depth := 0; ; depth++ {
+		switch n := node.(type) {
+		case *leafNode:
+			return n
+		case *extensionNode:
+			depth += n.length()
+			node = n.child
+			if inner, ok := node.(*innerNode); ok {
+				node = inner.leftChild
+				if getPathBit(path, depth) != leftChildBit {
+					node = inner.rightChild
+				}
+			}
+		case *innerNode:
+			node = n.leftChild
+			if getPathBit(path, depth) != leftChildBit {
+				node = n.rightChild
+			}
+		default:
+			return nil
+		}
+	}
+}
@@ -24,6 +24,8 @@ type extensionNode struct {
 	persisted bool
 	// The cached digest of the node trie
 	digest []byte
+	// The epoch of the trie operation that last accessed the node
+	accessed uint64
 }
 
 // Persisted satisfied the trieNode#Persisted interface
@@ -0,0 +1,292 @@
+package smt_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+const faultTestKeys = 64
+
+func faultTestKey(i int) []byte {
+	return []byte(fmt.Sprintf("key-%d", i))
+}
+
+// newFaultyTrieStore returns a faulty store wrapping a store holding a
+// committed trie with the first `faultTestKeys` keys, along with its root
+func newFaultyTrieStore(t *testing.T) (*kvstoretest.FaultyMapStore, []byte) {
+	t.Helper()
+	nodes := simplemap.NewSimpleMap()
+	trie := smt.NewSparseMerkleTrie(nodes, sha256.New())
+	for i := 0; i < faultTestKeys; i++ {
+		require.NoError(t, trie.Update(faultTestKey(i), []byte("value")))
+	}
+	require.NoError(t, trie.Commit())
+	return kvstoretest.NewFaultyMapStore(nodes), trie.Root()
+}
+
+// expectedRoot returns the root of a trie holding the keys in [start, end)
+func expectedRoot(t *testing.T, start, end int, extra ...[]byte) []byte {
+	t.Helper()
+	trie := smt.NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	for i := start; i < end; i++ {
+		require.NoError(t, trie.Update(faultTestKey(i), []byte("value")))
+	}
+	for _, key := range extra {
+		require.NoError(t, trie.Update(key, []byte("value")))
+	}
+	return trie.Root()
+}
+
+func TestSMT_FaultyStore_Mutations(t *testing.T) {
+	newKeys := [][]byte{[]byte("new-0"), []byte("new-1"), []byte("new-2")}
+	tests := []struct {
+		desc     string
+		mutate   func(trie *smt.SMT) error
+		wantRoot func(t *testing.T) []byte
+	}{
+		{
+			desc:     "Update",
+			mutate:   func(trie *smt.SMT) error { return trie.Update(newKeys[0], []byte("value")) },
+			wantRoot: func(t *testing.T) []byte { return expectedRoot(t, 0, faultTestKeys, newKeys[0]) },
+		},
+		{
+			desc:     "Delete",
+			mutate:   func(trie *smt.SMT) error { return trie.Delete(faultTestKey(0)) },
+			wantRoot: func(t *testing.T) []byte { return expectedRoot(t, 1, faultTestKeys) },
+		},
+		{
+			desc: "UpdateBatch",
+			mutate: func(trie *smt.SMT) error {
+				return trie.UpdateBatch(newKeys, [][]byte{[]byte("value"), []byte("value"), []byte("value")})
+			},
+			wantRoot: func(t *testing.T) []byte { return expectedRoot(t, 0, faultTestKeys, newKeys...) },
+		},
+		{
+			desc: "DeleteBatch",
+			mutate: func(trie *smt.SMT) error {
+				keys := make([][]byte, 0, faultTestKeys/2)
+				for i := 0; i < faultTestKeys/2; i++ {
+					keys = append(keys, faultTestKey(i))
+				}
+				return trie.DeleteBatch(keys)
+			},
+			wantRoot: func(t *testing.T) []byte { return expectedRoot(t, faultTestKeys/2, faultTestKeys) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			wantRoot := tt.wantRoot(t)
+			// Fail every possible read of the mutation until it succeeds
+			for failAfter := 0; ; failAfter++ {
+				store, root := newFaultyTrieStore(t)
+				trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+				store.FailAfter(kvstoretest.OpGet, failAfter)
+				err := tt.mutate(trie)
+				store.Reset()
+				if err == nil {
+					require.NoError(t, trie.Commit())
+					require.Equal(t, wantRoot, []byte(trie.Root()))
+					return
+				}
+				require.ErrorIs(t, err, kvstoretest.ErrInjectedFault)
+
+				// The trie is left untouched and can be used once the store recovers
+				require.Equal(t, root, []byte(trie.Root()))
+				require.NoError(t, tt.mutate(trie))
+				require.NoError(t, trie.Commit())
+				require.Equal(t, wantRoot, []byte(trie.Root()))
+				requireTrieInStore(t, store, trie.Root())
+			}
+		})
+	}
+}
+
+func TestSMT_FaultyStore_Commit(t *testing.T) {
+	store, root := newFaultyTrieStore(t)
+	trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+	for i := 0; i < faultTestKeys/2; i++ {
+		require.NoError(t, trie.Delete(faultTestKey(i)))
+	}
+	for i := faultTestKeys; i < 2*faultTestKeys; i++ {
+		require.NoError(t, trie.Update(faultTestKey(i), []byte("value")))
+	}
+	wantRoot := expectedRoot(t, faultTestKeys/2, 2*faultTestKeys)
+	require.Equal(t, wantRoot, []byte(trie.Root()))
+
+	// Retry the commit with increasingly later write failures until it succeeds
+	failures := 0
+	for failAfter := 0; ; failAfter += 7 {
+		store.FailAfter(kvstoretest.OpSet|kvstoretest.OpDelete, failAfter)
+		err := trie.Commit()
+		store.Reset()
+		if err == nil {
+			break
+		}
+		require.ErrorIs(t, err, kvstoretest.ErrInjectedFault)
+		require.Equal(t, wantRoot, []byte(trie.Root()))
+		failures++
+	}
+	require.Greater(t, failures, 1)
+	require.Equal(t, wantRoot, []byte(trie.Root()))
+	requireTrieInStore(t, store, trie.Root())
+
+	// The store holds exactly the nodes of a trie committed in one go
+	nodes := simplemap.NewSimpleMap()
+	reference := smt.NewSparseMerkleTrie(nodes, sha256.New())
+	for i := faultTestKeys / 2; i < 2*faultTestKeys; i++ {
+		require.NoError(t, reference.Update(faultTestKey(i), []byte("value")))
+	}
+	require.NoError(t, reference.Commit())
+	wantLen, err := nodes.Len()
+	require.NoError(t, err)
+	gotLen, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, wantLen, gotLen)
+}
+
+func TestSMT_FaultyStore_DirtyFlush(t *testing.T) {
+	store, root := newFaultyTrieStore(t)
+	trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root, smt.WithMaxDirtyNodes(16))
+
+	// A failed flush ahead of a mutation fails it before the trie is modified
+	failures := 0
+	for i := faultTestKeys; i < 2*faultTestKeys; i++ {
+		store.FailAfter(kvstoretest.OpSet, i%5)
+		before := trie.Root()
+		err := trie.Update(faultTestKey(i), []byte("value"))
+		store.Reset()
+		if err != nil {
+			require.ErrorIs(t, err, kvstoretest.ErrInjectedFault)
+			require.Equal(t, before, trie.Root())
+			require.NoError(t, trie.Update(faultTestKey(i), []byte("value")))
+			failures++
+		}
+	}
+	require.Positive(t, failures)
+	require.NoError(t, trie.Commit())
+	require.Equal(t, expectedRoot(t, 0, 2*faultTestKeys), []byte(trie.Root()))
+	requireTrieInStore(t, store, trie.Root())
+}
+
+func TestSMT_FaultyStore_Reads(t *testing.T) {
+	store, root := newFaultyTrieStore(t)
+	key := faultTestKey(7)
+	keys := [][]byte{faultTestKey(1), faultTestKey(2), key}
+	reads := map[string]func(trie *smt.SMT) error{
+		"Get": func(trie *smt.SMT) error {
+			_, err := trie.Get(key)
+			return err
+		},
+		"GetMany": func(trie *smt.SMT) error {
+			_, err := trie.GetMany(keys)
+			return err
+		},
+		"Prove": func(trie *smt.SMT) error {
+			_, err := trie.Prove(key)
+			return err
+		},
+		"ProveClosest": func(trie *smt.SMT) error {
+			path := sha256.Sum256(key)
+			_, err := trie.ProveClosest(path[:])
+			return err
+		},
+	}
+	for desc, read := range reads {
+		t.Run(desc, func(t *testing.T) {
+			for failAfter := 0; ; failAfter++ {
+				trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+				store.FailAfter(kvstoretest.OpGet, failAfter)
+				err := read(trie)
+				store.Reset()
+				if err == nil {
+					break
+				}
+				require.ErrorIs(t, err, kvstoretest.ErrInjectedFault)
+
+				// A failed read must not lose any part of the trie
+				require.Equal(t, root, []byte(trie.Root()))
+				require.NoError(t, read(trie))
+				value, err := trie.Get(key)
+				require.NoError(t, err)
+				require.NotNil(t, value)
+				proof, err := trie.Prove(key)
+				require.NoError(t, err)
+				valid, err := smt.VerifyProof(proof, root, key, []byte("value"), trie.Spec())
+				require.NoError(t, err)
+				require.True(t, valid)
+			}
+		})
+	}
+}
+
+func TestSMT_FaultyStore_FailKeys(t *testing.T) {
+	store, root := newFaultyTrieStore(t)
+	trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+
+	// Without its root node nothing can be read from the trie
+	store.FailKeys(kvstoretest.OpGet, root)
+	_, err := trie.Get(faultTestKey(0))
+	require.ErrorIs(t, err, kvstoretest.ErrInjectedFault)
+	require.ErrorIs(t, trie.Update(faultTestKey(0), []byte("new")), kvstoretest.ErrInjectedFault)
+	require.ErrorIs(t, trie.Delete(faultTestKey(0)), kvstoretest.ErrInjectedFault)
+	_, err = trie.Prove(faultTestKey(0))
+	require.ErrorIs(t, err, kvstoretest.ErrInjectedFault)
+
+	// Committing an untouched trie does not access the store
+	require.NoError(t, trie.Commit())
+	require.Equal(t, root, []byte(trie.Root()))
+
+	store.Reset()
+	require.NoError(t, trie.Update(faultTestKey(faultTestKeys), []byte("value")))
+	require.NoError(t, trie.Commit())
+	requireTrieInStore(t, store, trie.Root())
+}
+
+func TestSMST_FaultyStore_Commit(t *testing.T) {
+	store := kvstoretest.NewFaultyMapStore(simplemap.NewSimpleMap())
+	trie := smt.NewSparseMerkleSumTrie(store, sha256.New())
+	for i := 0; i < faultTestKeys; i++ {
+		require.NoError(t, trie.Update(faultTestKey(i), []byte("value"), uint64(i)))
+	}
+	store.FailAfter(kvstoretest.OpSet, faultTestKeys)
+	require.ErrorIs(t, trie.Commit(), kvstoretest.ErrInjectedFault)
+	store.Reset()
+	require.NoError(t, trie.Commit())
+
+	imported := smt.ImportSparseMerkleSumTrie(store, sha256.New(), trie.Root())
+	sum, err := imported.Sum()
+	require.NoError(t, err)
+	require.Equal(t, uint64(faultTestKeys*(faultTestKeys-1)/2), sum)
+	for i := 0; i < faultTestKeys; i++ {
+		_, weight, err := imported.Get(faultTestKey(i))
+		require.NoError(t, err)
+		require.Equal(t, uint64(i), weight)
+	}
+}
+
+// requireTrieInStore ensures every leaf of the trie with the given root can be
+// resolved and proven from the store alone
+func requireTrieInStore(t *testing.T, store *kvstoretest.FaultyMapStore, root []byte) {
+	t.Helper()
+	trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+	for i := 0; i < 2*faultTestKeys; i++ {
+		key := faultTestKey(i)
+		value, err := trie.Get(key)
+		require.NoError(t, err)
+		if value == nil {
+			continue
+		}
+		proof, err := trie.Prove(key)
+		require.NoError(t, err)
+		valid, err := smt.VerifyProof(proof, root, key, []byte("value"), trie.Spec())
+		require.NoError(t, err)
+		require.True(t, valid)
+	}
+}
@@ -0,0 +1,219 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+const (
+	// gcSweepBatchSize is the number of records scanned before the garbage
+	// found among them is deleted, as stores are not required to support
+	// writes while iterating
+	gcSweepBatchSize = 1000
+	// defaultGCProgressInterval is the default number of nodes marked or
+	// records scanned between two progress reports
+	defaultGCProgressInterval = 10_000
+)
+
+// GCPhase is a phase of the garbage collection
+type GCPhase string
+
+const (
+	// GCPhaseMark marks every node reachable from the live roots
+	GCPhaseMark GCPhase = "mark"
+	// GCPhaseSweep scans the node store and deletes the unreachable nodes
+	GCPhaseSweep GCPhase = "sweep"
+)
+
+// GCOptions configures `CollectGarbageWithOptions`
+type GCOptions struct {
+	// DryRun reports the unreachable nodes without deleting them
+	DryRun bool
+	// Progress, if set, is called with the report of the collection so far
+	// every ProgressInterval nodes marked or records scanned, and at the end
+	// of each phase
+	Progress func(phase GCPhase, report GCReport)
+	// ProgressInterval is the number of nodes marked or records scanned
+	// between two progress reports, 10000 if zero
+	ProgressInterval int
+}
+
+// GCReport is the result of a garbage collection
+type GCReport struct {
+	// Marked is the number of nodes reachable from the live roots
+	Marked int
+	// Scanned is the number of records of the node store scanned
+	Scanned int
+	// Unreachable is the number of node records which are not reachable from
+	// the live roots
+	Unreachable int
+	// UnreachableBytes is the total size of the keys and values of the
+	// unreachable node records
+	UnreachableBytes int64
+	// Deleted is the number of unreachable node records deleted, which is
+	// zero for a dry run
+	Deleted int
+}
+
+// CollectGarbage deletes every node record of the node store which is not
+// reachable from any of the live roots provided. See
+// `CollectGarbageWithOptions` for details.
+func CollectGarbage(nodes kvstore.IterableMapStore, spec *TrieSpec, liveRoots ...[]byte) (*GCReport, error) {
+	return CollectGarbageWithOptions(nodes, spec, GCOptions{}, liveRoots...)
+}
+
+// CollectGarbageWithOptions marks every node reachable from the live roots
+// provided, then scans the node store and deletes every node record which was
+// not marked, such as the nodes left behind by an interrupted commit or by a
+// trie abandoned without being cleaned up.
+//
+// Only the records which look like nodes of a trie with the given spec, i.e.
+// whose value is a node encoding hashing to their key, are deleted, so that
+// other records sharing the store are left untouched. If any of the live tries
+// cannot be walked entirely, e.g. because of a missing node, ErrIncompleteTrie
+// is returned before anything is deleted.
+//
+// The collection must not run concurrently with commits to the node store, as
+// the nodes written after they were marked would be deleted.
+func CollectGarbageWithOptions(
+	nodes kvstore.IterableMapStore,
+	spec *TrieSpec,
+	opts GCOptions,
+	liveRoots ...[]byte,
+) (*GCReport, error) {
+	gc := &garbageCollector{
+		spec:     spec,
+		nodes:    nodes,
+		opts:     opts,
+		marked:   make(map[string]struct{}),
+		report:   &GCReport{},
+		interval: opts.ProgressInterval,
+	}
+	if gc.interval <= 0 {
+		gc.interval = defaultGCProgressInterval
+	}
+	if err := gc.mark(liveRoots); err != nil {
+		return gc.report, err
+	}
+	if err := gc.sweep(); err != nil {
+		return gc.report, err
+	}
+	return gc.report, nil
+}
+
+// garbageCollector collects the nodes unreachable from a set of live roots
+type garbageCollector struct {
+	spec     *TrieSpec
+	nodes    kvstore.IterableMapStore
+	opts     GCOptions
+	marked   map[string]struct{}
+	report   *GCReport
+	interval int
+}
+
+// mark marks every node reachable from the live roots
+func (gc *garbageCollector) mark(liveRoots [][]byte) error {
+	checker := newTrieChecker(gc.nodes, gc.spec)
+	checker.visit = func(digest []byte) bool {
+		if _, ok := gc.marked[string(digest)]; ok {
+			// The subtrie was already marked from another root
+			return false
+		}
+		gc.marked[string(digest)] = struct{}{}
+		gc.report.Marked++
+		if gc.report.Marked%gc.interval == 0 {
+			gc.progress(GCPhaseMark)
+		}
+		return true
+	}
+	for _, root := range liveRoots {
+		report, err := checker.run(root)
+		if err != nil {
+			return err
+		}
+		for _, issue := range report.Issues {
+			switch issue.Kind {
+			case IssueMissingNode, IssueInvalidEncoding, IssueInvalidPathBounds:
+				// The nodes below the issue could not be marked
+				return errors.Join(ErrIncompleteTrie, report.Err())
+			}
+		}
+	}
+	gc.progress(GCPhaseMark)
+	return nil
+}
+
+// sweep scans the node store in batches, deleting the unmarked node records
+func (gc *garbageCollector) sweep() error {
+	var start []byte
+	for {
+		garbage, last, scanned, err := gc.scan(start)
+		if err != nil {
+			return err
+		}
+		if !gc.opts.DryRun {
+			for _, key := range garbage {
+				if err := gc.nodes.Delete(key); err != nil {
+					return err
+				}
+				gc.report.Deleted++
+			}
+		}
+		if scanned < gcSweepBatchSize {
+			gc.progress(GCPhaseSweep)
+			return nil
+		}
+		// Resume from the smallest key greater than the last one scanned
+		start = append(last, 0)
+	}
+}
+
+// scan scans a batch of records starting at the key provided and returns the
+// keys of the unmarked node records among them, along with the last key
+// scanned and the number of records scanned
+func (gc *garbageCollector) scan(start []byte) (garbage [][]byte, last []byte, scanned int, err error) {
+	it, err := gc.nodes.RangeIterator(kvstore.IterOptions{Start: start, Limit: gcSweepBatchSize})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		last = append(last[:0], key...)
+		scanned++
+		gc.report.Scanned++
+		if _, ok := gc.marked[string(key)]; !ok && gc.isNode(key, value) {
+			garbage = append(garbage, append([]byte{}, key...))
+			gc.report.Unreachable++
+			gc.report.UnreachableBytes += int64(len(key) + len(value))
+		}
+		if gc.report.Scanned%gc.interval == 0 {
+			gc.progress(GCPhaseSweep)
+		}
+	}
+	if err := it.Err(); err != nil {
+		it.Close()
+		return nil, nil, 0, err
+	}
+	if err := it.Close(); err != nil {
+		return nil, nil, 0, err
+	}
+	return garbage, last, scanned, nil
+}
+
+// isNode returns true if the record looks like a node of the trie, i.e. its
+// value is a valid node encoding which hashes to its key
+func (gc *garbageCollector) isNode(key, value []byte) bool {
+	if len(key) != gc.spec.hashSize() || !gc.spec.validEncoding(value) {
+		return false
+	}
+	return bytes.Equal(gc.spec.hashPreimage(value), key)
+}
+
+// progress reports the progress of the collection, if requested
+func (gc *garbageCollector) progress(phase GCPhase) {
+	if gc.opts.Progress != nil {
+		gc.opts.Progress(phase, *gc.report)
+	}
+}
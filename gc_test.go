@@ -0,0 +1,170 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// newGCTestTries commits two independent tries of the given number of leaves
+// to the same node store and returns their roots
+func newGCTestTries(t *testing.T, nodes simplemap.SimpleMap, sumTrie bool, leaves int) (live, dead []byte) {
+	t.Helper()
+	roots := make([][]byte, 2)
+	for i := range roots {
+		keys, values := randomBatch(t, leaves, int64(i))
+		if sumTrie {
+			trie := NewSparseMerkleSumTrie(nodes, sha256.New())
+			for j := range keys {
+				require.NoError(t, trie.Update(keys[j], values[j], uint64(j)))
+			}
+			require.NoError(t, trie.Commit())
+			roots[i] = trie.Root()
+		} else {
+			trie := NewSparseMerkleTrie(nodes, sha256.New())
+			require.NoError(t, trie.UpdateBatch(keys, values))
+			require.NoError(t, trie.Commit())
+			roots[i] = trie.Root()
+		}
+	}
+	return roots[0], roots[1]
+}
+
+func TestCollectGarbage(t *testing.T) {
+	for _, sumTrie := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sumTrie=%t", sumTrie), func(t *testing.T) {
+			spec := NewTrieSpec(sha256.New(), sumTrie)
+			nodes := simplemap.NewSimpleMap()
+			live, dead := newGCTestTries(t, nodes, sumTrie, 600)
+
+			// Records which are not nodes of the trie are never collected
+			require.NoError(t, nodes.Set([]byte("metadata"), []byte("value")))
+			require.NoError(t, nodes.Set(make([]byte, spec.hashSize()), []byte("not a node")))
+
+			before, err := nodes.Len()
+			require.NoError(t, err)
+			liveReport, err := Check(nodes, &spec, live)
+			require.NoError(t, err)
+			require.True(t, liveReport.OK())
+
+			report, err := CollectGarbage(nodes, &spec, live)
+			require.NoError(t, err)
+			require.Equal(t, liveReport.Nodes, report.Marked)
+			require.Equal(t, before, report.Scanned)
+			require.Equal(t, before-liveReport.Nodes-2, report.Unreachable)
+			require.Equal(t, report.Unreachable, report.Deleted)
+			require.Positive(t, report.UnreachableBytes)
+
+			after, err := nodes.Len()
+			require.NoError(t, err)
+			require.Equal(t, liveReport.Nodes+2, after)
+			liveReport, err = Check(nodes, &spec, live)
+			require.NoError(t, err)
+			require.True(t, liveReport.OK())
+			deadReport, err := Check(nodes, &spec, dead)
+			require.NoError(t, err)
+			require.Equal(t, IssueMissingNode, deadReport.Issues[0].Kind)
+			_, err = nodes.Get([]byte("metadata"))
+			require.NoError(t, err)
+
+			// Nothing is left to collect
+			report, err = CollectGarbage(nodes, &spec, live)
+			require.NoError(t, err)
+			require.Zero(t, report.Unreachable)
+		})
+	}
+}
+
+func TestCollectGarbage_MultipleRoots(t *testing.T) {
+	spec := NewTrieSpec(sha256.New(), false)
+	nodes := simplemap.NewSimpleMap()
+	first, second := newGCTestTries(t, nodes, false, 100)
+
+	// A newer version of the first trie, which shares most of its nodes
+	trie := ImportSparseMerkleTrie(nodes, sha256.New(), first)
+	require.NoError(t, trie.Update([]byte("new key"), []byte("new value")))
+	require.NoError(t, trie.Commit())
+	third := trie.Root()
+
+	// The first trie is no longer complete since its orphaned nodes were
+	// deleted by the commit, but the remaining tries do not hold any garbage
+	before, err := nodes.Len()
+	require.NoError(t, err)
+	report, err := CollectGarbage(nodes, &spec, second, third, third, spec.placeholder())
+	require.NoError(t, err)
+	require.Equal(t, before, report.Marked)
+	require.Zero(t, report.Unreachable)
+}
+
+func TestCollectGarbage_DryRun(t *testing.T) {
+	spec := NewTrieSpec(sha256.New(), false)
+	nodes := simplemap.NewSimpleMap()
+	live, _ := newGCTestTries(t, nodes, false, 100)
+	before, err := nodes.Len()
+	require.NoError(t, err)
+
+	report, err := CollectGarbageWithOptions(nodes, &spec, GCOptions{DryRun: true}, live)
+	require.NoError(t, err)
+	require.Positive(t, report.Unreachable)
+	require.Zero(t, report.Deleted)
+	after, err := nodes.Len()
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+}
+
+func TestCollectGarbage_Progress(t *testing.T) {
+	spec := NewTrieSpec(sha256.New(), false)
+	nodes := simplemap.NewSimpleMap()
+	live, _ := newGCTestTries(t, nodes, false, 100)
+
+	reports := make(map[GCPhase][]GCReport)
+	final, err := CollectGarbageWithOptions(nodes, &spec, GCOptions{
+		Progress: func(phase GCPhase, report GCReport) {
+			reports[phase] = append(reports[phase], report)
+		},
+		ProgressInterval: 10,
+	}, live)
+	require.NoError(t, err)
+
+	// Every phase reports its progress periodically and once it is done
+	marks, sweeps := reports[GCPhaseMark], reports[GCPhaseSweep]
+	require.Equal(t, final.Marked/10+1, len(marks))
+	require.Equal(t, final.Scanned/10+1, len(sweeps))
+	require.Equal(t, final.Marked, marks[len(marks)-1].Marked)
+	require.Zero(t, marks[len(marks)-1].Scanned)
+	require.Equal(t, *final, sweeps[len(sweeps)-1])
+	for i := 1; i < len(sweeps); i++ {
+		require.GreaterOrEqual(t, sweeps[i].Scanned, sweeps[i-1].Scanned)
+	}
+}
+
+func TestCollectGarbage_IncompleteTrie(t *testing.T) {
+	spec := NewTrieSpec(sha256.New(), false)
+	nodes := simplemap.NewSimpleMap()
+	live, _ := newGCTestTries(t, nodes, false, 100)
+
+	// Remove a node of the live trie, whose descendants can no longer be told
+	// apart from garbage
+	checker := newTrieChecker(nodes, &spec)
+	var digests [][]byte
+	checker.visit = func(digest []byte) bool {
+		digests = append(digests, digest)
+		return true
+	}
+	_, err := checker.run(live)
+	require.NoError(t, err)
+	require.NoError(t, nodes.Delete(digests[1]))
+	before, err := nodes.Len()
+	require.NoError(t, err)
+
+	_, err = CollectGarbage(nodes, &spec, live)
+	require.ErrorIs(t, err, ErrIncompleteTrie)
+	require.ErrorIs(t, err, ErrMissingNode)
+	after, err := nodes.Len()
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+}
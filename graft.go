@@ -0,0 +1,204 @@
+package smt
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Subtrie is the root of a trie built independently for all the leaves
+// sharing a common path prefix. Subtries built for disjoint prefixes can be
+// grafted under a common top with `GraftSubtries`, producing the same root a
+// single trie containing all of their leaves would have.
+type Subtrie struct {
+	// The path prefix shared by all the leaves in the subtrie
+	prefix []byte
+	// The number of bits of the prefix that are significant
+	prefixBits int
+	// Whether or not the subtrie was built for a sum trie
+	sumTrie bool
+	// The root of the subtrie, nil if the subtrie is empty
+	root *builtSubtrie
+}
+
+// Prefix returns the path prefix of the subtrie and the number of bits of the
+// prefix that are significant
+func (sub *Subtrie) Prefix() ([]byte, int) {
+	return sub.prefix, sub.prefixBits
+}
+
+// IsEmpty returns true if the subtrie does not contain any leaves
+func (sub *Subtrie) IsEmpty() bool {
+	return sub.root == nil
+}
+
+// Shard is a set of leaves sharing a path prefix which is built into its own
+// node store by `BuildShardedTrie`.
+type Shard struct {
+	// Prefix is the path prefix shared by all the leaves of the shard
+	Prefix []byte
+	// PrefixBits is the number of bits of the prefix that are significant
+	PrefixBits int
+	// Nodes is the node store the nodes of the shard are written to
+	Nodes kvstore.MapStore
+	// Leaves provides the leaves of the shard, sorted by path
+	Leaves LeafIterator
+}
+
+// BuildSubtrie builds the subtrie containing all the leaves provided, which
+// must be sorted by path and share the first `prefixBits` bits of `prefix`,
+// writing its nodes into the node store.
+//
+// Only the nodes below the prefix are written. The nodes joining the subtrie
+// to the rest of the trie are written by `GraftSubtries` since they depend on
+// the contents of the neighbouring subtries.
+func BuildSubtrie(
+	nodes kvstore.MapStore,
+	spec *TrieSpec,
+	prefix []byte,
+	prefixBits int,
+	leaves LeafIterator,
+) (*Subtrie, error) {
+	if prefixBits < 0 || prefixBits > spec.depth() || prefixBits > len(prefix)*8 {
+		return nil, ErrInvalidSubtriePrefix
+	}
+	builder := &trieBuilder{spec: spec, nodes: nodes}
+	if err := builder.addLeaves(&prefixLeafIterator{
+		LeafIterator: leaves,
+		prefix:       prefix,
+		prefixBits:   prefixBits,
+	}); err != nil {
+		return nil, err
+	}
+	root, err := builder.finish()
+	if err != nil {
+		return nil, err
+	}
+	prefixCopy := make([]byte, len(prefix))
+	copy(prefixCopy, prefix)
+	return &Subtrie{
+		prefix:     prefixCopy,
+		prefixBits: prefixBits,
+		sumTrie:    spec.sumTrie,
+		root:       root,
+	}, nil
+}
+
+// GraftSubtries joins the subtries provided under a common top, writing the
+// inner and extension nodes above the subtries into the node store, and
+// returns the root hash of the resulting trie.
+//
+// The prefixes of the subtries must be disjoint, but they do not need to cover
+// the entire path space: missing or empty subtries are treated as empty
+// branches of the trie. Subtries holding a single leaf are lifted to the
+// position the leaf would occupy in a single trie.
+func GraftSubtries(nodes kvstore.MapStore, spec *TrieSpec, subtries ...*Subtrie) ([]byte, error) {
+	nonEmpty := make([]*Subtrie, 0, len(subtries))
+	for _, sub := range subtries {
+		if sub.sumTrie != spec.sumTrie {
+			return nil, ErrIncompatibleSubtrie
+		}
+		if !sub.IsEmpty() {
+			nonEmpty = append(nonEmpty, sub)
+		}
+	}
+	sort.Slice(nonEmpty, func(i, j int) bool {
+		return bytes.Compare(nonEmpty[i].root.path, nonEmpty[j].root.path) < 0
+	})
+
+	builder := &trieBuilder{spec: spec, nodes: nodes}
+	for i, sub := range nonEmpty {
+		// Adjacent subtries must diverge above both of their prefixes
+		if i > 0 {
+			prev := nonEmpty[i-1]
+			commonBits := countCommonPrefixBits(prev.root.path, sub.root.path, 0)
+			if commonBits >= prev.prefixBits || commonBits >= sub.prefixBits {
+				return nil, ErrOverlappingSubtries
+			}
+		}
+		if err := builder.add(sub.root); err != nil {
+			return nil, err
+		}
+	}
+	top, err := builder.finish()
+	if err != nil {
+		return nil, err
+	}
+	if top == nil {
+		return spec.placeholder(), nil
+	}
+	return builder.wrap(top, 0)
+}
+
+// BuildShardedTrie builds every shard provided into its own node store on a
+// separate goroutine, then grafts the resulting subtries into the node store
+// provided and returns the root hash of the trie.
+//
+// Since the hashers of a TrieSpec are not safe for concurrent use, `newSpec`
+// is called once per shard and MUST return a spec with its own hasher. The
+// trie can only be imported once the nodes of all the shards are available in
+// a single node store.
+func BuildShardedTrie(
+	nodes kvstore.MapStore,
+	newSpec func() TrieSpec,
+	shards []Shard,
+) ([]byte, error) {
+	subtries := make([]*Subtrie, len(shards))
+	errs := make([]error, len(shards))
+	var wg sync.WaitGroup
+	for i := range shards {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			spec := newSpec()
+			shard := shards[i]
+			subtries[i], errs[i] = BuildSubtrie(
+				shard.Nodes, &spec, shard.Prefix, shard.PrefixBits, shard.Leaves,
+			)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	spec := newSpec()
+	return GraftSubtries(nodes, &spec, subtries...)
+}
+
+// prefixLeafIterator wraps a LeafIterator to ensure all the leaves it
+// provides share the given path prefix
+type prefixLeafIterator struct {
+	LeafIterator
+	prefix     []byte
+	prefixBits int
+	err        error
+}
+
+// Next satisfies the LeafIterator#Next interface
+func (it *prefixLeafIterator) Next() bool {
+	if it.err != nil || !it.LeafIterator.Next() {
+		return false
+	}
+	path := it.Leaf().Path
+	if len(path)*8 < it.prefixBits {
+		it.err = ErrInvalidLeafPath
+		return false
+	}
+	if match, _ := equalPrefixBits(path, it.prefix, 0, it.prefixBits); !match {
+		it.err = ErrLeafOutsidePrefix
+		return false
+	}
+	return true
+}
+
+// Err satisfies the LeafIterator#Err interface
+func (it *prefixLeafIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.LeafIterator.Err()
+}
@@ -0,0 +1,143 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestBuildShardedTrie(t *testing.T) {
+	// Few enough leaves that many of the 256 first-byte buckets are either
+	// empty or hold a single leaf
+	for _, numLeaves := range []int{0, 1, 2, 300, 2000} {
+		keys, values := randomBatch(t, numLeaves, int64(numLeaves))
+		spec := NewTrieSpec(sha256.New(), false)
+		leaves := make([]BulkLeaf, len(keys))
+		for i := range keys {
+			leaves[i] = BulkLeaf{
+				Path:      spec.ph.Path(keys[i]),
+				ValueHash: spec.valueHash(values[i]),
+			}
+		}
+		sortBulkLeaves(leaves)
+
+		expected, err := BuildSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), NewSliceLeafIterator(leaves))
+		require.NoError(t, err)
+
+		// Build every first-byte bucket into its own store
+		stores := make([]map[string][]byte, 256)
+		shards := make([]Shard, 256)
+		for b := range shards {
+			var bucket []BulkLeaf
+			for _, leaf := range leaves {
+				if leaf.Path[0] == byte(b) {
+					bucket = append(bucket, leaf)
+				}
+			}
+			stores[b] = make(map[string][]byte)
+			shards[b] = Shard{
+				Prefix:     []byte{byte(b)},
+				PrefixBits: 8,
+				Nodes:      simplemap.NewSimpleMapWithMap(stores[b]),
+				Leaves:     NewSliceLeafIterator(bucket),
+			}
+		}
+		top := make(map[string][]byte)
+		newSpec := func() TrieSpec { return NewTrieSpec(sha256.New(), false) }
+		root, err := BuildShardedTrie(simplemap.NewSimpleMapWithMap(top), newSpec, shards)
+		require.NoError(t, err)
+		require.Equal(t, []byte(expected), root)
+
+		// Merge all the stores to load the grafted trie
+		for _, store := range stores {
+			for k, v := range store {
+				top[k] = v
+			}
+		}
+		smt := ImportSparseMerkleTrie(simplemap.NewSimpleMapWithMap(top), sha256.New(), root)
+		for i := range keys {
+			value, err := smt.Get(keys[i])
+			require.NoError(t, err)
+			require.Equal(t, smt.valueHash(values[i]), value)
+		}
+	}
+}
+
+func TestGraftSubtries_SumTrie(t *testing.T) {
+	keys, values := randomBatch(t, 200, 7)
+	weights := make([]uint64, len(keys))
+	for i := range weights {
+		weights[i] = uint64(i)
+	}
+	expected := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, expected.UpdateBatch(keys, values, weights))
+
+	// Only populate the buckets for paths starting with 01 and 11, leaving the
+	// 00 and 10 buckets empty or missing entirely
+	spec := NewTrieSpec(sha256.New(), true)
+	buckets := make([][]BulkLeaf, 4)
+	for i := range keys {
+		path := spec.ph.Path(keys[i])
+		buckets[path[0]>>6] = append(buckets[path[0]>>6], BulkLeaf{
+			Path:      path,
+			ValueHash: spec.valueHash(values[i]),
+			Weight:    weights[i],
+		})
+	}
+	for i := range buckets {
+		sortBulkLeaves(buckets[i])
+	}
+	for _, key := range keys {
+		if bucket := spec.ph.Path(key)[0] >> 6; bucket == 0 || bucket == 2 {
+			require.NoError(t, expected.Delete(key))
+		}
+	}
+
+	nodes := simplemap.NewSimpleMap()
+	var subtries []*Subtrie
+	for _, bucket := range []byte{0, 1, 3} {
+		var leaves []BulkLeaf
+		if bucket != 0 {
+			leaves = buckets[bucket]
+		}
+		sub, err := BuildSubtrie(nodes, &spec, []byte{bucket << 6}, 2, NewSliceLeafIterator(leaves))
+		require.NoError(t, err)
+		subtries = append(subtries, sub)
+	}
+	require.True(t, subtries[0].IsEmpty())
+	root, err := GraftSubtries(nodes, &spec, subtries...)
+	require.NoError(t, err)
+	require.Equal(t, []byte(expected.Root()), root)
+
+	smst := ImportSparseMerkleSumTrie(nodes, sha256.New(), root)
+	require.Equal(t, expected.MustSum(), smst.MustSum())
+	require.Equal(t, expected.MustCount(), smst.MustCount())
+}
+
+func TestGraftSubtries_Errors(t *testing.T) {
+	spec := NewTrieSpec(sha256.New(), false)
+	nodes := simplemap.NewSimpleMap()
+	path := spec.ph.Path([]byte("key"))
+	leaf := []BulkLeaf{{Path: path, ValueHash: spec.valueHash([]byte("value"))}}
+
+	_, err := BuildSubtrie(nodes, &spec, []byte{path[0]}, 9, NewSliceLeafIterator(leaf))
+	require.ErrorIs(t, err, ErrInvalidSubtriePrefix)
+
+	_, err = BuildSubtrie(nodes, &spec, []byte{^path[0]}, 8, NewSliceLeafIterator(leaf))
+	require.ErrorIs(t, err, ErrLeafOutsidePrefix)
+
+	// A subtrie for the first bit overlaps a subtrie for the first byte
+	wide, err := BuildSubtrie(nodes, &spec, []byte{path[0]}, 1, NewSliceLeafIterator(leaf))
+	require.NoError(t, err)
+	narrow, err := BuildSubtrie(nodes, &spec, []byte{path[0]}, 8, NewSliceLeafIterator(leaf))
+	require.NoError(t, err)
+	_, err = GraftSubtries(nodes, &spec, wide, narrow)
+	require.ErrorIs(t, err, ErrOverlappingSubtries)
+
+	sumSpec := NewTrieSpec(sha256.New(), true)
+	_, err = GraftSubtries(nodes, &sumSpec, wide)
+	require.ErrorIs(t, err, ErrIncompatibleSubtrie)
+}
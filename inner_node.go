@@ -10,6 +10,7 @@ type innerNode struct {
 	leftChild, rightChild trieNode
 	persisted             bool
 	digest                []byte
+	accessed              uint64
 }
 
 // Persisted satisfied the trieNode#Persisted interface
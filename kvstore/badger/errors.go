@@ -8,6 +8,9 @@ var (
 	// ErrBadgerOpeningStore is returned when the badger store cannot be opened
 	// or an error occurs while opening/creating the BadgerKVStore
 	ErrBadgerOpeningStore = errors.New("error opening the store")
+	// ErrBadgerInvalidOption is returned when the options provided to open
+	// the store are invalid
+	ErrBadgerInvalidOption = errors.New("invalid store option")
 	// ErrBadgerUnableToSetValue is returned when the badger store fails to
 	// set a value
 	ErrBadgerUnableToSetValue = errors.New("unable to set value")
@@ -35,6 +38,12 @@ var (
 	// ErrBadgerGettingStoreLength is returned when the badger store fails to
 	// get the length of the database
 	ErrBadgerGettingStoreLength = errors.New("unable to get database length")
+	// ErrBadgerValueLogGC is returned when the badger store fails to run the
+	// value log garbage collection
+	ErrBadgerValueLogGC = errors.New("unable to run value log garbage collection")
+	// ErrBadgerFlattening is returned when the badger store fails to flatten
+	// the LSM tree
+	ErrBadgerFlattening = errors.New("unable to flatten database")
 	// ErrBadgerUnableToCheckExistence is returned when the badger store fails to
 	// check if a key exists
 	ErrBadgerUnableToCheckExistence = errors.New("unable to check key existence")
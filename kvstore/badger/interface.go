@@ -9,6 +9,12 @@ import (
 // Ensure the BadgerKVStore can be used as an SMT node store
 var _ kvstore.MapStore = (BadgerKVStore)(nil)
 
+// Ensure the BadgerKVStore can be iterated over and supports prefix deletion
+var (
+	_ kvstore.IterableMapStore = (BadgerKVStore)(nil)
+	_ kvstore.PrefixDeleter    = (BadgerKVStore)(nil)
+)
+
 // BadgerKVStore is an interface that defines a key-value store
 // that can be used standalone or as the node store for an SMT.
 // This is a superset of the MapStore interface that offers more
@@ -28,10 +34,29 @@ type BadgerKVStore interface {
 	// Restore loads the store from a backup in the reader provided
 	Restore(io.Reader) error
 
+	// --- Maintenance methods ---
+
+	// RunValueLogGC rewrites the value log files with at least the given
+	// proportion of stale data, reclaiming their space
+	RunValueLogGC(discardRatio float64) error
+	// Flatten compacts all the levels of the LSM tree into a single level
+	Flatten() error
+	// Size returns the size (in bytes) of the LSM tree and of the value log
+	Size() (lsm, vlog int64)
+
 	// --- Accessors ---
 
 	// GetAll returns all keys and values with the given prefix in the specified order
 	GetAll(prefixKey []byte, descending bool) (keys, values [][]byte, err error)
 	// Exists returns true if the key exists
 	Exists(key []byte) (bool, error)
+	// DeleteRange removes all the keys in the range [start, end)
+	DeleteRange(start, end []byte) error
+	// DeletePrefix removes all the keys starting with the prefix
+	DeletePrefix(prefix []byte) error
+	// Iterator returns an iterator over the keys with the given prefix, starting
+	// at the key provided (if any) in the specified order
+	Iterator(prefix, start []byte, descending bool) (kvstore.Iterator, error)
+	// RangeIterator returns an iterator over the keys satisfying the options
+	RangeIterator(opts kvstore.IterOptions) (kvstore.Iterator, error)
 }
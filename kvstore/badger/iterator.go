@@ -0,0 +1,129 @@
+package badger
+
+import (
+	"bytes"
+	"errors"
+
+	badgerv4 "github.com/dgraph-io/badger/v4"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+var _ kvstore.Iterator = &badgerIterator{}
+
+// badgerIterator streams the entries of a read-only transaction, which is
+// kept open until the iterator is closed
+type badgerIterator struct {
+	txn  *badgerv4.Txn
+	iter *badgerv4.Iterator
+	// The range of keys [lower, upper) visited, a nil bound is unbounded
+	lower, upper []byte
+	descending   bool
+	limit        int
+	count        int
+	started      bool
+	done         bool
+	closed       bool
+	key, value   []byte
+	err          error
+}
+
+// Iterator returns an iterator over the keys with the given prefix, starting
+// at the key provided (if any) in the specified order
+func (store *badgerKVStore) Iterator(prefix, start []byte, descending bool) (kvstore.Iterator, error) {
+	return store.RangeIterator(kvstore.IterOptions{
+		Prefix:     prefix,
+		Start:      start,
+		Descending: descending,
+	})
+}
+
+// RangeIterator returns an iterator over the keys satisfying the options
+func (store *badgerKVStore) RangeIterator(opts kvstore.IterOptions) (kvstore.Iterator, error) {
+	if store.db.IsClosed() {
+		return nil, errors.Join(ErrBadgerIteratingStore, badgerv4.ErrDBClosed)
+	}
+	lower, upper := opts.Bounds()
+	iterOpts := badgerv4.DefaultIteratorOptions
+	iterOpts.Reverse = opts.Descending
+	if !opts.Descending {
+		// Badger stops reverse iteration as soon as it seeks to a key outside
+		// of the prefix, so the prefix is only used to skip tables when
+		// iterating in ascending order
+		iterOpts.Prefix = opts.Prefix
+	}
+	if opts.Limit > 0 && opts.Limit < iterOpts.PrefetchSize {
+		iterOpts.PrefetchSize = opts.Limit
+	}
+	txn := store.db.NewTransaction(false)
+	return &badgerIterator{
+		txn:        txn,
+		iter:       txn.NewIterator(iterOpts),
+		lower:      lower,
+		upper:      upper,
+		descending: opts.Descending,
+		limit:      opts.Limit,
+	}, nil
+}
+
+// Next satisfies the kvstore.Iterator#Next interface
+func (it *badgerIterator) Next() bool {
+	if it.done || it.closed || it.err != nil || (it.limit > 0 && it.count >= it.limit) {
+		return false
+	}
+	if !it.started {
+		it.started = true
+		if it.descending {
+			// Reverse seeks land on the greatest key less than or equal to the
+			// upper bound, which is itself excluded from the range
+			it.iter.Seek(it.upper)
+			for it.iter.Valid() && it.upper != nil && bytes.Compare(it.iter.Item().Key(), it.upper) >= 0 {
+				it.iter.Next()
+			}
+		} else {
+			it.iter.Seek(it.lower)
+		}
+	} else {
+		it.iter.Next()
+	}
+	if !it.iter.Valid() {
+		it.done = true
+		return false
+	}
+	item := it.iter.Item()
+	if it.descending && it.lower != nil && bytes.Compare(item.Key(), it.lower) < 0 {
+		it.done = true
+		return false
+	}
+	if !it.descending && it.upper != nil && bytes.Compare(item.Key(), it.upper) >= 0 {
+		it.done = true
+		return false
+	}
+	it.key = item.KeyCopy(it.key[:0])
+	if it.value, it.err = item.ValueCopy(it.value[:0]); it.err != nil {
+		it.err = errors.Join(ErrBadgerIteratingStore, it.err)
+		return false
+	}
+	it.count++
+	return true
+}
+
+// Key satisfies the kvstore.Iterator#Key interface
+func (it *badgerIterator) Key() []byte { return it.key }
+
+// Value satisfies the kvstore.Iterator#Value interface
+func (it *badgerIterator) Value() []byte { return it.value }
+
+// Err satisfies the kvstore.Iterator#Err interface
+func (it *badgerIterator) Err() error { return it.err }
+
+// Close satisfies the kvstore.Iterator#Close interface
+func (it *badgerIterator) Close() error {
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+	it.iter.Close()
+	it.txn.Discard()
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"io"
 
 	badgerv4 "github.com/dgraph-io/badger/v4"
+
+	"github.com/pokt-network/smt/kvstore"
 )
 
 const (
@@ -16,25 +18,41 @@ var _ BadgerKVStore = &badgerKVStore{}
 type badgerKVStore struct {
 	db         *badgerv4.DB
 	lastBackup uint64 // timestamp of the most recent backup
+	// Channels used to stop the background maintenance goroutine, if any
+	stopMaintenance chan struct{}
+	maintenanceDone chan struct{}
 }
 
 // NewKVStore creates a new BadgerKVStore using badger as the underlying database
-// if no path for a persistence database is provided it will create one in-memory
-// TODO_CONSIDERATION: consider exposing the low-level options (`badgerv4.Options`) via a
-// config file to make it easier to test under different load conditions.
-func NewKVStore(path string) (BadgerKVStore, error) {
-	var db *badgerv4.DB
-	var err error
-	if path == "" {
-		db, err = badgerv4.Open(badgerOptions("").WithInMemory(true))
-	} else {
-		db, err = badgerv4.Open(badgerOptions(path))
+// if no path for a persistence database is provided it will create one in-memory.
+// The low-level options of the database can be configured with the options
+// provided, see `Config` for details.
+func NewKVStore(path string, opts ...Option) (BadgerKVStore, error) {
+	var cfg Config
+	for _, opt := range opts {
+		opt(&cfg)
 	}
+	badgerOpts, err := badgerOptions(path, cfg)
+	if err != nil {
+		return nil, errors.Join(ErrBadgerOpeningStore, err)
+	}
+	db, err := badgerv4.Open(badgerOpts)
 	if err != nil {
 		return nil, errors.Join(ErrBadgerOpeningStore, err)
 	}
 
-	return &badgerKVStore{db: db}, nil
+	store := &badgerKVStore{db: db}
+	// The value log only exists for persistent stores that can be written to
+	if cfg.GCInterval > 0 && path != "" && !cfg.ReadOnly {
+		store.stopMaintenance = make(chan struct{})
+		store.maintenanceDone = make(chan struct{})
+		discardRatio := cfg.GCDiscardRatio
+		if discardRatio == 0 {
+			discardRatio = defaultGCDiscardRatio
+		}
+		go store.runMaintenance(cfg.GCInterval, discardRatio, cfg.Logger)
+	}
+	return store, nil
 }
 
 // Set sets/updates the value for a given key
@@ -62,6 +80,9 @@ func (store *badgerKVStore) Get(key []byte) ([]byte, error) {
 		}
 		return nil
 	}); err != nil {
+		if errors.Is(err, badgerv4.ErrKeyNotFound) {
+			err = errors.Join(err, kvstore.ErrKeyNotFound)
+		}
 		return nil, errors.Join(ErrBadgerUnableToGetValue, err)
 	}
 	return val, nil
@@ -168,6 +189,11 @@ func (store *badgerKVStore) Restore(r io.Reader) error {
 
 // Stop closes the database connection, disabling any access to the store
 func (store *badgerKVStore) Stop() error {
+	if store.stopMaintenance != nil {
+		close(store.stopMaintenance)
+		<-store.maintenanceDone
+		store.stopMaintenance = nil
+	}
 	if err := store.db.Close(); err != nil {
 		return errors.Join(ErrBadgerClosingStore, err)
 	}
@@ -215,19 +241,3 @@ func prefixEndBytes(prefix []byte) []byte {
 	end[len(end)-1]++
 	return end
 }
-
-// badgerOptions returns the badger options for the store being created
-func badgerOptions(path string) badgerv4.Options {
-	// DEV_NOTE: Parameters should be adjusted carefully, depending on the type of load. We need to experiment more to find the best
-	// values, and even then they might need further adjustments as the type of load/environment (e.g. memory dedicated
-	// to the process) changes.
-	//
-	// Good links to read about options:
-	// - https://github.com/dgraph-io/badger/issues/1304#issuecomment-630078745
-	// - https://github.com/dgraph-io/badger/blob/master/options.go#L37
-	// - https://github.com/open-policy-agent/opa/issues/4014#issuecomment-1003700744
-	opts := badgerv4.DefaultOptions(path)
-	opts.Logger = nil // disable badger's logger since it's very noisy
-
-	return opts
-}
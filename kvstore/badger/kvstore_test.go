@@ -8,7 +8,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	badgerv4 "github.com/dgraph-io/badger/v4"
+	"github.com/pokt-network/smt/kvstore"
 	"github.com/pokt-network/smt/kvstore/badger"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
 )
 
 func TestBadger_KVStore_BasicOperations(t *testing.T) {
@@ -403,3 +405,16 @@ func setupStore(t *testing.T, store badger.BadgerKVStore) {
 	err = store.Set([]byte("baz"), []byte("bin"))
 	require.NoError(t, err)
 }
+
+func TestBadger_KVStore_Conformance(t *testing.T) {
+	kvstoretest.RunMapStoreSuite(t,
+		func(t *testing.T) kvstore.MapStore {
+			store, err := badger.NewKVStore("")
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, store.Stop()) })
+			return store
+		},
+		kvstoretest.WithKeyNotFoundError(badgerv4.ErrKeyNotFound, kvstore.ErrKeyNotFound),
+		kvstoretest.WithEmptyKeyError(badgerv4.ErrEmptyKey),
+	)
+}
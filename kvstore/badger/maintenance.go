@@ -0,0 +1,59 @@
+package badger
+
+import (
+	"errors"
+	"runtime"
+	"time"
+
+	badgerv4 "github.com/dgraph-io/badger/v4"
+)
+
+// RunValueLogGC reclaims the space of the value log files whose proportion of
+// stale data (e.g. overwritten or deleted values) is at least `discardRatio`.
+// Files are rewritten one at a time until no file qualifies. It is a no-op
+// for in-memory stores, which do not have a value log.
+func (store *badgerKVStore) RunValueLogGC(discardRatio float64) error {
+	for {
+		err := store.db.RunValueLogGC(discardRatio)
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, badgerv4.ErrNoRewrite), errors.Is(err, badgerv4.ErrGCInMemoryMode):
+			return nil
+		default:
+			return errors.Join(ErrBadgerValueLogGC, err)
+		}
+	}
+}
+
+// Flatten compacts all the levels of the LSM tree into a single level, which
+// drops the keys that were overwritten or deleted
+func (store *badgerKVStore) Flatten() error {
+	if err := store.db.Flatten(runtime.GOMAXPROCS(0)); err != nil {
+		return errors.Join(ErrBadgerFlattening, err)
+	}
+	return nil
+}
+
+// Size returns the size (in bytes) of the LSM tree and of the value log files
+func (store *badgerKVStore) Size() (lsm, vlog int64) {
+	return store.db.Size()
+}
+
+// runMaintenance periodically runs the value log garbage collection until the
+// store is stopped
+func (store *badgerKVStore) runMaintenance(interval time.Duration, discardRatio float64, logger Logger) {
+	defer close(store.maintenanceDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-store.stopMaintenance:
+			return
+		case <-ticker.C:
+			if err := store.RunValueLogGC(discardRatio); err != nil && logger != nil {
+				logger.Warningf("value log garbage collection failed: %v", err)
+			}
+		}
+	}
+}
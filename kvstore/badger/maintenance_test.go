@@ -0,0 +1,63 @@
+package badger_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/badger"
+)
+
+func TestBadger_KVStore_Maintenance(t *testing.T) {
+	store, err := badger.NewKVStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Stop()
+
+	value := make([]byte, 2<<20) // large enough to be stored in the value log
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%d", i)), value))
+	}
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.Delete([]byte(fmt.Sprintf("key-%d", i))))
+	}
+
+	require.NoError(t, store.Flatten())
+	require.NoError(t, store.RunValueLogGC(0.5))
+	// Sizes are refreshed periodically by badger so they may still be zero
+	lsm, vlog := store.Size()
+	require.GreaterOrEqual(t, lsm, int64(0))
+	require.GreaterOrEqual(t, vlog, int64(0))
+
+	// Maintenance is a no-op for in-memory stores
+	memStore, err := badger.NewKVStore("")
+	require.NoError(t, err)
+	defer memStore.Stop()
+	require.NoError(t, memStore.RunValueLogGC(0.5))
+	require.NoError(t, memStore.Flatten())
+}
+
+func TestBadger_KVStore_BackgroundMaintenance(t *testing.T) {
+	logger := &recordingLogger{}
+	store, err := badger.NewKVStore(t.TempDir(),
+		badger.WithValueLogGC(time.Millisecond, 0.5),
+		badger.WithLogger(logger),
+	)
+	require.NoError(t, err)
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+	time.Sleep(20 * time.Millisecond)
+
+	// Stopping the store must wait for the background goroutine to exit
+	done := make(chan error)
+	go func() { done <- store.Stop() }()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("store did not stop")
+	}
+
+	_, err = badger.NewKVStore(t.TempDir(), badger.WithValueLogGC(time.Second, 1))
+	require.ErrorIs(t, err, badger.ErrBadgerInvalidOption)
+}
@@ -0,0 +1,159 @@
+package badger
+
+import (
+	"errors"
+	"time"
+
+	badgerv4 "github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/options"
+)
+
+// Compression is the compression algorithm used for the data blocks stored on
+// disk
+type Compression string
+
+const (
+	// CompressionDefault keeps the default compression of the database
+	CompressionDefault Compression = ""
+	// CompressionNone disables compression
+	CompressionNone Compression = "none"
+	// CompressionSnappy compresses data blocks with Snappy
+	CompressionSnappy Compression = "snappy"
+	// CompressionZstd compresses data blocks with Zstandard
+	CompressionZstd Compression = "zstd"
+)
+
+// defaultGCDiscardRatio is the discard ratio of the background value log
+// garbage collection if none is configured, as recommended by badger
+const defaultGCDiscardRatio = 0.5
+
+// Logger is the interface used by the database to report internal events. It
+// is satisfied by badger's own loggers.
+type Logger = badgerv4.Logger
+
+// Config holds the low-level options of the store. Zero values keep the
+// defaults of the database, so a Config can be partially populated, e.g. when
+// loaded from a configuration file.
+type Config struct {
+	// SyncWrites makes every write wait for the data to be synced to disk
+	SyncWrites *bool `json:"sync_writes,omitempty" yaml:"sync_writes,omitempty"`
+	// BlockCacheSize is the size (in bytes) of the cache of data blocks
+	BlockCacheSize int64 `json:"block_cache_size,omitempty" yaml:"block_cache_size,omitempty"`
+	// MemTableSize is the size (in bytes) of each memtable. Badger bounds the
+	// size of a write batch to a fraction of it, so it must be large enough to
+	// hold values up to the value log threshold (1MiB by default).
+	MemTableSize int64 `json:"mem_table_size,omitempty" yaml:"mem_table_size,omitempty"`
+	// Compression is the compression algorithm of the data blocks
+	Compression Compression `json:"compression,omitempty" yaml:"compression,omitempty"`
+	// ReadOnly opens an existing persistent database in read-only mode
+	ReadOnly bool `json:"read_only,omitempty" yaml:"read_only,omitempty"`
+	// Logger receives the database logs, which are discarded if nil
+	Logger Logger `json:"-" yaml:"-"`
+	// GCInterval is the interval at which the value log garbage collection
+	// runs in the background, disabled if zero
+	GCInterval time.Duration `json:"gc_interval,omitempty" yaml:"gc_interval,omitempty"`
+	// GCDiscardRatio is the proportion of stale data above which a value log
+	// file is rewritten by the background garbage collection, defaults to 0.5
+	GCDiscardRatio float64 `json:"gc_discard_ratio,omitempty" yaml:"gc_discard_ratio,omitempty"`
+}
+
+// Option is a function that configures the store created by NewKVStore
+type Option func(*Config)
+
+// WithConfig returns an Option that replaces the configuration of the store
+// with the one provided. Options provided after it are applied on top.
+func WithConfig(cfg Config) Option {
+	return func(c *Config) { *c = cfg }
+}
+
+// WithSyncWrites returns an Option that sets whether every write waits for
+// the data to be synced to disk
+func WithSyncWrites(sync bool) Option {
+	return func(c *Config) { c.SyncWrites = &sync }
+}
+
+// WithBlockCacheSize returns an Option that sets the size (in bytes) of the
+// cache of data blocks
+func WithBlockCacheSize(size int64) Option {
+	return func(c *Config) { c.BlockCacheSize = size }
+}
+
+// WithMemTableSize returns an Option that sets the size (in bytes) of each
+// memtable
+func WithMemTableSize(size int64) Option {
+	return func(c *Config) { c.MemTableSize = size }
+}
+
+// WithCompression returns an Option that sets the compression algorithm of
+// the data blocks
+func WithCompression(compression Compression) Option {
+	return func(c *Config) { c.Compression = compression }
+}
+
+// WithReadOnly returns an Option that opens an existing persistent database
+// in read-only mode
+func WithReadOnly() Option {
+	return func(c *Config) { c.ReadOnly = true }
+}
+
+// WithLogger returns an Option that sets the logger receiving the database
+// logs
+func WithLogger(logger Logger) Option {
+	return func(c *Config) { c.Logger = logger }
+}
+
+// WithValueLogGC returns an Option that runs the value log garbage collection
+// in the background at the given interval, rewriting the files whose
+// proportion of stale data is at least `discardRatio`. The background
+// goroutine is stopped by `Stop`. It is ignored for in-memory and read-only
+// stores.
+func WithValueLogGC(interval time.Duration, discardRatio float64) Option {
+	return func(c *Config) {
+		c.GCInterval = interval
+		c.GCDiscardRatio = discardRatio
+	}
+}
+
+// badgerOptions returns the badger options for the store being created
+func badgerOptions(path string, cfg Config) (badgerv4.Options, error) {
+	// DEV_NOTE: Parameters should be adjusted carefully, depending on the type of load. We need to experiment more to find the best
+	// values, and even then they might need further adjustments as the type of load/environment (e.g. memory dedicated
+	// to the process) changes.
+	//
+	// Good links to read about options:
+	// - https://github.com/dgraph-io/badger/issues/1304#issuecomment-630078745
+	// - https://github.com/dgraph-io/badger/blob/master/options.go#L37
+	// - https://github.com/open-policy-agent/opa/issues/4014#issuecomment-1003700744
+	opts := badgerv4.DefaultOptions(path)
+	opts.Logger = cfg.Logger // disable badger's logger by default since it's very noisy
+	if cfg.GCInterval < 0 || cfg.GCDiscardRatio < 0 || cfg.GCDiscardRatio >= 1 {
+		return opts, errors.Join(ErrBadgerInvalidOption, errors.New("invalid value log garbage collection settings"))
+	}
+	if path == "" {
+		if cfg.ReadOnly {
+			return opts, errors.Join(ErrBadgerInvalidOption, errors.New("an in-memory store cannot be read-only"))
+		}
+		opts = opts.WithInMemory(true)
+	}
+	if cfg.SyncWrites != nil {
+		opts = opts.WithSyncWrites(*cfg.SyncWrites)
+	}
+	if cfg.BlockCacheSize > 0 {
+		opts = opts.WithBlockCacheSize(cfg.BlockCacheSize)
+	}
+	if cfg.MemTableSize > 0 {
+		opts = opts.WithMemTableSize(cfg.MemTableSize)
+	}
+	switch cfg.Compression {
+	case CompressionDefault:
+	case CompressionNone:
+		opts = opts.WithCompression(options.None)
+	case CompressionSnappy:
+		opts = opts.WithCompression(options.Snappy)
+	case CompressionZstd:
+		opts = opts.WithCompression(options.ZSTD)
+	default:
+		return opts, errors.Join(ErrBadgerInvalidOption, errors.New("unknown compression: "+string(cfg.Compression)))
+	}
+	return opts.WithReadOnly(cfg.ReadOnly), nil
+}
@@ -0,0 +1,82 @@
+package badger_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/badger"
+)
+
+func TestBadger_KVStore_Options(t *testing.T) {
+	logger := &recordingLogger{}
+	dir := t.TempDir()
+	store, err := badger.NewKVStore(dir,
+		badger.WithSyncWrites(true),
+		badger.WithBlockCacheSize(8<<20),
+		badger.WithMemTableSize(16<<20),
+		badger.WithCompression(badger.CompressionZstd),
+		badger.WithLogger(logger),
+	)
+	require.NoError(t, err)
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+	require.NoError(t, store.Stop())
+	require.NotZero(t, logger.count())
+
+	// Reopen the database in read-only mode
+	store, err = badger.NewKVStore(dir, badger.WithReadOnly())
+	require.NoError(t, err)
+	defer store.Stop()
+	value, err := store.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), value)
+	require.Error(t, store.Set([]byte("foo"), []byte("baz")))
+}
+
+func TestBadger_KVStore_OptionsConfig(t *testing.T) {
+	var cfg badger.Config
+	err := json.Unmarshal([]byte(`{"sync_writes": true, "mem_table_size": 16777216, "compression": "none"}`), &cfg)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.SyncWrites)
+	require.True(t, *cfg.SyncWrites)
+	require.Equal(t, int64(16<<20), cfg.MemTableSize)
+	require.Equal(t, badger.CompressionNone, cfg.Compression)
+
+	store, err := badger.NewKVStore("", badger.WithConfig(cfg), badger.WithBlockCacheSize(1<<20))
+	require.NoError(t, err)
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+	require.NoError(t, store.Stop())
+
+	_, err = badger.NewKVStore("", badger.WithCompression("lz4"))
+	require.ErrorIs(t, err, badger.ErrBadgerOpeningStore)
+	require.ErrorIs(t, err, badger.ErrBadgerInvalidOption)
+
+	_, err = badger.NewKVStore("", badger.WithReadOnly())
+	require.ErrorIs(t, err, badger.ErrBadgerInvalidOption)
+}
+
+// recordingLogger counts the log messages it receives
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) Errorf(format string, args ...interface{})   { l.log(format, args...) }
+func (l *recordingLogger) Warningf(format string, args ...interface{}) { l.log(format, args...) }
+func (l *recordingLogger) Infof(format string, args ...interface{})    { l.log(format, args...) }
+func (l *recordingLogger) Debugf(format string, args ...interface{})   { l.log(format, args...) }
+
+func (l *recordingLogger) log(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.messages)
+}
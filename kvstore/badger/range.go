@@ -0,0 +1,78 @@
+package badger
+
+import (
+	"bytes"
+	"errors"
+
+	badgerv4 "github.com/dgraph-io/badger/v4"
+)
+
+// deleteRangeBatchSize is the number of keys collected and deleted at once
+// when deleting a range of keys
+const deleteRangeBatchSize = 10_000
+
+// DeleteRange removes all the keys in the range [start, end). A nil end
+// leaves the range unbounded. Badger has no range tombstones, so the keys are
+// collected with a key-only iteration and deleted in batches. The deletion is
+// not atomic: if it fails, part of the range may already be deleted.
+func (store *badgerKVStore) DeleteRange(start, end []byte) error {
+	for {
+		keys, err := store.rangeKeys(start, end, deleteRangeBatchSize)
+		if err != nil {
+			return errors.Join(ErrBadgerUnableToDeleteValue, err)
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		batch := store.db.NewWriteBatch()
+		for _, key := range keys {
+			if err := batch.Delete(key); err != nil {
+				batch.Cancel()
+				return errors.Join(ErrBadgerUnableToDeleteValue, err)
+			}
+		}
+		if err := batch.Flush(); err != nil {
+			return errors.Join(ErrBadgerUnableToDeleteValue, err)
+		}
+		if len(keys) < deleteRangeBatchSize {
+			return nil
+		}
+		// Resume right after the last key deleted
+		start = append(keys[len(keys)-1], 0)
+	}
+}
+
+// DeletePrefix removes all the keys starting with the prefix, using badger's
+// DropPrefix which drops the matching keys without reading them. Writes are
+// blocked while the keys are being dropped.
+func (store *badgerKVStore) DeletePrefix(prefix []byte) error {
+	var err error
+	if len(prefix) == 0 {
+		err = store.db.DropAll()
+	} else {
+		err = store.db.DropPrefix(prefix)
+	}
+	if err != nil {
+		return errors.Join(ErrBadgerUnableToDeleteValue, err)
+	}
+	return nil
+}
+
+// rangeKeys returns up to `limit` keys in the range [start, end)
+func (store *badgerKVStore) rangeKeys(start, end []byte, limit int) (keys [][]byte, err error) {
+	err = store.db.View(func(tx *badgerv4.Txn) error {
+		opt := badgerv4.DefaultIteratorOptions
+		opt.PrefetchValues = false
+		it := tx.NewIterator(opt)
+		defer it.Close()
+		for it.Seek(start); it.Valid() && len(keys) < limit; it.Next() {
+			key := it.Item().KeyCopy(nil)
+			if end != nil && bytes.Compare(key, end) >= 0 {
+				break
+			}
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
@@ -0,0 +1,24 @@
+package badger_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/badger"
+)
+
+func TestBadger_KVStore_DeleteRangeBatches(t *testing.T) {
+	store, err := badger.NewKVStore("")
+	require.NoError(t, err)
+	defer store.Stop()
+	// More keys than are deleted in a single batch
+	for i := 0; i < 25_000; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%05d", i)), []byte("value")))
+	}
+	require.NoError(t, store.DeleteRange([]byte("key-00010"), []byte("key-24990")))
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, 20, length)
+}
@@ -0,0 +1,13 @@
+package cache
+
+import (
+	"errors"
+)
+
+var (
+	// ErrCacheInvalidCapacity is returned when the capacity of the cache is
+	// not a positive number of entries
+	ErrCacheInvalidCapacity = errors.New("cache capacity must be positive")
+	// ErrCacheNilStore is returned when no underlying store is provided
+	ErrCacheNilStore = errors.New("underlying store is nil")
+)
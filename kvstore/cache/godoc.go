@@ -0,0 +1,5 @@
+// Package cache provides a read-through LRU cache, bounded by its number of
+// entries, that wraps any MapStore. It can be used as the node store of the SM(S)T to avoid hitting the
+// underlying database when the same (usually upper-level) nodes are resolved
+// repeatedly, for example when generating many proofs on an imported trie.
+package cache
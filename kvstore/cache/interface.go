@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the CacheKVStore can be used as an SMT node store
+var _ kvstore.MapStore = (CacheKVStore)(nil)
+
+// CacheKVStore is a MapStore that caches the most recently used values of the
+// store it wraps. Writes go through to the underlying store, and the cache is
+// kept coherent with every Set, Delete and ClearAll performed through it.
+// The cache is bounded by its number of entries, not by the size of the
+// values it holds.
+//
+// Writes made directly to the underlying store are not visible through the
+// cache until the affected entries are evicted or Purge is called.
+type CacheKVStore interface {
+	kvstore.MapStore
+
+	// --- Cache methods ---
+
+	// Stats returns the hit and miss counters of the cache
+	Stats() Stats
+	// Purge drops every cached entry without modifying the underlying store
+	Purge()
+}
+
+// Stats reports the effectiveness of the cache
+type Stats struct {
+	// Hits is the number of Get calls served from the cache
+	Hits uint64
+	// Misses is the number of Get calls forwarded to the underlying store
+	Misses uint64
+	// Entries is the number of values currently cached
+	Entries int
+}
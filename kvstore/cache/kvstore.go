@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+var _ CacheKVStore = &cacheKVStore{}
+
+// cacheKVStore is a read-through LRU cache in front of a MapStore
+type cacheKVStore struct {
+	store    kvstore.MapStore
+	capacity int
+
+	mu sync.Mutex
+	// Most recently used entries are kept at the front of the list
+	lru     *list.List
+	entries map[string]*list.Element
+	// version is bumped when every write starts and completes so that values
+	// read from the underlying store concurrently with a write are never
+	// cached
+	version uint64
+	// writes tracks the writes to the underlying store in progress by key
+	writes map[string]*pendingWrites
+	// clears is bumped by every ClearAll, so that the values written
+	// concurrently with it are not cached
+	clears uint64
+	hits   uint64
+	misses uint64
+}
+
+// pendingWrites are the writes in progress to a single key
+type pendingWrites struct {
+	count int
+	// overlapped is set once two writes to the key were in progress at once,
+	// in which case the order they were applied in is unknown
+	overlapped bool
+}
+
+// entry is a single cached key-value pair
+type entry struct {
+	key   string
+	value []byte
+}
+
+// NewKVStore creates a new CacheKVStore holding at most `capacity` values. The
+// capacity is a number of entries rather than bytes, so the memory used by the
+// cache also depends on the size of the values stored.
+// read from or written to the store provided.
+func NewKVStore(store kvstore.MapStore, capacity int) (CacheKVStore, error) {
+	if store == nil {
+		return nil, ErrCacheNilStore
+	}
+	if capacity <= 0 {
+		return nil, ErrCacheInvalidCapacity
+	}
+	return &cacheKVStore{
+		store:    store,
+		capacity: capacity,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		writes:   make(map[string]*pendingWrites),
+	}, nil
+}
+
+// Get returns the value for a given key, reading it from the underlying store
+// and caching it if it is not already cached
+func (c *cacheKVStore) Get(key []byte) ([]byte, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[string(key)]; ok {
+		c.hits++
+		c.lru.MoveToFront(elem)
+		value := copyBytes(elem.Value.(*entry).value)
+		c.mu.Unlock()
+		return value, nil
+	}
+	c.misses++
+	version := c.version
+	c.mu.Unlock()
+
+	value, err := c.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version == version {
+		c.put(string(key), copyBytes(value))
+	}
+	return value, nil
+}
+
+// Set sets/updates the value for a given key in the underlying store and the
+// cache. The lock is not held while the underlying store is written to, so
+// slow writes do not block the reads served from the cache. The value is only
+// cached if no other write to the key was in progress at the same time.
+func (c *cacheKVStore) Set(key, value []byte) error {
+	clears := c.startWrite(string(key))
+	err := c.store.Set(key, value)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ordered := c.finishWrite(string(key))
+	// On failure the state of the key in the underlying store is unknown
+	if err == nil && ordered && c.clears == clears {
+		c.put(string(key), copyBytes(value))
+	}
+	return err
+}
+
+// Delete removes a key and its value from the underlying store and the cache
+func (c *cacheKVStore) Delete(key []byte) error {
+	c.startWrite(string(key))
+	err := c.store.Delete(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.finishWrite(string(key))
+	return err
+}
+
+// Len returns the number of key-value pairs in the underlying store
+func (c *cacheKVStore) Len() (int, error) {
+	return c.store.Len()
+}
+
+// ClearAll deletes all key-value pairs in the underlying store and the cache.
+// Unlike other writes it holds the lock while clearing the underlying store.
+func (c *cacheKVStore) ClearAll() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.store.ClearAll()
+	c.clears++
+	c.purge()
+	return err
+}
+
+// Stats returns the hit and miss counters of the cache
+func (c *cacheKVStore) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: c.lru.Len(),
+	}
+}
+
+// Purge drops every cached entry without modifying the underlying store
+func (c *cacheKVStore) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.purge()
+}
+
+// startWrite drops the cached value of the key before it is written to the
+// underlying store, and returns the number of ClearAll calls made so far
+func (c *cacheKVStore) startWrite(key string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	c.remove(key)
+	pending, ok := c.writes[key]
+	if !ok {
+		pending = &pendingWrites{}
+		c.writes[key] = pending
+	}
+	pending.count++
+	pending.overlapped = pending.overlapped || pending.count > 1
+	return c.clears
+}
+
+// finishWrite records the completion of a write to the underlying store and
+// returns whether it did not overlap with other writes to the key, in which
+// case the value it wrote is the one stored. The caller must hold the lock.
+func (c *cacheKVStore) finishWrite(key string) bool {
+	c.version++
+	c.remove(key)
+	pending := c.writes[key]
+	pending.count--
+	if pending.count == 0 {
+		delete(c.writes, key)
+	}
+	return !pending.overlapped
+}
+
+// purge drops every cached entry. The caller must hold the lock.
+func (c *cacheKVStore) purge() {
+	c.version++
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// put caches the value for the key, evicting the least recently used entry if
+// the cache is full. The caller must hold the lock.
+func (c *cacheKVStore) put(key string, value []byte) {
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*entry).value = value
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&entry{key: key, value: value})
+	if c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+}
+
+// remove drops the cached value for the key, if any. The caller must hold
+// the lock.
+func (c *cacheKVStore) remove(key string) {
+	if elem, ok := c.entries[key]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// copyBytes returns a copy of the slice provided so that callers can never
+// modify the cached values
+func copyBytes(bz []byte) []byte {
+	if bz == nil {
+		return nil
+	}
+	cp := make([]byte, len(bz))
+	copy(cp, bz)
+	return cp
+}
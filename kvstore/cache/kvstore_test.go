@@ -0,0 +1,315 @@
+package cache_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/cache"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestCache_KVStore_Invalid(t *testing.T) {
+	_, err := cache.NewKVStore(nil, 10)
+	require.ErrorIs(t, err, cache.ErrCacheNilStore)
+	_, err = cache.NewKVStore(simplemap.NewSimpleMap(), 0)
+	require.ErrorIs(t, err, cache.ErrCacheInvalidCapacity)
+}
+
+func TestCache_KVStore_Conformance(t *testing.T) {
+	kvstoretest.RunMapStoreSuite(t,
+		func(t *testing.T) kvstore.MapStore {
+			// A small capacity exercises evictions during the SMT round-trips
+			store, err := cache.NewKVStore(simplemap.NewSimpleMap(), 16)
+			require.NoError(t, err)
+			return store
+		},
+		kvstoretest.WithKeyNotFoundError(simplemap.ErrKVStoreKeyNotFound),
+		kvstoretest.WithEmptyKeyError(simplemap.ErrKVStoreEmptyKey),
+	)
+}
+
+func TestCache_KVStore_ReadThrough(t *testing.T) {
+	backing := newCountingStore(simplemap.NewSimpleMap())
+	require.NoError(t, backing.Set([]byte("foo"), []byte("bar")))
+	store, err := cache.NewKVStore(backing, 2)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		value, err := store.Get([]byte("foo"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("bar"), value)
+	}
+	require.Equal(t, 1, backing.gets)
+	require.Equal(t, cache.Stats{Hits: 2, Misses: 1, Entries: 1}, store.Stats())
+
+	// Modifying a returned value must not modify the cached value
+	value, err := store.Get([]byte("foo"))
+	require.NoError(t, err)
+	value[0] = 'x'
+	value, err = store.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), value)
+
+	// Missing keys are not cached
+	_, err = store.Get([]byte("missing"))
+	require.ErrorIs(t, err, simplemap.ErrKVStoreKeyNotFound)
+	_, err = store.Get([]byte("missing"))
+	require.ErrorIs(t, err, simplemap.ErrKVStoreKeyNotFound)
+	require.Equal(t, uint64(3), store.Stats().Misses)
+}
+
+func TestCache_KVStore_Eviction(t *testing.T) {
+	backing := newCountingStore(simplemap.NewSimpleMap())
+	store, err := cache.NewKVStore(backing, 2)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set([]byte("a"), []byte("1")))
+	require.NoError(t, store.Set([]byte("b"), []byte("2")))
+	// Access "a" so that "b" becomes the least recently used entry
+	_, err = store.Get([]byte("a"))
+	require.NoError(t, err)
+	require.NoError(t, store.Set([]byte("c"), []byte("3")))
+	require.Equal(t, 2, store.Stats().Entries)
+
+	_, err = store.Get([]byte("a"))
+	require.NoError(t, err)
+	_, err = store.Get([]byte("c"))
+	require.NoError(t, err)
+	require.Equal(t, 0, backing.gets)
+	_, err = store.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Equal(t, 1, backing.gets)
+}
+
+func TestCache_KVStore_Coherence(t *testing.T) {
+	backing := simplemap.NewSimpleMap()
+	store, err := cache.NewKVStore(backing, 10)
+	require.NoError(t, err)
+
+	key := []byte("key")
+	require.NoError(t, store.Set(key, []byte("first")))
+	require.NoError(t, store.Set(key, []byte("second")))
+	value, err := store.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, []byte("second"), value)
+
+	require.NoError(t, store.Delete(key))
+	_, err = store.Get(key)
+	require.ErrorIs(t, err, simplemap.ErrKVStoreKeyNotFound)
+
+	require.NoError(t, store.Set(key, []byte("third")))
+	require.NoError(t, store.ClearAll())
+	require.Equal(t, 0, store.Stats().Entries)
+	_, err = store.Get(key)
+	require.ErrorIs(t, err, simplemap.ErrKVStoreKeyNotFound)
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, 0, length)
+
+	// Purging the cache reveals writes made directly to the backing store
+	require.NoError(t, store.Set(key, []byte("cached")))
+	require.NoError(t, backing.Set(key, []byte("direct")))
+	store.Purge()
+	value, err = store.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, []byte("direct"), value)
+}
+
+func TestCache_KVStore_Concurrent(t *testing.T) {
+	store, err := cache.NewKVStore(newLockedStore(simplemap.NewSimpleMap()), 16)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := []byte(fmt.Sprintf("key-%d", i%32))
+				if i%3 == 0 {
+					_ = store.Set(key, []byte(fmt.Sprintf("%d-%d", w, i)))
+				} else {
+					_, _ = store.Get(key)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	require.LessOrEqual(t, store.Stats().Entries, 16)
+}
+
+func TestCache_KVStore_SlowWrite(t *testing.T) {
+	backing := newBlockingStore(simplemap.NewSimpleMap())
+	store, err := cache.NewKVStore(backing, 16)
+	require.NoError(t, err)
+	require.NoError(t, store.Set([]byte("a"), []byte("1")))
+	require.NoError(t, store.Set([]byte("b"), []byte("2")))
+
+	backing.block()
+	done := make(chan error)
+	go func() { done <- store.Set([]byte("a"), []byte("3")) }()
+	<-backing.blocked
+
+	// Reads of other keys are served while the write is in progress
+	value, err := store.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), value)
+	// The key being written is not served from the cache
+	require.Equal(t, uint64(0), store.Stats().Misses)
+	require.Equal(t, 1, store.Stats().Entries)
+
+	close(backing.release)
+	require.NoError(t, <-done)
+	value, err = store.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("3"), value)
+	require.Equal(t, uint64(2), store.Stats().Hits)
+}
+
+func TestCache_KVStore_OverlappingWrites(t *testing.T) {
+	backing := newBlockingStore(simplemap.NewSimpleMap())
+	store, err := cache.NewKVStore(backing, 16)
+	require.NoError(t, err)
+
+	backing.block()
+	first := make(chan error)
+	go func() { first <- store.Set([]byte("a"), []byte("1")) }()
+	<-backing.blocked
+	// The second write is applied to the underlying store before the first
+	second := make(chan error)
+	go func() { second <- store.Set([]byte("a"), []byte("2")) }()
+	require.NoError(t, <-second)
+	close(backing.release)
+	require.NoError(t, <-first)
+
+	// Neither value is cached since the order of the writes is unknown
+	require.Equal(t, 0, store.Stats().Entries)
+	value, err := store.Get([]byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), value)
+}
+
+func TestCache_KVStore_SMTProofs(t *testing.T) {
+	backing := newCountingStore(simplemap.NewSimpleMap())
+	trie := smt.NewSparseMerkleTrie(backing, sha256.New())
+	for i := 0; i < 100; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+	}
+	require.NoError(t, trie.Commit())
+
+	store, err := cache.NewKVStore(backing, 1000)
+	require.NoError(t, err)
+	root := trie.Root()
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 100; i++ {
+			imported := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+			proof, err := imported.Prove([]byte(fmt.Sprintf("key-%d", i)))
+			require.NoError(t, err)
+			valid, err := smt.VerifyProof(proof, root, []byte(fmt.Sprintf("key-%d", i)), []byte("value"), trie.Spec())
+			require.NoError(t, err)
+			require.True(t, valid)
+		}
+	}
+	// Every node is only read from the backing store once
+	stats := store.Stats()
+	length, err := backing.Len()
+	require.NoError(t, err)
+	require.Equal(t, uint64(backing.gets), stats.Misses)
+	require.LessOrEqual(t, stats.Misses, uint64(length))
+	require.Greater(t, stats.Hits, stats.Misses)
+}
+
+// countingStore counts the Get calls made to the store it wraps
+type countingStore struct {
+	kvstore.MapStore
+	gets int
+}
+
+func newCountingStore(store kvstore.MapStore) *countingStore {
+	return &countingStore{MapStore: store}
+}
+
+func (s *countingStore) Get(key []byte) ([]byte, error) {
+	s.gets++
+	return s.MapStore.Get(key)
+}
+
+// lockedStore makes the store it wraps safe for concurrent use
+type lockedStore struct {
+	mu    sync.Mutex
+	store kvstore.MapStore
+}
+
+func newLockedStore(store kvstore.MapStore) *lockedStore {
+	return &lockedStore{store: store}
+}
+
+func (s *lockedStore) Get(key []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Get(key)
+}
+
+func (s *lockedStore) Set(key, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Set(key, value)
+}
+
+func (s *lockedStore) Delete(key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Delete(key)
+}
+
+func (s *lockedStore) Len() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Len()
+}
+
+func (s *lockedStore) ClearAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.ClearAll()
+}
+
+// blockingStore blocks a single Set until released, once block is called
+type blockingStore struct {
+	lockedStore
+	blocking bool
+	blocked  chan struct{}
+	release  chan struct{}
+}
+
+func newBlockingStore(store kvstore.MapStore) *blockingStore {
+	return &blockingStore{lockedStore: lockedStore{store: store}}
+}
+
+func (s *blockingStore) block() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocking = true
+	s.blocked = make(chan struct{})
+	s.release = make(chan struct{})
+}
+
+func (s *blockingStore) Set(key, value []byte) error {
+	s.mu.Lock()
+	if !s.blocking {
+		defer s.mu.Unlock()
+		return s.store.Set(key, value)
+	}
+	s.blocking = false
+	s.mu.Unlock()
+	close(s.blocked)
+	<-s.release
+	return s.lockedStore.Set(key, value)
+}
@@ -0,0 +1,29 @@
+package encrypted
+
+import (
+	"errors"
+)
+
+var (
+	// ErrEncryptedNilStore is returned when no underlying store is provided
+	ErrEncryptedNilStore = errors.New("underlying store is nil")
+	// ErrEncryptedNilKeyProvider is returned when no key provider is provided
+	ErrEncryptedNilKeyProvider = errors.New("key provider is nil")
+	// ErrEncryptedUnknownKey is returned by a KeyRing for a key ID it does
+	// not hold
+	ErrEncryptedUnknownKey = errors.New("unknown encryption key")
+	// ErrEncryptedCurrentKey is returned when removing the current key of a
+	// KeyRing
+	ErrEncryptedCurrentKey = errors.New("cannot remove the current encryption key")
+	// ErrEncryptedCorruptValue is returned when a value read from the
+	// underlying store is not a valid ciphertext or fails authentication
+	ErrEncryptedCorruptValue = errors.New("encrypted value is corrupt")
+	// ErrEncryptedInvalidKey is returned when a key supplied by the key
+	// provider cannot be used with the selected cipher
+	ErrEncryptedInvalidKey = errors.New("invalid encryption key for cipher")
+	// ErrEncryptedUnableToSeal is returned when a value cannot be encrypted
+	ErrEncryptedUnableToSeal = errors.New("unable to encrypt value")
+	// ErrEncryptedNotIterable is returned by Reseal when the underlying store
+	// does not support iteration
+	ErrEncryptedNotIterable = errors.New("underlying store is not iterable")
+)
@@ -0,0 +1,6 @@
+// Package encrypted provides a MapStore wrapper that encrypts every value at
+// rest with an AEAD cipher (AES-GCM by default) before passing it to the store
+// it wraps. The keys are provided by a caller-supplied KeyProvider, and every
+// ciphertext is prefixed with the ID of the key that sealed it so that keys
+// can be rotated without re-encrypting the whole store at once.
+package encrypted
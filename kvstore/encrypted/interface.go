@@ -0,0 +1,49 @@
+package encrypted
+
+import (
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the EncryptedKVStore can be used as an SMT node store
+var _ kvstore.MapStore = (EncryptedKVStore)(nil)
+
+// EncryptedKVStore is a MapStore that encrypts the values written to the store
+// it wraps and decrypts the values read from it. Keys are stored in plaintext.
+//
+// Every value is authenticated together with its key, so values moved to a
+// different key or modified in the underlying store are reported as corrupt
+// instead of being returned.
+type EncryptedKVStore interface {
+	kvstore.MapStore
+
+	// --- Encryption methods ---
+
+	// Reseal re-encrypts with the current key every value of the underlying
+	// store sealed with a different key, returning the number of values
+	// re-encrypted. It requires the underlying store to be iterable.
+	Reseal() (int, error)
+}
+
+// KeyProvider supplies the keys used to encrypt and decrypt values. The key
+// registered under an ID must never change, since it is needed to decrypt
+// the values sealed with it.
+type KeyProvider interface {
+	// CurrentKey returns the key used to encrypt new values and its ID
+	CurrentKey() (id uint32, key []byte, err error)
+	// Key returns the key with the given ID
+	Key(id uint32) ([]byte, error)
+}
+
+// KeyRing is a KeyProvider holding several keys, which allows rotating the
+// key used to encrypt new values while still decrypting older values
+type KeyRing interface {
+	KeyProvider
+
+	// Add registers a key under the given ID, replacing any previous key
+	Add(id uint32, key []byte)
+	// SetCurrent selects the key used to encrypt new values
+	SetCurrent(id uint32) error
+	// Remove unregisters the key with the given ID, once no values sealed
+	// with it remain
+	Remove(id uint32) error
+}
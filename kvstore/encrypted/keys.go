@@ -0,0 +1,79 @@
+package encrypted
+
+import (
+	"sync"
+)
+
+var _ KeyRing = &keyRing{}
+
+// keyRing is a concurrency-safe, in-memory KeyRing
+type keyRing struct {
+	mu      sync.RWMutex
+	keys    map[uint32][]byte
+	current uint32
+}
+
+// NewKeyRing returns a KeyRing holding a single key, which is used to encrypt
+// new values until another key is selected with SetCurrent
+func NewKeyRing(id uint32, key []byte) KeyRing {
+	ring := &keyRing{keys: make(map[uint32][]byte)}
+	ring.Add(id, key)
+	ring.current = id
+	return ring
+}
+
+// NewStaticKeyProvider returns a KeyProvider always using the key provided,
+// registered under the ID 0
+func NewStaticKeyProvider(key []byte) KeyProvider {
+	return NewKeyRing(0, key)
+}
+
+// CurrentKey returns the key used to encrypt new values and its ID
+func (r *keyRing) CurrentKey() (uint32, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current, r.keys[r.current], nil
+}
+
+// Key returns the key with the given ID
+func (r *keyRing) Key(id uint32) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, ErrEncryptedUnknownKey
+	}
+	return key, nil
+}
+
+// Add registers a key under the given ID, replacing any previous key
+func (r *keyRing) Add(id uint32, key []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[id] = append([]byte{}, key...)
+}
+
+// SetCurrent selects the key used to encrypt new values
+func (r *keyRing) SetCurrent(id uint32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[id]; !ok {
+		return ErrEncryptedUnknownKey
+	}
+	r.current = id
+	return nil
+}
+
+// Remove unregisters the key with the given ID
+func (r *keyRing) Remove(id uint32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == r.current {
+		return ErrEncryptedCurrentKey
+	}
+	if _, ok := r.keys[id]; !ok {
+		return ErrEncryptedUnknownKey
+	}
+	delete(r.keys, id)
+	return nil
+}
@@ -0,0 +1,234 @@
+package encrypted
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+const (
+	// The number of bytes of the key ID prefixing every ciphertext
+	keyIDSize = 4
+	// The number of values read before re-encrypting them in Reseal
+	resealBatchSize = 1000
+)
+
+var _ EncryptedKVStore = &encryptedKVStore{}
+
+// encryptedKVStore encrypts the values of the MapStore it wraps. Ciphertexts
+// are encoded as: [key ID (big endian uint32)][nonce][sealed value].
+type encryptedKVStore struct {
+	store   kvstore.MapStore
+	keys    KeyProvider
+	newAEAD AEADConstructor
+
+	mu sync.Mutex
+	// AEADs are cached by key ID along with the key they were created from
+	aeads map[uint32]*keyedAEAD
+}
+
+// keyedAEAD is an AEAD cipher along with the key it was created from
+type keyedAEAD struct {
+	key  []byte
+	aead cipher.AEAD
+}
+
+// NewKVStore creates a new EncryptedKVStore encrypting the values of the store
+// provided with the keys supplied by the KeyProvider.
+func NewKVStore(store kvstore.MapStore, keys KeyProvider, opts ...Option) (EncryptedKVStore, error) {
+	if store == nil {
+		return nil, ErrEncryptedNilStore
+	}
+	if keys == nil {
+		return nil, ErrEncryptedNilKeyProvider
+	}
+	e := &encryptedKVStore{
+		store:   store,
+		keys:    keys,
+		newAEAD: NewAESGCM,
+		aeads:   make(map[uint32]*keyedAEAD),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	// Ensure the current key can be used before accepting any write
+	if _, _, err := e.currentAEAD(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Get returns the decrypted value for a given key
+func (e *encryptedKVStore) Get(key []byte) ([]byte, error) {
+	ciphertext, err := e.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return e.open(key, ciphertext)
+}
+
+// Set encrypts the value with the current key and stores it under the given key
+func (e *encryptedKVStore) Set(key, value []byte) error {
+	ciphertext, err := e.seal(key, value)
+	if err != nil {
+		return err
+	}
+	return e.store.Set(key, ciphertext)
+}
+
+// Delete removes a key and its value from the underlying store
+func (e *encryptedKVStore) Delete(key []byte) error {
+	return e.store.Delete(key)
+}
+
+// Len returns the number of key-value pairs in the underlying store
+func (e *encryptedKVStore) Len() (int, error) {
+	return e.store.Len()
+}
+
+// ClearAll deletes all key-value pairs in the underlying store
+func (e *encryptedKVStore) ClearAll() error {
+	return e.store.ClearAll()
+}
+
+// Reseal re-encrypts with the current key every value of the underlying store
+// sealed with a different key, returning the number of values re-encrypted.
+func (e *encryptedKVStore) Reseal() (int, error) {
+	iterable, ok := e.store.(kvstore.IterableMapStore)
+	if !ok {
+		return 0, ErrEncryptedNotIterable
+	}
+	currentID, _, err := e.currentAEAD()
+	if err != nil {
+		return 0, err
+	}
+
+	resealed := 0
+	var lowerBound []byte
+	for {
+		// Collect a batch of keys before re-encrypting them, as stores are not
+		// required to support writes while iterating
+		it, err := iterable.RangeIterator(kvstore.IterOptions{
+			LowerBound: lowerBound,
+			Limit:      resealBatchSize,
+		})
+		if err != nil {
+			return resealed, err
+		}
+		var stale [][]byte
+		read := 0
+		for it.Next() {
+			read++
+			lowerBound = append(append(lowerBound[:0], it.Key()...), 0)
+			if id, ok := ciphertextKeyID(it.Value()); !ok || id != currentID {
+				stale = append(stale, append([]byte{}, it.Key()...))
+			}
+		}
+		if err := it.Err(); err != nil {
+			it.Close()
+			return resealed, err
+		}
+		if err := it.Close(); err != nil {
+			return resealed, err
+		}
+		for _, key := range stale {
+			value, err := e.Get(key)
+			if err != nil {
+				return resealed, err
+			}
+			if err := e.Set(key, value); err != nil {
+				return resealed, err
+			}
+			resealed++
+		}
+		if read < resealBatchSize {
+			return resealed, nil
+		}
+	}
+}
+
+// seal encrypts the value with the current key, authenticating it together
+// with the key it is stored under
+func (e *encryptedKVStore) seal(key, value []byte) ([]byte, error) {
+	id, aead, err := e.currentAEAD()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := make([]byte, keyIDSize+aead.NonceSize(), keyIDSize+aead.NonceSize()+len(value)+aead.Overhead())
+	binary.BigEndian.PutUint32(ciphertext, id)
+	nonce := ciphertext[keyIDSize:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Join(ErrEncryptedUnableToSeal, err)
+	}
+	return aead.Seal(ciphertext, nonce, value, key), nil
+}
+
+// open decrypts a ciphertext read from the underlying store under the given key
+func (e *encryptedKVStore) open(key, ciphertext []byte) ([]byte, error) {
+	id, ok := ciphertextKeyID(ciphertext)
+	if !ok {
+		return nil, ErrEncryptedCorruptValue
+	}
+	secret, err := e.keys.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := e.aead(id, secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < keyIDSize+aead.NonceSize()+aead.Overhead() {
+		return nil, ErrEncryptedCorruptValue
+	}
+	nonce := ciphertext[keyIDSize : keyIDSize+aead.NonceSize()]
+	value, err := aead.Open(nil, nonce, ciphertext[keyIDSize+aead.NonceSize():], key)
+	if err != nil {
+		return nil, errors.Join(ErrEncryptedCorruptValue, err)
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	return value, nil
+}
+
+// currentAEAD returns the AEAD for the current key of the key provider
+func (e *encryptedKVStore) currentAEAD() (uint32, cipher.AEAD, error) {
+	id, secret, err := e.keys.CurrentKey()
+	if err != nil {
+		return 0, nil, err
+	}
+	aead, err := e.aead(id, secret)
+	if err != nil {
+		return 0, nil, err
+	}
+	return id, aead, nil
+}
+
+// aead returns the AEAD for the key provided, creating it if the key with the
+// same ID is not already cached
+func (e *encryptedKVStore) aead(id uint32, secret []byte) (cipher.AEAD, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if cached, ok := e.aeads[id]; ok && bytes.Equal(cached.key, secret) {
+		return cached.aead, nil
+	}
+	aead, err := e.newAEAD(secret)
+	if err != nil {
+		return nil, errors.Join(ErrEncryptedInvalidKey, err)
+	}
+	e.aeads[id] = &keyedAEAD{key: append([]byte{}, secret...), aead: aead}
+	return aead, nil
+}
+
+// ciphertextKeyID returns the ID of the key a ciphertext was sealed with
+func ciphertextKeyID(ciphertext []byte) (uint32, bool) {
+	if len(ciphertext) < keyIDSize {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(ciphertext), true
+}
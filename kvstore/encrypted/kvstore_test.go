@@ -0,0 +1,168 @@
+package encrypted_test
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/encrypted"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+var (
+	testKey1 = bytes.Repeat([]byte{1}, 32)
+	testKey2 = bytes.Repeat([]byte{2}, 16)
+)
+
+func TestEncrypted_KVStore_Invalid(t *testing.T) {
+	_, err := encrypted.NewKVStore(nil, encrypted.NewStaticKeyProvider(testKey1))
+	require.ErrorIs(t, err, encrypted.ErrEncryptedNilStore)
+	_, err = encrypted.NewKVStore(simplemap.NewSimpleMap(), nil)
+	require.ErrorIs(t, err, encrypted.ErrEncryptedNilKeyProvider)
+	_, err = encrypted.NewKVStore(simplemap.NewSimpleMap(), encrypted.NewStaticKeyProvider([]byte("short")))
+	require.ErrorIs(t, err, encrypted.ErrEncryptedInvalidKey)
+}
+
+func TestEncrypted_KVStore_Conformance(t *testing.T) {
+	kvstoretest.RunMapStoreSuite(t,
+		func(t *testing.T) kvstore.MapStore {
+			store, err := encrypted.NewKVStore(simplemap.NewSimpleMap(), encrypted.NewStaticKeyProvider(testKey1))
+			require.NoError(t, err)
+			return store
+		},
+		kvstoretest.WithKeyNotFoundError(simplemap.ErrKVStoreKeyNotFound),
+		kvstoretest.WithEmptyKeyError(simplemap.ErrKVStoreEmptyKey),
+	)
+}
+
+func TestEncrypted_KVStore_Ciphertexts(t *testing.T) {
+	underlying := simplemap.NewSimpleMap()
+	store, err := encrypted.NewKVStore(underlying, encrypted.NewStaticKeyProvider(testKey1))
+	require.NoError(t, err)
+
+	value := []byte("a value which must not be stored in plaintext")
+	require.NoError(t, store.Set([]byte("foo"), value))
+	require.NoError(t, store.Set([]byte("bar"), value))
+	foo, err := underlying.Get([]byte("foo"))
+	require.NoError(t, err)
+	bar, err := underlying.Get([]byte("bar"))
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(foo, value))
+	// Every value is sealed with a random nonce
+	require.NotEqual(t, foo[4:], bar[4:])
+	// Ciphertexts are prefixed with the ID of the key that sealed them
+	require.Equal(t, []byte{0, 0, 0, 0}, foo[:4])
+
+	// Values moved to another key fail authentication
+	require.NoError(t, underlying.Set([]byte("bar"), foo))
+	_, err = store.Get([]byte("bar"))
+	require.ErrorIs(t, err, encrypted.ErrEncryptedCorruptValue)
+
+	// Modified and truncated values are detected
+	for _, corrupt := range [][]byte{
+		append(append([]byte{}, foo[:len(foo)-1]...), foo[len(foo)-1]^1),
+		foo[:20],
+		foo[:3],
+		{},
+	} {
+		require.NoError(t, underlying.Set([]byte("foo"), corrupt))
+		_, err = store.Get([]byte("foo"))
+		require.ErrorIs(t, err, encrypted.ErrEncryptedCorruptValue)
+	}
+
+	// A different key cannot decrypt the values
+	require.NoError(t, store.Set([]byte("foo"), value))
+	other, err := encrypted.NewKVStore(underlying, encrypted.NewStaticKeyProvider(bytes.Repeat([]byte{9}, 32)))
+	require.NoError(t, err)
+	_, err = other.Get([]byte("foo"))
+	require.ErrorIs(t, err, encrypted.ErrEncryptedCorruptValue)
+}
+
+func TestEncrypted_KVStore_KeyRotation(t *testing.T) {
+	underlying := simplemap.NewSimpleMap()
+	ring := encrypted.NewKeyRing(1, testKey1)
+	store, err := encrypted.NewKVStore(underlying, ring)
+	require.NoError(t, err)
+	for i := 0; i < 2500; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))))
+	}
+
+	// Rotate the key: old values can still be read and new values use the new key
+	require.ErrorIs(t, ring.SetCurrent(2), encrypted.ErrEncryptedUnknownKey)
+	ring.Add(2, testKey2)
+	require.NoError(t, ring.SetCurrent(2))
+	require.ErrorIs(t, ring.Remove(2), encrypted.ErrEncryptedCurrentKey)
+	require.NoError(t, store.Set([]byte("key-0"), []byte("new value")))
+	ciphertext, err := underlying.Get([]byte("key-0"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 0, 0, 2}, ciphertext[:4])
+	value, err := store.Get([]byte("key-1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value-1"), value)
+
+	// Re-encrypt the remaining values before retiring the old key
+	resealed, err := store.Reseal()
+	require.NoError(t, err)
+	require.Equal(t, 2499, resealed)
+	resealed, err = store.Reseal()
+	require.NoError(t, err)
+	require.Zero(t, resealed)
+	require.NoError(t, ring.Remove(1))
+	require.ErrorIs(t, ring.Remove(1), encrypted.ErrEncryptedUnknownKey)
+
+	for i := 1; i < 2500; i++ {
+		value, err := store.Get([]byte(fmt.Sprintf("key-%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value-%d", i)), value)
+	}
+	value, err = store.Get([]byte("key-0"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("new value"), value)
+
+	// Values sealed with a removed key can no longer be read
+	ring.Add(3, testKey1)
+	require.NoError(t, ring.SetCurrent(3))
+	require.NoError(t, store.Set([]byte("key-0"), []byte("value")))
+	require.NoError(t, ring.SetCurrent(2))
+	require.NoError(t, ring.Remove(3))
+	_, err = store.Get([]byte("key-0"))
+	require.ErrorIs(t, err, encrypted.ErrEncryptedUnknownKey)
+
+	// Reseal requires an iterable store
+	notIterable, err := encrypted.NewKVStore(&mapStoreOnly{underlying}, ring)
+	require.NoError(t, err)
+	_, err = notIterable.Reseal()
+	require.ErrorIs(t, err, encrypted.ErrEncryptedNotIterable)
+}
+
+func TestEncrypted_KVStore_CustomAEAD(t *testing.T) {
+	calls := 0
+	newAEAD := func(key []byte) (cipher.AEAD, error) {
+		calls++
+		return encrypted.NewAESGCM(key)
+	}
+	store, err := encrypted.NewKVStore(
+		simplemap.NewSimpleMap(),
+		encrypted.NewStaticKeyProvider(testKey2),
+		encrypted.WithAEAD(newAEAD),
+	)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+		value, err := store.Get([]byte("foo"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("bar"), value)
+	}
+	// The cipher is only created once per key
+	require.Equal(t, 1, calls)
+}
+
+// mapStoreOnly hides every method of a store but the MapStore ones
+type mapStoreOnly struct {
+	kvstore.MapStore
+}
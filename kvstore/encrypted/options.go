@@ -0,0 +1,32 @@
+package encrypted
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+)
+
+// AEADConstructor creates the AEAD cipher used to encrypt and decrypt values
+// from a key, e.g. `chacha20poly1305.New` from golang.org/x/crypto
+type AEADConstructor func(key []byte) (cipher.AEAD, error)
+
+// Option configures an EncryptedKVStore
+type Option func(*encryptedKVStore)
+
+// WithAEAD selects the AEAD cipher used to encrypt values, instead of the
+// default AES-GCM. Every key supplied by the KeyProvider must be valid for it.
+func WithAEAD(newAEAD AEADConstructor) Option {
+	return func(store *encryptedKVStore) {
+		store.newAEAD = newAEAD
+	}
+}
+
+// NewAESGCM returns an AES-GCM AEAD for the key provided, which must be 16,
+// 24 or 32 bytes long to select AES-128, AES-192 or AES-256. It is the default
+// AEADConstructor.
+func NewAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,10 @@
+package kvstore
+
+import (
+	"errors"
+)
+
+// ErrKeyNotFound is returned, possibly wrapped, by the stores of this library
+// when the key requested is not present in the store. The trie relies on it
+// to tell a node missing from the node store apart from any other failure.
+var ErrKeyNotFound = errors.New("key not found")
@@ -0,0 +1,8 @@
+package instrumented
+
+import (
+	"errors"
+)
+
+// ErrInstrumentedNilStore is returned when no underlying store is provided
+var ErrInstrumentedNilStore = errors.New("underlying store is nil")
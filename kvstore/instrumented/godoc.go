@@ -0,0 +1,5 @@
+// Package instrumented provides a MapStore wrapper which measures the
+// operations made on the store it wraps, reporting per-operation counts, bytes
+// and latencies, and optionally forwarding every operation to an observer,
+// e.g. to log it or export it as a metric.
+package instrumented
@@ -0,0 +1,82 @@
+package instrumented
+
+import (
+	"time"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the InstrumentedKVStore can be used as an SMT node store
+var _ kvstore.MapStore = (InstrumentedKVStore)(nil)
+
+// InstrumentedKVStore is a MapStore measuring the operations made on the store
+// it wraps
+type InstrumentedKVStore interface {
+	kvstore.MapStore
+
+	// --- Instrumentation methods ---
+
+	// Stats returns the statistics of the operations made since the store was
+	// created or last reset
+	Stats() Stats
+	// ResetStats resets the statistics of the store
+	ResetStats()
+}
+
+// Operation identifies a MapStore method
+type Operation string
+
+// The operations measured by the store
+const (
+	OpGet      Operation = "get"
+	OpSet      Operation = "set"
+	OpDelete   Operation = "delete"
+	OpLen      Operation = "len"
+	OpClearAll Operation = "clear_all"
+)
+
+// Event describes a single operation made on the store
+type Event struct {
+	// Op is the operation made
+	Op Operation
+	// Key is the key of the operation, nil for Len and ClearAll
+	Key []byte
+	// Bytes is the size of the value read or written
+	Bytes int
+	// Duration is the time taken by the underlying store
+	Duration time.Duration
+	// Err is the error returned by the underlying store, if any
+	Err error
+}
+
+// Observer is notified of every operation made on the store. It is called
+// synchronously, so it must be safe for concurrent use if the store is, and
+// must not modify the key of the event.
+type Observer interface {
+	StoreOperation(event Event)
+}
+
+// OperationStats holds the statistics of a single operation
+type OperationStats struct {
+	// Count is the number of calls made
+	Count uint64
+	// Errors is the number of calls which returned an error
+	Errors uint64
+	// Bytes is the total size of the values read (Get) or written (Set)
+	Bytes uint64
+	// TotalLatency is the total time spent in the underlying store
+	TotalLatency time.Duration
+	// MaxLatency is the longest time spent in a single call
+	MaxLatency time.Duration
+}
+
+// MeanLatency returns the mean time spent in the underlying store per call
+func (s OperationStats) MeanLatency() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Count)
+}
+
+// Stats holds the statistics of every operation of the store
+type Stats map[Operation]OperationStats
@@ -0,0 +1,147 @@
+package instrumented
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+var _ InstrumentedKVStore = &instrumentedKVStore{}
+
+// instrumentedKVStore measures the operations made on the underlying store
+type instrumentedKVStore struct {
+	store    kvstore.MapStore
+	observer Observer
+	// stats holds the counters of every operation, indexed by opIndex
+	stats [5]opCounters
+}
+
+// opCounters holds the counters of a single operation, updated atomically so
+// that the store is safe for concurrent use if the underlying store is
+type opCounters struct {
+	count        atomic.Uint64
+	errors       atomic.Uint64
+	bytes        atomic.Uint64
+	totalLatency atomic.Int64
+	maxLatency   atomic.Int64
+}
+
+// NewKVStore creates a new InstrumentedKVStore measuring the operations made
+// on the store provided.
+func NewKVStore(store kvstore.MapStore, opts ...Option) (InstrumentedKVStore, error) {
+	if store == nil {
+		return nil, ErrInstrumentedNilStore
+	}
+	instrumented := &instrumentedKVStore{store: store}
+	for _, opt := range opts {
+		opt(instrumented)
+	}
+	return instrumented, nil
+}
+
+// Get returns the value for a given key
+func (s *instrumentedKVStore) Get(key []byte) ([]byte, error) {
+	start := time.Now()
+	value, err := s.store.Get(key)
+	s.record(OpGet, key, len(value), start, err)
+	return value, err
+}
+
+// Set sets/updates the value for a given key
+func (s *instrumentedKVStore) Set(key, value []byte) error {
+	start := time.Now()
+	err := s.store.Set(key, value)
+	s.record(OpSet, key, len(value), start, err)
+	return err
+}
+
+// Delete removes a key
+func (s *instrumentedKVStore) Delete(key []byte) error {
+	start := time.Now()
+	err := s.store.Delete(key)
+	s.record(OpDelete, key, 0, start, err)
+	return err
+}
+
+// Len returns the number of key-value pairs in the store
+func (s *instrumentedKVStore) Len() (int, error) {
+	start := time.Now()
+	length, err := s.store.Len()
+	s.record(OpLen, nil, 0, start, err)
+	return length, err
+}
+
+// ClearAll deletes all key-value pairs in the store
+func (s *instrumentedKVStore) ClearAll() error {
+	start := time.Now()
+	err := s.store.ClearAll()
+	s.record(OpClearAll, nil, 0, start, err)
+	return err
+}
+
+// Stats returns the statistics of the operations made on the store
+func (s *instrumentedKVStore) Stats() Stats {
+	stats := make(Stats, len(s.stats))
+	for _, op := range []Operation{OpGet, OpSet, OpDelete, OpLen, OpClearAll} {
+		counters := &s.stats[opIndex(op)]
+		stats[op] = OperationStats{
+			Count:        counters.count.Load(),
+			Errors:       counters.errors.Load(),
+			Bytes:        counters.bytes.Load(),
+			TotalLatency: time.Duration(counters.totalLatency.Load()),
+			MaxLatency:   time.Duration(counters.maxLatency.Load()),
+		}
+	}
+	return stats
+}
+
+// ResetStats resets the statistics of the store
+func (s *instrumentedKVStore) ResetStats() {
+	for i := range s.stats {
+		counters := &s.stats[i]
+		counters.count.Store(0)
+		counters.errors.Store(0)
+		counters.bytes.Store(0)
+		counters.totalLatency.Store(0)
+		counters.maxLatency.Store(0)
+	}
+}
+
+// record updates the statistics of an operation and notifies the observer
+func (s *instrumentedKVStore) record(op Operation, key []byte, bytes int, start time.Time, err error) {
+	duration := time.Since(start)
+	counters := &s.stats[opIndex(op)]
+	counters.count.Add(1)
+	if err != nil {
+		counters.errors.Add(1)
+	} else {
+		counters.bytes.Add(uint64(bytes))
+	}
+	counters.totalLatency.Add(int64(duration))
+	for {
+		current := counters.maxLatency.Load()
+		if int64(duration) <= current || counters.maxLatency.CompareAndSwap(current, int64(duration)) {
+			break
+		}
+	}
+	if s.observer != nil {
+		s.observer.StoreOperation(Event{Op: op, Key: key, Bytes: bytes, Duration: duration, Err: err})
+	}
+}
+
+// opIndex returns the index of the counters of an operation
+func opIndex(op Operation) int {
+	switch op {
+	case OpGet:
+		return 0
+	case OpSet:
+		return 1
+	case OpDelete:
+		return 2
+	case OpLen:
+		return 3
+	default:
+		return 4
+	}
+}
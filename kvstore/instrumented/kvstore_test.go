@@ -0,0 +1,120 @@
+package instrumented_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/instrumented"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/memdb"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// recordingObserver records every operation it observes
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []instrumented.Event
+}
+
+func (o *recordingObserver) StoreOperation(event instrumented.Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+}
+
+func TestInstrumented_KVStore_Invalid(t *testing.T) {
+	_, err := instrumented.NewKVStore(nil)
+	require.ErrorIs(t, err, instrumented.ErrInstrumentedNilStore)
+}
+
+func TestInstrumented_KVStore_Conformance(t *testing.T) {
+	kvstoretest.RunMapStoreSuite(t,
+		func(t *testing.T) kvstore.MapStore {
+			store, err := instrumented.NewKVStore(simplemap.NewSimpleMap())
+			require.NoError(t, err)
+			return store
+		},
+		kvstoretest.WithKeyNotFoundError(simplemap.ErrKVStoreKeyNotFound),
+		// The wrapped simple map does not copy values
+		kvstoretest.WithSharedValues(),
+		kvstoretest.WithEmptyKeyError(simplemap.ErrKVStoreEmptyKey),
+	)
+}
+
+func TestInstrumented_KVStore_Stats(t *testing.T) {
+	faulty := kvstoretest.NewFaultyMapStore(simplemap.NewSimpleMap())
+	observer := &recordingObserver{}
+	store, err := instrumented.NewKVStore(faulty, instrumented.WithObserver(observer))
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set([]byte("foo"), []byte("value")))
+	require.NoError(t, store.Set([]byte("bar"), []byte("longer value")))
+	_, err = store.Get([]byte("foo"))
+	require.NoError(t, err)
+	_, err = store.Get([]byte("baz"))
+	require.ErrorIs(t, err, simplemap.ErrKVStoreKeyNotFound)
+	require.NoError(t, store.Delete([]byte("bar")))
+	faulty.SetLatency(time.Millisecond)
+	_, err = store.Len()
+	require.NoError(t, err)
+	faulty.SetLatency(0)
+	faulty.FailKeys(kvstoretest.OpSet, []byte("foo"))
+	require.ErrorIs(t, store.Set([]byte("foo"), []byte("value")), kvstoretest.ErrInjectedFault)
+	require.NoError(t, store.ClearAll())
+
+	stats := store.Stats()
+	require.Equal(t, uint64(3), stats[instrumented.OpSet].Count)
+	require.Equal(t, uint64(1), stats[instrumented.OpSet].Errors)
+	require.Equal(t, uint64(len("value")+len("longer value")), stats[instrumented.OpSet].Bytes)
+	require.Equal(t, uint64(2), stats[instrumented.OpGet].Count)
+	require.Equal(t, uint64(1), stats[instrumented.OpGet].Errors)
+	require.Equal(t, uint64(len("value")), stats[instrumented.OpGet].Bytes)
+	require.Equal(t, uint64(1), stats[instrumented.OpDelete].Count)
+	require.Equal(t, uint64(1), stats[instrumented.OpLen].Count)
+	require.GreaterOrEqual(t, stats[instrumented.OpLen].MaxLatency, time.Millisecond)
+	require.Equal(t, stats[instrumented.OpLen].TotalLatency, stats[instrumented.OpLen].MeanLatency())
+	require.Equal(t, uint64(1), stats[instrumented.OpClearAll].Count)
+
+	ops := make([]instrumented.Operation, 0, len(observer.events))
+	for _, event := range observer.events {
+		ops = append(ops, event.Op)
+	}
+	require.Equal(t, []instrumented.Operation{
+		instrumented.OpSet, instrumented.OpSet, instrumented.OpGet, instrumented.OpGet,
+		instrumented.OpDelete, instrumented.OpLen, instrumented.OpSet, instrumented.OpClearAll,
+	}, ops)
+	require.Equal(t, []byte("foo"), observer.events[6].Key)
+	require.ErrorIs(t, observer.events[6].Err, kvstoretest.ErrInjectedFault)
+
+	store.ResetStats()
+	for _, op := range []instrumented.Operation{
+		instrumented.OpGet, instrumented.OpSet, instrumented.OpDelete, instrumented.OpLen, instrumented.OpClearAll,
+	} {
+		require.Zero(t, store.Stats()[op])
+	}
+}
+
+func TestInstrumented_KVStore_Concurrent(t *testing.T) {
+	store, err := instrumented.NewKVStore(memdb.NewKVStore())
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				require.NoError(t, store.Set([]byte{byte(i), byte(j)}, []byte{1}))
+				_, err := store.Get([]byte{byte(i), byte(j)})
+				require.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+	stats := store.Stats()
+	require.Equal(t, uint64(800), stats[instrumented.OpSet].Count)
+	require.Equal(t, uint64(800), stats[instrumented.OpGet].Bytes)
+}
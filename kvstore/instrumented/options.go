@@ -0,0 +1,10 @@
+package instrumented
+
+// Option is a function that configures the store created by NewKVStore
+type Option func(*instrumentedKVStore)
+
+// WithObserver returns an Option that forwards every operation made on the
+// store to the observer provided
+func WithObserver(observer Observer) Option {
+	return func(store *instrumentedKVStore) { store.observer = observer }
+}
@@ -20,3 +20,28 @@ type MapStore interface {
 	// ClearAll deletes all key-value pairs in the store
 	ClearAll() error
 }
+
+// IterableMapStore is a MapStore that can also iterate over its key-value
+// pairs in order. It is implemented by all the stores provided by the kvstore
+// submodules.
+type IterableMapStore interface {
+	MapStore
+
+	// RangeIterator returns an iterator over the keys satisfying the options
+	RangeIterator(opts IterOptions) (Iterator, error)
+}
+
+// RangeDeleter is implemented by stores that can delete all the keys in a
+// range more efficiently than deleting them one by one
+type RangeDeleter interface {
+	// DeleteRange removes all the keys in the range [start, end), a nil start
+	// or end leaving the range unbounded on that side
+	DeleteRange(start, end []byte) error
+}
+
+// PrefixDeleter is implemented by stores that can delete all the keys
+// starting with a prefix more efficiently than deleting them one by one
+type PrefixDeleter interface {
+	// DeletePrefix removes all the keys starting with the prefix
+	DeletePrefix(prefix []byte) error
+}
@@ -0,0 +1,94 @@
+package kvstore
+
+import "bytes"
+
+// Iterator streams the key-value pairs of a store in order, without loading
+// them all in memory. It starts positioned before the first entry:
+//
+//	defer it.Close()
+//	for it.Next() {
+//		key, value := it.Key(), it.Value()
+//		...
+//	}
+//	if err := it.Err(); err != nil { ... }
+//
+// The slices returned by Key and Value are only valid until the next call to
+// Next or Close, and must be copied in order to be retained.
+type Iterator interface {
+	// Next advances the iterator to the next entry, returning false once the
+	// iterator is exhausted, its limit is reached or an error occurred
+	Next() bool
+	// Key returns the key of the current entry
+	Key() []byte
+	// Value returns the value of the current entry
+	Value() []byte
+	// Err returns the error, if any, encountered during iteration
+	Err() error
+	// Close releases the resources held by the iterator. It must always be
+	// called once the iterator is no longer needed.
+	Close() error
+}
+
+// IterOptions configures the range of keys visited by an Iterator. All the
+// constraints provided are combined, so an iterator only visits the keys
+// satisfying every one of them.
+type IterOptions struct {
+	// Prefix restricts iteration to the keys starting with the prefix
+	Prefix []byte
+	// LowerBound is the smallest key (inclusive) visited, if set
+	LowerBound []byte
+	// UpperBound is the key (exclusive) above every key visited, if set
+	UpperBound []byte
+	// Start is the first key (inclusive) visited: the smallest key greater
+	// than or equal to Start when ascending, and the greatest key less than or
+	// equal to Start when descending. It is usually set to resume iteration
+	// from a page boundary.
+	Start []byte
+	// Descending iterates over the keys in reverse lexicographical order
+	Descending bool
+	// Limit is the maximum number of entries visited, or zero if unlimited
+	Limit int
+}
+
+// Bounds returns the range of keys [lower, upper) satisfying the prefix, the
+// bounds and the start key of the options. A nil bound is unbounded.
+func (opts IterOptions) Bounds() (lower, upper []byte) {
+	lower, upper = opts.Prefix, PrefixEnd(opts.Prefix)
+	if len(opts.LowerBound) > 0 && bytes.Compare(opts.LowerBound, lower) > 0 {
+		lower = opts.LowerBound
+	}
+	if opts.UpperBound != nil && (upper == nil || bytes.Compare(opts.UpperBound, upper) < 0) {
+		upper = opts.UpperBound
+	}
+	if opts.Start != nil {
+		if !opts.Descending && bytes.Compare(opts.Start, lower) > 0 {
+			lower = opts.Start
+		}
+		if opts.Descending {
+			// The smallest key greater than Start, so that Start is included
+			startEnd := append(append(make([]byte, 0, len(opts.Start)+1), opts.Start...), 0)
+			if upper == nil || bytes.Compare(startEnd, upper) < 0 {
+				upper = startEnd
+			}
+		}
+	}
+	if len(lower) == 0 {
+		lower = nil
+	}
+	return lower, upper
+}
+
+// PrefixEnd returns the smallest key greater than every key starting with the
+// prefix, or nil if there is no such key (i.e. the prefix is empty or only
+// made of 0xff bytes).
+func PrefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		end[i]++
+		if end[i] != 0 {
+			return end[:i+1]
+		}
+	}
+	return nil
+}
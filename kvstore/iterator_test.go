@@ -0,0 +1,68 @@
+package kvstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterOptions_Bounds(t *testing.T) {
+	tests := []struct {
+		desc         string
+		opts         IterOptions
+		lower, upper []byte
+	}{
+		{
+			desc: "unbounded",
+		},
+		{
+			desc:  "prefix",
+			opts:  IterOptions{Prefix: []byte("ab")},
+			lower: []byte("ab"),
+			upper: []byte("ac"),
+		},
+		{
+			desc:  "prefix ending with 0xff",
+			opts:  IterOptions{Prefix: []byte{'a', 0xff}},
+			lower: []byte{'a', 0xff},
+			upper: []byte("b"),
+		},
+		{
+			desc:  "bounds narrower than prefix",
+			opts:  IterOptions{Prefix: []byte("a"), LowerBound: []byte("ab"), UpperBound: []byte("ad")},
+			lower: []byte("ab"),
+			upper: []byte("ad"),
+		},
+		{
+			desc:  "bounds wider than prefix",
+			opts:  IterOptions{Prefix: []byte("b"), LowerBound: []byte("a"), UpperBound: []byte("d")},
+			lower: []byte("b"),
+			upper: []byte("c"),
+		},
+		{
+			desc:  "ascending start",
+			opts:  IterOptions{LowerBound: []byte("a"), Start: []byte("b")},
+			lower: []byte("b"),
+		},
+		{
+			desc:  "descending start",
+			opts:  IterOptions{Prefix: []byte("a"), Start: []byte("ab"), Descending: true},
+			lower: []byte("a"),
+			upper: []byte{'a', 'b', 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			lower, upper := tt.opts.Bounds()
+			require.Equal(t, tt.lower, lower)
+			require.Equal(t, tt.upper, upper)
+		})
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	require.Nil(t, PrefixEnd(nil))
+	require.Nil(t, PrefixEnd([]byte{0xff, 0xff}))
+	require.Equal(t, []byte("user:2"), PrefixEnd([]byte("user:1")))
+	require.Equal(t, []byte{0x01}, PrefixEnd([]byte{0x00, 0xff}))
+}
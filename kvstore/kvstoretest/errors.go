@@ -0,0 +1,9 @@
+package kvstoretest
+
+import (
+	"errors"
+)
+
+// ErrInjectedFault is returned by a FaultyMapStore for the operations it has
+// been configured to fail
+var ErrInjectedFault = errors.New("injected fault")
@@ -0,0 +1,167 @@
+package kvstoretest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+var _ kvstore.MapStore = (*FaultyMapStore)(nil)
+
+// Operation is a set of MapStore operations faults can be injected into
+type Operation uint8
+
+const (
+	// OpGet selects the `Get` operation
+	OpGet Operation = 1 << iota
+	// OpSet selects the `Set` operation
+	OpSet
+	// OpDelete selects the `Delete` operation
+	OpDelete
+
+	// OpAll selects all the operations faults can be injected into
+	OpAll = OpGet | OpSet | OpDelete
+)
+
+// FaultyMapStore wraps a MapStore to inject failures and latency into its
+// operations, in order to test how its users handle node store errors. The
+// faults are injected before the underlying store is accessed, so a failed
+// operation never modifies it.
+//
+// FaultyMapStore is safe for concurrent use as long as the wrapped store is.
+type FaultyMapStore struct {
+	store kvstore.MapStore
+
+	mu sync.Mutex
+	// The operations failing for each key
+	failKeys map[string]Operation
+	// The operations failing once failAfter of them have succeeded, if set
+	failAfterOps Operation
+	failAfter    int
+	// The number of operations selected by failAfterOps that succeeded
+	counted int
+	// The total number of Get, Set and Delete operations attempted
+	ops     int
+	latency time.Duration
+}
+
+// NewFaultyMapStore returns a FaultyMapStore wrapping the store provided, which
+// does not inject any fault until configured to do so
+func NewFaultyMapStore(store kvstore.MapStore) *FaultyMapStore {
+	return &FaultyMapStore{
+		store:    store,
+		failKeys: make(map[string]Operation),
+	}
+}
+
+// FailKeys makes the operations provided fail for the given keys, in addition
+// to any key and operation configured previously
+func (f *FaultyMapStore) FailKeys(ops Operation, keys ...[]byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, key := range keys {
+		f.failKeys[string(key)] |= ops
+	}
+}
+
+// FailAfter makes the operations provided fail once `n` of them have
+// succeeded, counting from this call
+func (f *FaultyMapStore) FailAfter(ops Operation, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failAfterOps = ops
+	f.failAfter = n
+	f.counted = 0
+}
+
+// SetLatency makes every operation on the store sleep for the given duration
+// before accessing the underlying store
+func (f *FaultyMapStore) SetLatency(latency time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = latency
+}
+
+// Reset removes all the faults and the latency injected into the store
+func (f *FaultyMapStore) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failKeys = make(map[string]Operation)
+	f.failAfterOps = 0
+	f.failAfter = 0
+	f.counted = 0
+	f.latency = 0
+}
+
+// Operations returns the number of Get, Set and Delete operations attempted
+// on the store, including the failed ones
+func (f *FaultyMapStore) Operations() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ops
+}
+
+// Get returns the value for a given key unless a fault is injected
+func (f *FaultyMapStore) Get(key []byte) ([]byte, error) {
+	if err := f.inject(OpGet, key); err != nil {
+		return nil, err
+	}
+	return f.store.Get(key)
+}
+
+// Set sets/updates the value for a given key unless a fault is injected
+func (f *FaultyMapStore) Set(key, value []byte) error {
+	if err := f.inject(OpSet, key); err != nil {
+		return err
+	}
+	return f.store.Set(key, value)
+}
+
+// Delete removes a key unless a fault is injected
+func (f *FaultyMapStore) Delete(key []byte) error {
+	if err := f.inject(OpDelete, key); err != nil {
+		return err
+	}
+	return f.store.Delete(key)
+}
+
+// Len returns the number of key-value pairs in the underlying store
+func (f *FaultyMapStore) Len() (int, error) {
+	f.sleep()
+	return f.store.Len()
+}
+
+// ClearAll deletes all key-value pairs in the underlying store
+func (f *FaultyMapStore) ClearAll() error {
+	f.sleep()
+	return f.store.ClearAll()
+}
+
+// inject sleeps for the configured latency and returns ErrInjectedFault if the
+// operation on the key provided must fail
+func (f *FaultyMapStore) inject(op Operation, key []byte) error {
+	f.sleep()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ops++
+	if f.failKeys[string(key)]&op != 0 {
+		return ErrInjectedFault
+	}
+	if f.failAfterOps&op != 0 {
+		if f.counted >= f.failAfter {
+			return ErrInjectedFault
+		}
+		f.counted++
+	}
+	return nil
+}
+
+func (f *FaultyMapStore) sleep() {
+	f.mu.Lock()
+	latency := f.latency
+	f.mu.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+}
@@ -0,0 +1,91 @@
+package kvstoretest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestFaultyMapStore_Conformance(t *testing.T) {
+	// Without any fault injected the store behaves like the one it wraps
+	kvstoretest.RunMapStoreSuite(t,
+		func(t *testing.T) kvstore.MapStore {
+			return kvstoretest.NewFaultyMapStore(simplemap.NewSimpleMap())
+		},
+		kvstoretest.WithKeyNotFoundError(simplemap.ErrKVStoreKeyNotFound),
+		// The wrapped simple map does not copy values
+		kvstoretest.WithSharedValues(),
+		kvstoretest.WithEmptyKeyError(simplemap.ErrKVStoreEmptyKey),
+	)
+}
+
+func TestFaultyMapStore_FailKeys(t *testing.T) {
+	underlying := simplemap.NewSimpleMap()
+	store := kvstoretest.NewFaultyMapStore(underlying)
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+
+	store.FailKeys(kvstoretest.OpGet|kvstoretest.OpDelete, []byte("foo"))
+	_, err := store.Get([]byte("foo"))
+	require.ErrorIs(t, err, kvstoretest.ErrInjectedFault)
+	require.ErrorIs(t, store.Delete([]byte("foo")), kvstoretest.ErrInjectedFault)
+	// Other operations and keys are not affected
+	require.NoError(t, store.Set([]byte("foo"), []byte("baz")))
+	_, err = store.Get([]byte("missing"))
+	require.ErrorIs(t, err, simplemap.ErrKVStoreKeyNotFound)
+
+	// Failed operations do not reach the underlying store
+	value, err := underlying.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("baz"), value)
+	require.Equal(t, 5, store.Operations())
+
+	// Later calls add to the keys and operations configured previously
+	store.FailKeys(kvstoretest.OpSet, []byte("foo"), []byte("other"))
+	require.ErrorIs(t, store.Set([]byte("foo"), []byte("qux")), kvstoretest.ErrInjectedFault)
+	require.ErrorIs(t, store.Delete([]byte("foo")), kvstoretest.ErrInjectedFault)
+	require.ErrorIs(t, store.Set([]byte("other"), []byte("qux")), kvstoretest.ErrInjectedFault)
+	_, err = store.Get([]byte("other"))
+	require.ErrorIs(t, err, simplemap.ErrKVStoreKeyNotFound)
+
+	store.Reset()
+	value, err = store.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("baz"), value)
+}
+
+func TestFaultyMapStore_FailAfter(t *testing.T) {
+	store := kvstoretest.NewFaultyMapStore(simplemap.NewSimpleMap())
+	store.FailAfter(kvstoretest.OpSet, 2)
+	require.NoError(t, store.Set([]byte("a"), []byte("a")))
+	// Operations which are not selected are not counted
+	_, err := store.Get([]byte("a"))
+	require.NoError(t, err)
+	require.NoError(t, store.Set([]byte("b"), []byte("b")))
+	require.ErrorIs(t, store.Set([]byte("c"), []byte("c")), kvstoretest.ErrInjectedFault)
+	require.ErrorIs(t, store.Set([]byte("d"), []byte("d")), kvstoretest.ErrInjectedFault)
+
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, 2, length)
+
+	// The count restarts when the fault is configured again
+	store.FailAfter(kvstoretest.OpAll, 1)
+	require.NoError(t, store.Delete([]byte("a")))
+	_, err = store.Get([]byte("b"))
+	require.ErrorIs(t, err, kvstoretest.ErrInjectedFault)
+}
+
+func TestFaultyMapStore_Latency(t *testing.T) {
+	store := kvstoretest.NewFaultyMapStore(simplemap.NewSimpleMap())
+	store.SetLatency(10 * time.Millisecond)
+	start := time.Now()
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+	_, err := store.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
@@ -0,0 +1,5 @@
+// Package kvstoretest provides a conformance test suite for implementations of
+// the MapStore interface. Any key-value store, including third-party ones, can
+// prove it is a suitable node store for the SM(S)T by calling
+// `RunMapStoreSuite` from its own tests.
+package kvstoretest
@@ -0,0 +1,495 @@
+package kvstoretest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Factory returns a new, empty store for the test provided. Any resources held
+// by the store should be released using `t.Cleanup`.
+type Factory func(t *testing.T) kvstore.MapStore
+
+// Option configures the expectations of the MapStore suite
+type Option func(*config)
+
+type config struct {
+	keyNotFoundErrs []error
+	emptyKeyErr     error
+	emptyKeyStored  bool
+	sharedValues    bool
+}
+
+// WithKeyNotFoundError requires the errors returned by `Get` for missing keys
+// to match every error provided using `errors.Is`. By default any non-nil
+// error is accepted.
+func WithKeyNotFoundError(errs ...error) Option {
+	return func(cfg *config) { cfg.keyNotFoundErrs = append(cfg.keyNotFoundErrs, errs...) }
+}
+
+// WithEmptyKeyError requires the errors returned for nil and empty keys to
+// match the error provided using `errors.Is`. By default any non-nil error is
+// accepted.
+func WithEmptyKeyError(err error) Option {
+	return func(cfg *config) { cfg.emptyKeyErr = err }
+}
+
+// WithEmptyKeyStored declares that the store only rejects nil keys, and stores
+// the empty key like any other key.
+func WithEmptyKeyStored() Option {
+	return func(cfg *config) { cfg.emptyKeyStored = true }
+}
+
+// WithSharedValues declares that the store keeps the values passed to `Set`
+// and returns them from `Get` without copying them, so only the isolation of
+// the keys is checked.
+func WithSharedValues() Option {
+	return func(cfg *config) { cfg.sharedValues = true }
+}
+
+// RunMapStoreSuite runs the MapStore conformance tests against the stores
+// returned by the factory, each test using a new store. The tests cover the
+// semantics of every MapStore method, the handling of nil and empty keys and
+// values, the isolation of the slices passed to and returned by the store,
+// the range and prefix deletions of the stores supporting them, and the use of
+// the store as the node store of the SMT and SMST, including the garbage
+// collection of unreachable nodes for stores which can be iterated over.
+func RunMapStoreSuite(t *testing.T, factory Factory, opts ...Option) {
+	t.Helper()
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	s := &suite{config: cfg, factory: factory}
+
+	t.Run("SetAndGet", s.testSetAndGet)
+	t.Run("GetMissingKey", s.testGetMissingKey)
+	t.Run("Delete", s.testDelete)
+	t.Run("Len", s.testLen)
+	t.Run("ClearAll", s.testClearAll)
+	t.Run("EmptyKeys", s.testEmptyKeys)
+	t.Run("EmptyValues", s.testEmptyValues)
+	t.Run("BinaryKeys", s.testBinaryKeys)
+	t.Run("CopyIsolation", s.testCopyIsolation)
+	t.Run("DeleteRange", s.testDeleteRange)
+	t.Run("DeletePrefix", s.testDeletePrefix)
+	t.Run("SMTRoundTrip", s.testSMTRoundTrip)
+	t.Run("SMSTRoundTrip", s.testSMSTRoundTrip)
+	t.Run("GarbageCollection", s.testGarbageCollection)
+}
+
+type suite struct {
+	*config
+	factory Factory
+}
+
+func (s *suite) newStore(t *testing.T) kvstore.MapStore {
+	t.Helper()
+	store := s.factory(t)
+	require.NotNil(t, store)
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Zero(t, length, "the factory must return an empty store")
+	return store
+}
+
+func (s *suite) requireValue(t *testing.T, store kvstore.MapStore, key, want []byte) {
+	t.Helper()
+	got, err := store.Get(key)
+	require.NoError(t, err)
+	if len(want) == 0 {
+		// Stores may return either nil or an empty slice for empty values
+		require.Empty(t, got)
+		return
+	}
+	require.Equal(t, want, got)
+}
+
+func (s *suite) requireMissing(t *testing.T, store kvstore.MapStore, key []byte) {
+	t.Helper()
+	value, err := store.Get(key)
+	require.Error(t, err)
+	for _, want := range s.keyNotFoundErrs {
+		require.ErrorIs(t, err, want)
+	}
+	require.Nil(t, value)
+}
+
+func (s *suite) requireLen(t *testing.T, store kvstore.MapStore, want int) {
+	t.Helper()
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, want, length)
+}
+
+func (s *suite) requireEmptyKeyError(t *testing.T, err error) {
+	t.Helper()
+	require.Error(t, err)
+	if s.emptyKeyErr != nil {
+		require.ErrorIs(t, err, s.emptyKeyErr)
+	}
+}
+
+func (s *suite) testSetAndGet(t *testing.T) {
+	store := s.newStore(t)
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+	require.NoError(t, store.Set([]byte("baz"), []byte("qux")))
+	s.requireValue(t, store, []byte("foo"), []byte("bar"))
+	s.requireValue(t, store, []byte("baz"), []byte("qux"))
+
+	// Overwriting a key replaces its value, whatever the length of the values
+	require.NoError(t, store.Set([]byte("foo"), []byte("a much longer value")))
+	s.requireValue(t, store, []byte("foo"), []byte("a much longer value"))
+	require.NoError(t, store.Set([]byte("foo"), []byte("b")))
+	s.requireValue(t, store, []byte("foo"), []byte("b"))
+	s.requireValue(t, store, []byte("baz"), []byte("qux"))
+}
+
+func (s *suite) testGetMissingKey(t *testing.T) {
+	store := s.newStore(t)
+	s.requireMissing(t, store, []byte("missing"))
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+	// Neither prefixes nor extensions of a stored key are stored
+	s.requireMissing(t, store, []byte("fo"))
+	s.requireMissing(t, store, []byte("foo\x00"))
+}
+
+func (s *suite) testDelete(t *testing.T) {
+	store := s.newStore(t)
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+	require.NoError(t, store.Set([]byte("baz"), []byte("qux")))
+
+	require.NoError(t, store.Delete([]byte("foo")))
+	s.requireMissing(t, store, []byte("foo"))
+	s.requireValue(t, store, []byte("baz"), []byte("qux"))
+
+	// Deleting a missing key is not an error
+	require.NoError(t, store.Delete([]byte("foo")))
+	require.NoError(t, store.Delete([]byte("missing")))
+
+	// A deleted key can be set again
+	require.NoError(t, store.Set([]byte("foo"), []byte("new")))
+	s.requireValue(t, store, []byte("foo"), []byte("new"))
+}
+
+func (s *suite) testLen(t *testing.T) {
+	store := s.newStore(t)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+	}
+	s.requireLen(t, store, 100)
+
+	// Overwrites do not change the length
+	require.NoError(t, store.Set([]byte("key-0"), []byte("new value")))
+	s.requireLen(t, store, 100)
+
+	for i := 0; i < 50; i++ {
+		require.NoError(t, store.Delete([]byte(fmt.Sprintf("key-%d", i))))
+	}
+	s.requireLen(t, store, 50)
+
+	// Deleting missing keys does not change the length
+	require.NoError(t, store.Delete([]byte("key-0")))
+	s.requireLen(t, store, 50)
+}
+
+func (s *suite) testClearAll(t *testing.T) {
+	store := s.newStore(t)
+	require.NoError(t, store.ClearAll())
+	s.requireLen(t, store, 0)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+	}
+	require.NoError(t, store.ClearAll())
+	s.requireLen(t, store, 0)
+	s.requireMissing(t, store, []byte("key-0"))
+
+	// The store is still usable after being cleared
+	require.NoError(t, store.Set([]byte("key-0"), []byte("value")))
+	s.requireValue(t, store, []byte("key-0"), []byte("value"))
+	s.requireLen(t, store, 1)
+}
+
+func (s *suite) testEmptyKeys(t *testing.T) {
+	store := s.newStore(t)
+	keys := [][]byte{nil, {}}
+	if s.emptyKeyStored {
+		keys = keys[:1]
+		require.NoError(t, store.Set([]byte{}, []byte("value")))
+		s.requireValue(t, store, []byte{}, []byte("value"))
+		s.requireLen(t, store, 1)
+		require.NoError(t, store.Delete([]byte{}))
+		s.requireMissing(t, store, []byte{})
+	}
+	for _, key := range keys {
+		s.requireEmptyKeyError(t, store.Set(key, []byte("value")))
+		value, err := store.Get(key)
+		s.requireEmptyKeyError(t, err)
+		require.Nil(t, value)
+		s.requireEmptyKeyError(t, store.Delete(key))
+	}
+	s.requireLen(t, store, 0)
+}
+
+func (s *suite) testEmptyValues(t *testing.T) {
+	store := s.newStore(t)
+	require.NoError(t, store.Set([]byte("nil"), nil))
+	require.NoError(t, store.Set([]byte("empty"), []byte{}))
+
+	// Keys with empty values are stored, they are not deletions
+	s.requireValue(t, store, []byte("nil"), nil)
+	s.requireValue(t, store, []byte("empty"), nil)
+	s.requireLen(t, store, 2)
+
+	require.NoError(t, store.Set([]byte("nil"), []byte("value")))
+	s.requireValue(t, store, []byte("nil"), []byte("value"))
+	require.NoError(t, store.Set([]byte("nil"), nil))
+	s.requireValue(t, store, []byte("nil"), nil)
+	s.requireLen(t, store, 2)
+}
+
+func (s *suite) testBinaryKeys(t *testing.T) {
+	store := s.newStore(t)
+	keys := [][]byte{
+		{0x00},
+		{0x00, 0x00},
+		{0xff},
+		{0xff, 0xff, 0x00},
+		{0x01, 0x00, 0x02},
+		bytes.Repeat([]byte{0xab}, 1024),
+	}
+	for i, key := range keys {
+		require.NoError(t, store.Set(key, []byte{byte(i), 0x00, 0xff}))
+	}
+	for i, key := range keys {
+		s.requireValue(t, store, key, []byte{byte(i), 0x00, 0xff})
+	}
+	s.requireLen(t, store, len(keys))
+}
+
+func (s *suite) testCopyIsolation(t *testing.T) {
+	store := s.newStore(t)
+	key := []byte("key")
+	value := []byte("value")
+	require.NoError(t, store.Set(key, value))
+
+	// Modifying the slices passed to Set must not modify the store
+	key[0] = 'x'
+	s.requireValue(t, store, []byte("key"), []byte("value"))
+	s.requireMissing(t, store, []byte("xey"))
+	if s.sharedValues {
+		return
+	}
+	value[0] = 'x'
+	s.requireValue(t, store, []byte("key"), []byte("value"))
+
+	// Modifying the slices returned by Get must not modify the store
+	got, err := store.Get([]byte("key"))
+	require.NoError(t, err)
+	got[0] = 'x'
+	s.requireValue(t, store, []byte("key"), []byte("value"))
+
+	// Appending to the slices returned by Get must not modify the store
+	got, err = store.Get([]byte("key"))
+	require.NoError(t, err)
+	_ = append(got[:1], 'x')
+	s.requireValue(t, store, []byte("key"), []byte("value"))
+}
+
+// rangeKeys are the keys stored to test range and prefix deletions
+var rangeKeys = []string{"a", "b", "ba", "bb", "c"}
+
+// newRangeStore returns a new store holding the rangeKeys
+func (s *suite) newRangeStore(t *testing.T) kvstore.MapStore {
+	t.Helper()
+	store := s.newStore(t)
+	for _, key := range rangeKeys {
+		require.NoError(t, store.Set([]byte(key), []byte("value")))
+	}
+	return store
+}
+
+// requireRangeKeys ensures the store holds exactly the expected rangeKeys
+func (s *suite) requireRangeKeys(t *testing.T, store kvstore.MapStore, expected []string) {
+	t.Helper()
+	for _, key := range rangeKeys {
+		if slices.Contains(expected, key) {
+			s.requireValue(t, store, []byte(key), []byte("value"))
+		} else {
+			s.requireMissing(t, store, []byte(key))
+		}
+	}
+	s.requireLen(t, store, len(expected))
+}
+
+func (s *suite) testDeleteRange(t *testing.T) {
+	if _, ok := s.newStore(t).(kvstore.RangeDeleter); !ok {
+		t.Skip("the store cannot delete ranges")
+	}
+	tests := []struct {
+		desc         string
+		start, end   []byte
+		expectedKeys []string
+	}{
+		{
+			desc:         "Delete bounded range",
+			start:        []byte("b"),
+			end:          []byte("c"),
+			expectedKeys: []string{"a", "c"},
+		},
+		{
+			desc:         "Delete unbounded range",
+			start:        []byte("ba"),
+			expectedKeys: []string{"a", "b"},
+		},
+		{
+			desc:         "Delete everything",
+			expectedKeys: nil,
+		},
+		{
+			desc:         "Delete empty range",
+			start:        []byte("d"),
+			end:          []byte("e"),
+			expectedKeys: rangeKeys,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			store := s.newRangeStore(t)
+			require.NoError(t, store.(kvstore.RangeDeleter).DeleteRange(tt.start, tt.end))
+			s.requireRangeKeys(t, store, tt.expectedKeys)
+		})
+	}
+}
+
+func (s *suite) testDeletePrefix(t *testing.T) {
+	store, ok := s.newRangeStore(t).(interface {
+		kvstore.MapStore
+		kvstore.PrefixDeleter
+	})
+	if !ok {
+		t.Skip("the store cannot delete prefixes")
+	}
+	require.NoError(t, store.DeletePrefix([]byte("b")))
+	s.requireRangeKeys(t, store, []string{"a", "c"})
+
+	// Prefixes made of the largest bytes have no upper bound
+	require.NoError(t, store.Set([]byte{0xff, 0xff}, []byte("value")))
+	require.NoError(t, store.DeletePrefix([]byte{0xff}))
+	s.requireMissing(t, store, []byte{0xff, 0xff})
+	s.requireRangeKeys(t, store, []string{"a", "c"})
+
+	require.NoError(t, store.DeletePrefix(nil))
+	s.requireRangeKeys(t, store, nil)
+}
+
+func (s *suite) testSMTRoundTrip(t *testing.T) {
+	store := s.newStore(t)
+	trie := smt.NewSparseMerkleTrie(store, sha256.New())
+	for i := 0; i < 100; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i))))
+	}
+	require.NoError(t, trie.Commit())
+	for i := 0; i < 50; i++ {
+		require.NoError(t, trie.Delete([]byte(fmt.Sprintf("key-%d", i))))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+
+	// A trie imported from the store must have the same contents and proofs
+	imported := smt.ImportSparseMerkleTrie(store, sha256.New(), root)
+	for i := 0; i < 100; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		value, err := imported.Get(key)
+		require.NoError(t, err)
+		want := []byte(fmt.Sprintf("value-%d", i))
+		if i < 50 {
+			require.Nil(t, value)
+			want = nil
+		} else {
+			digest := sha256.Sum256(want)
+			require.Equal(t, digest[:], value)
+		}
+		proof, err := imported.Prove(key)
+		require.NoError(t, err)
+		valid, err := smt.VerifyProof(proof, root, key, want, imported.Spec())
+		require.NoError(t, err)
+		require.True(t, valid)
+	}
+
+	// Deleting every key must leave no nodes behind in the store
+	for i := 50; i < 100; i++ {
+		require.NoError(t, imported.Delete([]byte(fmt.Sprintf("key-%d", i))))
+	}
+	require.NoError(t, imported.Commit())
+	empty := smt.NewSparseMerkleTrie(store, sha256.New())
+	require.Equal(t, empty.Root(), imported.Root())
+	s.requireLen(t, store, 0)
+}
+
+func (s *suite) testSMSTRoundTrip(t *testing.T) {
+	store := s.newStore(t)
+	trie := smt.NewSparseMerkleSumTrie(store, sha256.New())
+	for i := 0; i < 100; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte("value"), uint64(i)))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+
+	imported := smt.ImportSparseMerkleSumTrie(store, sha256.New(), root)
+	sum, err := imported.Sum()
+	require.NoError(t, err)
+	require.Equal(t, uint64(99*100/2), sum)
+	count, err := imported.Count()
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), count)
+
+	key := []byte("key-42")
+	_, weight, err := imported.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), weight)
+	proof, err := imported.Prove(key)
+	require.NoError(t, err)
+	valid, err := smt.VerifySumProof(proof, root, key, []byte("value"), 42, 1, imported.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func (s *suite) testGarbageCollection(t *testing.T) {
+	store, ok := s.newStore(t).(kvstore.IterableMapStore)
+	if !ok {
+		t.Skip("the store cannot be iterated over")
+	}
+	spec := smt.NewTrieSpec(sha256.New(), false)
+	// Enough nodes for the store to be swept in several batches
+	roots := make([][]byte, 2)
+	for i := range roots {
+		trie := smt.NewSparseMerkleTrie(store, sha256.New())
+		for j := 0; j < 400; j++ {
+			require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d-%d", i, j)), []byte("value")))
+		}
+		require.NoError(t, trie.Commit())
+		roots[i] = trie.Root()
+	}
+	require.NoError(t, store.Set([]byte("metadata"), []byte("value")))
+
+	report, err := smt.CollectGarbage(store, &spec, roots[0])
+	require.NoError(t, err)
+	require.Equal(t, report.Unreachable, report.Deleted)
+	require.Positive(t, report.Deleted)
+	s.requireLen(t, store, report.Marked+1)
+	s.requireValue(t, store, []byte("metadata"), []byte("value"))
+
+	check, err := smt.Check(store, &spec, roots[0])
+	require.NoError(t, err)
+	require.True(t, check.OK())
+	require.Equal(t, report.Marked, check.Nodes)
+}
@@ -0,0 +1,113 @@
+package logstore
+
+import (
+	"errors"
+	"os"
+	"sort"
+)
+
+// Compact rewrites the store in the directory provided so that it only holds
+// the latest value of every live key, reclaiming the space of overwritten and
+// deleted values. It must not be called while the store is open.
+//
+// The live records are first written to new segments, numbered after the
+// existing ones, which are then removed in order. A crash at any point leaves
+// a directory which opens to the same contents, and compaction can simply be
+// run again.
+func Compact(path string, opts ...Option) error {
+	cfg, err := newConfig(opts...)
+	if err != nil {
+		return err
+	}
+	// The store is only written to by the compaction
+	cfg.Sync = SyncNever
+	store, err := openStore(path, cfg)
+	if err != nil {
+		return errors.Join(ErrLogStoreCompacting, err)
+	}
+	if err := store.compact(); err != nil {
+		store.closeFiles()
+		return errors.Join(ErrLogStoreCompacting, err)
+	}
+	if err := store.closeFiles(); err != nil {
+		return errors.Join(ErrLogStoreCompacting, err)
+	}
+	return nil
+}
+
+// compact writes the live records of the store to new segments and removes
+// the previous ones
+func (store *logKVStore) compact() error {
+	oldIDs := store.segmentIDs()
+	keys := make([]string, 0, len(store.index))
+	for key := range store.index {
+		keys = append(keys, key)
+	}
+	// Sorting the keys keeps neighbouring keys close to each other on disk
+	sort.Strings(keys)
+
+	var (
+		id   = store.activeID
+		file *os.File
+		size int64
+		hint map[string]hintEntry
+	)
+	seal := func() error {
+		if file == nil {
+			return nil
+		}
+		defer file.Close()
+		if err := file.Sync(); err != nil {
+			return err
+		}
+		return writeHintFile(store.path, id, hint)
+	}
+	for _, key := range keys {
+		if file == nil || size >= store.cfg.MaxSegmentSize {
+			if err := seal(); err != nil {
+				return err
+			}
+			id++
+			var err error
+			file, err = os.OpenFile(segmentPath(store.path, id), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+			if err != nil {
+				return err
+			}
+			size, hint = 0, make(map[string]hintEntry)
+		}
+		value, err := store.Get([]byte(key))
+		if err != nil {
+			file.Close()
+			return err
+		}
+		rec := encodeRecord(kindPut, []byte(key), value)
+		if _, err := file.WriteAt(rec, size); err != nil {
+			file.Close()
+			return err
+		}
+		hint[key] = hintEntry{
+			kind: kindPut,
+			loc:  location{segment: id, offset: size, keyLen: uint32(len(key)), valueLen: uint32(len(value))},
+		}
+		size += int64(len(rec))
+	}
+	if err := seal(); err != nil {
+		return err
+	}
+	// The new segments must be persisted before the old ones are removed
+	if err := syncDir(store.path); err != nil {
+		return err
+	}
+	// Removing the old segments in order only ever leaves the most recent of
+	// them behind, which replay to the same state before the new segments
+	for _, oldID := range oldIDs {
+		if file, ok := store.segments[oldID]; ok {
+			file.Close()
+			delete(store.segments, oldID)
+		}
+		if err := removeSegment(store.path, oldID); err != nil {
+			return err
+		}
+	}
+	return syncDir(store.path)
+}
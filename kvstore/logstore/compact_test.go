@@ -0,0 +1,124 @@
+package logstore_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/logstore"
+)
+
+func TestLogStore_Compact(t *testing.T) {
+	dir := t.TempDir()
+	opt := logstore.WithMaxSegmentSize(1 << 10)
+	store, err := logstore.NewKVStore(dir, opt)
+	require.NoError(t, err)
+	expected := writeRandom(t, store, 2000)
+	require.NoError(t, store.Stop())
+	before := dirSize(t, dir)
+
+	require.NoError(t, logstore.Compact(dir, opt))
+	require.Less(t, dirSize(t, dir), before/2)
+
+	store, err = logstore.NewKVStore(dir, opt)
+	require.NoError(t, err)
+	requireContents(t, store, expected)
+	// The compacted store can be written to and compacted again
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+	expected["foo"] = "bar"
+	require.NoError(t, store.Stop())
+	require.NoError(t, logstore.Compact(dir, opt))
+	store, err = logstore.NewKVStore(dir, opt)
+	require.NoError(t, err)
+	defer store.Stop()
+	requireContents(t, store, expected)
+}
+
+func TestLogStore_Compact_Empty(t *testing.T) {
+	dir := t.TempDir()
+	store, err := logstore.NewKVStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+	require.NoError(t, store.Delete([]byte("foo")))
+	require.NoError(t, store.Stop())
+
+	require.NoError(t, logstore.Compact(dir))
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+	store, err = logstore.NewKVStore(dir)
+	require.NoError(t, err)
+	defer store.Stop()
+	requireContents(t, store, map[string]string{})
+}
+
+func TestLogStore_Compact_Interrupted(t *testing.T) {
+	dir := t.TempDir()
+	opt := logstore.WithMaxSegmentSize(1 << 10)
+	store, err := logstore.NewKVStore(dir, opt)
+	require.NoError(t, err)
+	expected := writeRandom(t, store, 2000)
+	require.NoError(t, store.Stop())
+
+	// Keep a copy of the segments to simulate a crash before all of them were
+	// removed by the compaction
+	old := readDir(t, dir)
+	require.NoError(t, logstore.Compact(dir, opt))
+	compacted := readDir(t, dir)
+	for i, name := range sortedNames(old) {
+		if i < len(old)/2 {
+			continue
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), old[name], 0o644))
+	}
+
+	store, err = logstore.NewKVStore(dir, opt)
+	require.NoError(t, err)
+	requireContents(t, store, expected)
+	require.NoError(t, store.Stop())
+
+	// Compacting again removes the leftover segments
+	require.NoError(t, logstore.Compact(dir, opt))
+	require.Len(t, readDir(t, dir), len(compacted))
+	store, err = logstore.NewKVStore(dir, opt)
+	require.NoError(t, err)
+	defer store.Stop()
+	requireContents(t, store, expected)
+}
+
+// readDir returns the contents of the files of a directory by name
+func readDir(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	files := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		require.NoError(t, err)
+		files[entry.Name()] = data
+	}
+	return files
+}
+
+// sortedNames returns the names of the files in order
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dirSize returns the total size of the files of a directory
+func dirSize(t *testing.T, dir string) int {
+	t.Helper()
+	size := 0
+	for _, data := range readDir(t, dir) {
+		size += len(data)
+	}
+	return size
+}
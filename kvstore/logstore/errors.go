@@ -0,0 +1,39 @@
+package logstore
+
+import (
+	"errors"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+var (
+	// ErrLogStoreKeyNotFound is returned when the key is not in the store
+	ErrLogStoreKeyNotFound = kvstore.ErrKeyNotFound
+	// ErrLogStoreEmptyKey is returned when an empty key is provided
+	ErrLogStoreEmptyKey = errors.New("empty key provided")
+	// ErrLogStoreRecordTooLarge is returned when a key or a value exceeds the
+	// maximum size of a record
+	ErrLogStoreRecordTooLarge = errors.New("key or value is too large")
+	// ErrLogStoreInvalidOption is returned when the store is configured with
+	// invalid options
+	ErrLogStoreInvalidOption = errors.New("invalid option")
+	// ErrLogStoreOpeningStore is returned when the store cannot be opened
+	ErrLogStoreOpeningStore = errors.New("unable to open store")
+	// ErrLogStoreCorrupt is returned when a record of a segment, other than the
+	// torn tail of the last one, fails validation
+	ErrLogStoreCorrupt = errors.New("corrupt segment record")
+	// ErrLogStoreUnableToGetValue is returned when a value cannot be read
+	ErrLogStoreUnableToGetValue = errors.New("unable to get value")
+	// ErrLogStoreUnableToWrite is returned when a record cannot be appended
+	ErrLogStoreUnableToWrite = errors.New("unable to write record")
+	// ErrLogStoreUnableToSync is returned when the log cannot be synced to disk
+	ErrLogStoreUnableToSync = errors.New("unable to sync store")
+	// ErrLogStoreClearingStore is returned when the store cannot be cleared
+	ErrLogStoreClearingStore = errors.New("unable to clear store")
+	// ErrLogStoreClosingStore is returned when the store cannot be closed
+	ErrLogStoreClosingStore = errors.New("unable to close store")
+	// ErrLogStoreClosed is returned when the store is used after being stopped
+	ErrLogStoreClosed = errors.New("store is closed")
+	// ErrLogStoreCompacting is returned when the store cannot be compacted
+	ErrLogStoreCompacting = errors.New("unable to compact store")
+)
@@ -0,0 +1,6 @@
+// Package logstore provides a dependency-free, persistent key-value store
+// built on an append-only log of segment files, in the spirit of Bitcask. The
+// keys and the location of their values are indexed in memory, so it is best
+// suited to stores whose keys fit in memory, such as the node store of a
+// trie used by lightweight binaries which cannot afford larger databases.
+package logstore
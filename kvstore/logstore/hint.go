@@ -0,0 +1,93 @@
+package logstore
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+)
+
+// Hint files hold the final state of every key written to a sealed segment,
+// so that the index can be rebuilt without reading the values. They are
+// encoded as a sequence of entries followed by the CRC-32C of the entries:
+//
+//	[kind (1)][key length (4)][value length (4)][offset (8)][key]
+const hintEntryHeaderSize = 1 + 4 + 4 + 8
+
+// hintEntry is the final state of a key in a segment
+type hintEntry struct {
+	kind byte
+	loc  location
+}
+
+// writeHintFile atomically writes the hint file of a segment
+func writeHintFile(dir string, id uint32, entries map[string]hintEntry) error {
+	size := 4
+	for key := range entries {
+		size += hintEntryHeaderSize + len(key)
+	}
+	data := make([]byte, 0, size)
+	for key, entry := range entries {
+		data = append(data, entry.kind)
+		data = binary.BigEndian.AppendUint32(data, uint32(len(key)))
+		data = binary.BigEndian.AppendUint32(data, entry.loc.valueLen)
+		data = binary.BigEndian.AppendUint64(data, uint64(entry.loc.offset))
+		data = append(data, key...)
+	}
+	data = binary.BigEndian.AppendUint32(data, crc32.Checksum(data, crcTable))
+
+	tmpPath := hintPath(dir, id) + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, hintPath(dir, id))
+}
+
+// readHintFile reads the hint file of a segment, calling fn with the kind, key
+// and location of every entry. It returns false if the hint file is missing or
+// invalid, in which case fn is never called.
+func readHintFile(dir string, id uint32, fn func(kind byte, key []byte, loc location)) bool {
+	data, err := os.ReadFile(hintPath(dir, id))
+	if err != nil || len(data) < 4 {
+		return false
+	}
+	entries := data[:len(data)-4]
+	if binary.BigEndian.Uint32(data[len(data)-4:]) != crc32.Checksum(entries, crcTable) {
+		return false
+	}
+	// Validate every entry before calling fn
+	for rest := entries; len(rest) > 0; {
+		if len(rest) < hintEntryHeaderSize {
+			return false
+		}
+		keyLen := int(binary.BigEndian.Uint32(rest[1:]))
+		if keyLen > len(rest)-hintEntryHeaderSize {
+			return false
+		}
+		rest = rest[hintEntryHeaderSize+keyLen:]
+	}
+	for rest := entries; len(rest) > 0; {
+		kind := rest[0]
+		keyLen := binary.BigEndian.Uint32(rest[1:])
+		loc := location{
+			segment:  id,
+			keyLen:   keyLen,
+			valueLen: binary.BigEndian.Uint32(rest[5:]),
+			offset:   int64(binary.BigEndian.Uint64(rest[9:])),
+		}
+		fn(kind, rest[hintEntryHeaderSize:hintEntryHeaderSize+keyLen], loc)
+		rest = rest[hintEntryHeaderSize+int(keyLen):]
+	}
+	return true
+}
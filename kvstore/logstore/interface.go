@@ -0,0 +1,32 @@
+package logstore
+
+import (
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the LogKVStore can be used as an SMT node store
+var _ kvstore.MapStore = (LogKVStore)(nil)
+
+// Ensure the LogKVStore can be iterated over
+var _ kvstore.IterableMapStore = (LogKVStore)(nil)
+
+// LogKVStore is a persistent MapStore appending every write to a log of
+// segment files. Overwritten and deleted values are only reclaimed by
+// compacting the store with `Compact` while it is not open.
+//
+// A directory must only be opened by a single store at a time.
+type LogKVStore interface {
+	kvstore.MapStore
+
+	// --- Lifecycle methods ---
+
+	// Stop syncs and closes the store
+	Stop() error
+	// Sync flushes the writes to the active segment to disk
+	Sync() error
+
+	// --- Accessors ---
+
+	// RangeIterator returns an iterator over the keys satisfying the options
+	RangeIterator(opts kvstore.IterOptions) (kvstore.Iterator, error)
+}
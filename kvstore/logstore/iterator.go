@@ -0,0 +1,80 @@
+package logstore
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// logIterator iterates over a sorted copy of the keys of the index taken when
+// the iterator was created, reading their values from the log as it goes
+type logIterator struct {
+	store *logKVStore
+	keys  []string
+	idx   int
+	value []byte
+	err   error
+}
+
+// RangeIterator returns an iterator over the keys satisfying the options.
+// The matching keys are copied and sorted when the iterator is created, while
+// values are read as the iterator advances: keys deleted in the meantime are
+// skipped and updated keys return their latest value.
+func (store *logKVStore) RangeIterator(opts kvstore.IterOptions) (kvstore.Iterator, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	if store.closed {
+		return nil, ErrLogStoreClosed
+	}
+	lower, upper := opts.Bounds()
+	keys := make([]string, 0)
+	for key := range store.index {
+		if bytes.Compare([]byte(key), lower) >= 0 && (upper == nil || bytes.Compare([]byte(key), upper) < 0) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if opts.Descending {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+	if opts.Limit > 0 && len(keys) > opts.Limit {
+		keys = keys[:opts.Limit]
+	}
+	return &logIterator{store: store, keys: keys, idx: -1}, nil
+}
+
+// Next satisfies the kvstore.Iterator#Next interface
+func (it *logIterator) Next() bool {
+	for it.err == nil && it.idx+1 < len(it.keys) {
+		it.idx++
+		value, err := it.store.Get([]byte(it.keys[it.idx]))
+		if errors.Is(err, ErrLogStoreKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			it.err = err
+			break
+		}
+		it.value = value
+		return true
+	}
+	it.idx = len(it.keys)
+	it.value = nil
+	return false
+}
+
+// Key satisfies the kvstore.Iterator#Key interface
+func (it *logIterator) Key() []byte { return []byte(it.keys[it.idx]) }
+
+// Value satisfies the kvstore.Iterator#Value interface
+func (it *logIterator) Value() []byte { return it.value }
+
+// Err satisfies the kvstore.Iterator#Err interface
+func (it *logIterator) Err() error { return it.err }
+
+// Close satisfies the kvstore.Iterator#Close interface
+func (it *logIterator) Close() error { return nil }
@@ -0,0 +1,414 @@
+package logstore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ LogKVStore = &logKVStore{}
+
+// logKVStore appends every write to the active segment of the log, indexing
+// the location of the latest value of every key in memory
+type logKVStore struct {
+	path string
+	cfg  Config
+
+	mu sync.RWMutex
+	// index holds the location of the latest record of every live key
+	index map[string]location
+	// segments holds the open segment files, including the active one
+	segments map[uint32]*os.File
+	// active is the segment the records are appended to
+	active     *os.File
+	activeID   uint32
+	activeSize int64
+	// activeHint holds the final state of the keys written to the active
+	// segment, written to its hint file once it is sealed
+	activeHint map[string]hintEntry
+	// dirty is set when the active segment has unsynced writes
+	dirty  bool
+	closed bool
+	// syncErr holds the error of the last failed background sync, returned by
+	// the next call to Sync or Stop
+	syncErr error
+
+	// Channels used to stop the background sync goroutine, if any
+	stopSync chan struct{}
+	syncDone chan struct{}
+}
+
+// NewKVStore opens the LogKVStore in the directory provided, creating it if
+// it does not exist. The index is rebuilt from the hint files of the sealed
+// segments and by scanning the others; an incomplete record at the end of the
+// last segment, as left behind by a crash, is truncated. See `Config` for the
+// options available.
+func NewKVStore(path string, opts ...Option) (LogKVStore, error) {
+	cfg, err := newConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return openStore(path, cfg)
+}
+
+// openStore opens the store in the directory provided and starts its
+// background sync goroutine, if any
+func openStore(path string, cfg Config) (*logKVStore, error) {
+	if path == "" {
+		return nil, errors.Join(ErrLogStoreOpeningStore, errors.New("empty path"))
+	}
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, errors.Join(ErrLogStoreOpeningStore, err)
+	}
+	store := &logKVStore{
+		path:     path,
+		cfg:      cfg,
+		index:    make(map[string]location),
+		segments: make(map[uint32]*os.File),
+	}
+	if err := store.load(); err != nil {
+		store.closeFiles()
+		return nil, err
+	}
+	if cfg.Sync == SyncPeriodic {
+		store.stopSync = make(chan struct{})
+		store.syncDone = make(chan struct{})
+		go store.runSync(cfg.SyncInterval)
+	}
+	return store, nil
+}
+
+// load rebuilds the index from the segments of the store
+func (store *logKVStore) load() error {
+	ids, err := listSegments(store.path)
+	if err != nil {
+		return errors.Join(ErrLogStoreOpeningStore, err)
+	}
+	if len(ids) == 0 {
+		return store.createActive(1)
+	}
+	apply := func(kind byte, key []byte, loc location) {
+		if kind == kindPut {
+			store.index[string(key)] = loc
+		} else {
+			delete(store.index, string(key))
+		}
+	}
+	sealed, last := ids[:len(ids)-1], ids[len(ids)-1]
+	for _, id := range sealed {
+		file, err := os.Open(segmentPath(store.path, id))
+		if err != nil {
+			return errors.Join(ErrLogStoreOpeningStore, err)
+		}
+		store.segments[id] = file
+		if readHintFile(store.path, id, apply) {
+			continue
+		}
+		// The hint file is missing or invalid, so the segment is scanned and
+		// its hint file rebuilt
+		hint := make(map[string]hintEntry)
+		_, err = scanSegment(file, id, func(kind byte, key []byte, loc location) {
+			apply(kind, key, loc)
+			hint[string(key)] = hintEntry{kind: kind, loc: loc}
+		})
+		if errors.Is(err, errTornRecord) {
+			return errors.Join(ErrLogStoreCorrupt, fmt.Errorf("segment %d", id))
+		}
+		if err != nil {
+			return errors.Join(ErrLogStoreOpeningStore, err)
+		}
+		if err := writeHintFile(store.path, id, hint); err != nil {
+			return errors.Join(ErrLogStoreOpeningStore, err)
+		}
+	}
+	return store.openActive(last, apply)
+}
+
+// openActive opens the last segment of the log as the active one, truncating
+// any incomplete record at its end
+func (store *logKVStore) openActive(id uint32, apply func(kind byte, key []byte, loc location)) error {
+	// The hint file of the segment becomes stale with the first write, so it
+	// is removed before writing to the segment again
+	if err := os.Remove(hintPath(store.path, id)); err == nil {
+		if err := syncDir(store.path); err != nil {
+			return errors.Join(ErrLogStoreOpeningStore, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return errors.Join(ErrLogStoreOpeningStore, err)
+	}
+	file, err := os.OpenFile(segmentPath(store.path, id), os.O_RDWR, 0o644)
+	if err != nil {
+		return errors.Join(ErrLogStoreOpeningStore, err)
+	}
+	store.segments[id] = file
+	hint := make(map[string]hintEntry)
+	size, err := scanSegment(file, id, func(kind byte, key []byte, loc location) {
+		apply(kind, key, loc)
+		hint[string(key)] = hintEntry{kind: kind, loc: loc}
+	})
+	if errors.Is(err, errTornRecord) {
+		if err := file.Truncate(size); err != nil {
+			return errors.Join(ErrLogStoreOpeningStore, err)
+		}
+		if err := file.Sync(); err != nil {
+			return errors.Join(ErrLogStoreOpeningStore, err)
+		}
+	} else if err != nil {
+		return errors.Join(ErrLogStoreOpeningStore, err)
+	}
+	store.active, store.activeID, store.activeSize, store.activeHint = file, id, size, hint
+	return nil
+}
+
+// createActive creates a new, empty, active segment
+func (store *logKVStore) createActive(id uint32) error {
+	file, err := os.OpenFile(segmentPath(store.path, id), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	store.segments[id] = file
+	store.active, store.activeID, store.activeSize = file, id, 0
+	store.activeHint = make(map[string]hintEntry)
+	return syncDir(store.path)
+}
+
+// Get returns the value for a given key
+func (store *logKVStore) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrLogStoreEmptyKey
+	}
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	if store.closed {
+		return nil, ErrLogStoreClosed
+	}
+	loc, ok := store.index[string(key)]
+	if !ok {
+		return nil, ErrLogStoreKeyNotFound
+	}
+	rec := make([]byte, loc.size())
+	if _, err := store.segments[loc.segment].ReadAt(rec, loc.offset); err != nil {
+		return nil, errors.Join(ErrLogStoreUnableToGetValue, err)
+	}
+	kind, recKey, value, ok := decodeRecord(rec)
+	if !ok || kind != kindPut || string(recKey) != string(key) {
+		return nil, errors.Join(ErrLogStoreUnableToGetValue, ErrLogStoreCorrupt,
+			fmt.Errorf("segment %d, offset %d", loc.segment, loc.offset))
+	}
+	return value, nil
+}
+
+// Set sets/updates the value for a given key
+func (store *logKVStore) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return ErrLogStoreEmptyKey
+	}
+	if len(key) > maxKeySize || len(value) > maxValueSize {
+		return ErrLogStoreRecordTooLarge
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return ErrLogStoreClosed
+	}
+	loc, err := store.append(kindPut, key, value)
+	if err != nil {
+		return err
+	}
+	store.index[string(key)] = loc
+	return nil
+}
+
+// Delete removes a key and its value from the store
+func (store *logKVStore) Delete(key []byte) error {
+	if len(key) == 0 {
+		return ErrLogStoreEmptyKey
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return ErrLogStoreClosed
+	}
+	if _, ok := store.index[string(key)]; !ok {
+		return nil
+	}
+	if _, err := store.append(kindDelete, key, nil); err != nil {
+		return err
+	}
+	delete(store.index, string(key))
+	return nil
+}
+
+// Len returns the number of key-value pairs in the store
+func (store *logKVStore) Len() (int, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	if store.closed {
+		return 0, ErrLogStoreClosed
+	}
+	return len(store.index), nil
+}
+
+// ClearAll deletes all key-value pairs in the store, removing every segment
+func (store *logKVStore) ClearAll() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return ErrLogStoreClosed
+	}
+	store.closeFiles()
+	store.segments = make(map[uint32]*os.File)
+	store.index = make(map[string]location)
+	store.active, store.dirty = nil, false
+	ids, err := listSegments(store.path)
+	if err != nil {
+		return errors.Join(ErrLogStoreClearingStore, err)
+	}
+	for _, id := range ids {
+		if err := removeSegment(store.path, id); err != nil {
+			return errors.Join(ErrLogStoreClearingStore, err)
+		}
+	}
+	if err := store.createActive(1); err != nil {
+		return errors.Join(ErrLogStoreClearingStore, err)
+	}
+	return nil
+}
+
+// Sync flushes the writes to the active segment to disk
+func (store *logKVStore) Sync() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return ErrLogStoreClosed
+	}
+	return store.sync()
+}
+
+// Stop syncs and closes the store, disabling any access to it
+func (store *logKVStore) Stop() error {
+	if store.stopSync != nil {
+		close(store.stopSync)
+		<-store.syncDone
+		store.stopSync = nil
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.closed {
+		return ErrLogStoreClosed
+	}
+	store.closed = true
+	syncErr := store.sync()
+	if err := store.closeFiles(); err != nil {
+		return errors.Join(ErrLogStoreClosingStore, syncErr, err)
+	}
+	return syncErr
+}
+
+// append writes a record to the active segment, sealing it first if it
+// exceeds the maximum segment size, and returns the location of the record
+func (store *logKVStore) append(kind byte, key, value []byte) (location, error) {
+	if store.active == nil {
+		return location{}, errors.Join(ErrLogStoreUnableToWrite, errors.New("no active segment"))
+	}
+	if store.activeSize > 0 && store.activeSize >= store.cfg.MaxSegmentSize {
+		if err := store.rotate(); err != nil {
+			return location{}, errors.Join(ErrLogStoreUnableToWrite, err)
+		}
+	}
+	rec := encodeRecord(kind, key, value)
+	if _, err := store.active.WriteAt(rec, store.activeSize); err != nil {
+		// Partially written records are overwritten by the next write
+		return location{}, errors.Join(ErrLogStoreUnableToWrite, err)
+	}
+	store.dirty = true
+	if store.cfg.Sync == SyncAlways {
+		if err := store.sync(); err != nil {
+			return location{}, err
+		}
+	}
+	loc := location{
+		segment:  store.activeID,
+		offset:   store.activeSize,
+		keyLen:   uint32(len(key)),
+		valueLen: uint32(len(value)),
+	}
+	store.activeSize += int64(len(rec))
+	store.activeHint[string(key)] = hintEntry{kind: kind, loc: loc}
+	return loc, nil
+}
+
+// rotate seals the active segment, writing its hint file, and starts a new one
+func (store *logKVStore) rotate() error {
+	if err := store.sync(); err != nil {
+		return err
+	}
+	if err := writeHintFile(store.path, store.activeID, store.activeHint); err != nil {
+		return err
+	}
+	return store.createActive(store.activeID + 1)
+}
+
+// sync syncs the active segment if it has unsynced writes, returning the error
+// of any failed background sync
+func (store *logKVStore) sync() error {
+	if err := store.syncErr; err != nil {
+		store.syncErr = nil
+		return err
+	}
+	if !store.dirty || store.active == nil {
+		return nil
+	}
+	if err := store.active.Sync(); err != nil {
+		return errors.Join(ErrLogStoreUnableToSync, err)
+	}
+	store.dirty = false
+	return nil
+}
+
+// runSync periodically syncs the active segment until the store is stopped
+func (store *logKVStore) runSync(interval time.Duration) {
+	defer close(store.syncDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-store.stopSync:
+			return
+		case <-ticker.C:
+			store.mu.Lock()
+			if !store.closed && store.dirty && store.active != nil {
+				if err := store.active.Sync(); err != nil {
+					store.syncErr = errors.Join(ErrLogStoreUnableToSync, err)
+				} else {
+					store.dirty = false
+				}
+			}
+			store.mu.Unlock()
+		}
+	}
+}
+
+// closeFiles closes every open segment file
+func (store *logKVStore) closeFiles() error {
+	var errs []error
+	for _, file := range store.segments {
+		if err := file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// segmentIDs returns the IDs of the open segments in ascending order
+func (store *logKVStore) segmentIDs() []uint32 {
+	ids := make([]uint32, 0, len(store.segments))
+	for id := range store.segments {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
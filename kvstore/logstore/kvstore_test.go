@@ -0,0 +1,263 @@
+package logstore_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/logstore"
+)
+
+func TestLogStore_Conformance(t *testing.T) {
+	kvstoretest.RunMapStoreSuite(t,
+		func(t *testing.T) kvstore.MapStore {
+			store, err := logstore.NewKVStore(t.TempDir())
+			require.NoError(t, err)
+			t.Cleanup(func() { store.Stop() })
+			return store
+		},
+		kvstoretest.WithKeyNotFoundError(logstore.ErrLogStoreKeyNotFound),
+		kvstoretest.WithEmptyKeyError(logstore.ErrLogStoreEmptyKey),
+	)
+}
+
+func TestLogStore_InvalidOptions(t *testing.T) {
+	_, err := logstore.NewKVStore("")
+	require.ErrorIs(t, err, logstore.ErrLogStoreOpeningStore)
+	for _, opt := range []logstore.Option{
+		logstore.WithMaxSegmentSize(-1),
+		logstore.WithSyncInterval(-time.Second),
+		logstore.WithSyncPolicy("sometimes"),
+	} {
+		_, err := logstore.NewKVStore(t.TempDir(), opt)
+		require.ErrorIs(t, err, logstore.ErrLogStoreInvalidOption)
+	}
+}
+
+func TestLogStore_Reopen(t *testing.T) {
+	for _, policy := range []logstore.SyncPolicy{logstore.SyncAlways, logstore.SyncPeriodic, logstore.SyncNever} {
+		t.Run(string(policy), func(t *testing.T) {
+			dir := t.TempDir()
+			opts := []logstore.Option{
+				logstore.WithSyncPolicy(policy),
+				logstore.WithSyncInterval(time.Millisecond),
+				logstore.WithMaxSegmentSize(1 << 10),
+			}
+			store, err := logstore.NewKVStore(dir, opts...)
+			require.NoError(t, err)
+			expected := writeRandom(t, store, 500)
+			require.NoError(t, store.Sync())
+			require.NoError(t, store.Stop())
+			require.ErrorIs(t, store.Set([]byte("foo"), []byte("bar")), logstore.ErrLogStoreClosed)
+
+			store, err = logstore.NewKVStore(dir, opts...)
+			require.NoError(t, err)
+			defer store.Stop()
+			requireContents(t, store, expected)
+		})
+	}
+}
+
+func TestLogStore_Segments(t *testing.T) {
+	dir := t.TempDir()
+	store, err := logstore.NewKVStore(dir, logstore.WithMaxSegmentSize(1<<10))
+	require.NoError(t, err)
+	expected := writeRandom(t, store, 500)
+	require.NoError(t, store.Stop())
+
+	segments, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	require.NoError(t, err)
+	hints, err := filepath.Glob(filepath.Join(dir, "*.hint"))
+	require.NoError(t, err)
+	require.Greater(t, len(segments), 1)
+	// Every segment but the active one is sealed with a hint file
+	require.Len(t, hints, len(segments)-1)
+
+	// Invalid and missing hint files fall back to scanning the segments
+	require.NoError(t, os.WriteFile(hints[0], []byte("not a hint file"), 0o644))
+	require.NoError(t, os.Remove(hints[1]))
+	store, err = logstore.NewKVStore(dir, logstore.WithMaxSegmentSize(1<<10))
+	require.NoError(t, err)
+	requireContents(t, store, expected)
+	require.NoError(t, store.Stop())
+	// and are rebuilt
+	_, err = os.Stat(hints[1])
+	require.NoError(t, err)
+
+	// A mismatch between a segment and its hint file is detected when reading
+	data, err := os.ReadFile(segments[0])
+	require.NoError(t, err)
+	data[len(data)-1] ^= 1
+	require.NoError(t, os.WriteFile(segments[0], data, 0o644))
+	store, err = logstore.NewKVStore(dir, logstore.WithMaxSegmentSize(1<<10))
+	require.NoError(t, err)
+	defer store.Stop()
+	failed := 0
+	for key := range expected {
+		if _, err := store.Get([]byte(key)); err != nil {
+			require.ErrorIs(t, err, logstore.ErrLogStoreCorrupt)
+			failed++
+		}
+	}
+	require.LessOrEqual(t, failed, 1)
+}
+
+func TestLogStore_TornTail(t *testing.T) {
+	dir := t.TempDir()
+	store, err := logstore.NewKVStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+	require.NoError(t, store.Set([]byte("baz"), []byte("qux")))
+	require.NoError(t, store.Stop())
+
+	segment := filepath.Join(dir, "0000000001.log")
+	data, err := os.ReadFile(segment)
+	require.NoError(t, err)
+	// Cut the last record in the middle, as a crash during a write would
+	require.NoError(t, os.WriteFile(segment, data[:len(data)-2], 0o644))
+
+	store, err = logstore.NewKVStore(dir)
+	require.NoError(t, err)
+	requireContents(t, store, map[string]string{"foo": "bar"})
+	// The torn record is truncated, and writes resume after the valid prefix
+	require.NoError(t, store.Set([]byte("quux"), []byte("corge")))
+	require.NoError(t, store.Stop())
+
+	store, err = logstore.NewKVStore(dir)
+	require.NoError(t, err)
+	defer store.Stop()
+	requireContents(t, store, map[string]string{"foo": "bar", "quux": "corge"})
+}
+
+func TestLogStore_CorruptSegment(t *testing.T) {
+	dir := t.TempDir()
+	store, err := logstore.NewKVStore(dir, logstore.WithMaxSegmentSize(1<<10))
+	require.NoError(t, err)
+	writeRandom(t, store, 500)
+	require.NoError(t, store.Stop())
+
+	// Records of sealed segments are never torn, so an invalid record is
+	// reported rather than truncated
+	segment := filepath.Join(dir, "0000000001.log")
+	data, err := os.ReadFile(segment)
+	require.NoError(t, err)
+	data[len(data)/2] ^= 1
+	require.NoError(t, os.WriteFile(segment, data, 0o644))
+	require.NoError(t, os.Remove(filepath.Join(dir, "0000000001.hint")))
+	_, err = logstore.NewKVStore(dir, logstore.WithMaxSegmentSize(1<<10))
+	require.ErrorIs(t, err, logstore.ErrLogStoreCorrupt)
+}
+
+func TestLogStore_ClearAll(t *testing.T) {
+	dir := t.TempDir()
+	store, err := logstore.NewKVStore(dir, logstore.WithMaxSegmentSize(1<<10))
+	require.NoError(t, err)
+	writeRandom(t, store, 500)
+	require.NoError(t, store.ClearAll())
+	require.NoError(t, store.Set([]byte("foo"), []byte("bar")))
+	require.NoError(t, store.Stop())
+
+	segments, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "0000000001.log")}, segments)
+	store, err = logstore.NewKVStore(dir)
+	require.NoError(t, err)
+	defer store.Stop()
+	requireContents(t, store, map[string]string{"foo": "bar"})
+}
+
+func TestLogStore_Iterator(t *testing.T) {
+	store, err := logstore.NewKVStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Stop()
+	for _, key := range []string{"a", "b1", "b2", "b3", "c"} {
+		require.NoError(t, store.Set([]byte(key), []byte("v"+key)))
+	}
+
+	tests := []struct {
+		desc     string
+		opts     kvstore.IterOptions
+		expected []string
+	}{
+		{"all", kvstore.IterOptions{}, []string{"a", "b1", "b2", "b3", "c"}},
+		{"prefix", kvstore.IterOptions{Prefix: []byte("b")}, []string{"b1", "b2", "b3"}},
+		{"descending", kvstore.IterOptions{Prefix: []byte("b"), Descending: true}, []string{"b3", "b2", "b1"}},
+		{"bounds", kvstore.IterOptions{LowerBound: []byte("b2"), UpperBound: []byte("c")}, []string{"b2", "b3"}},
+		{"start", kvstore.IterOptions{Start: []byte("b2"), Descending: true}, []string{"b2", "b1", "a"}},
+		{"limit", kvstore.IterOptions{Limit: 2}, []string{"a", "b1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			it, err := store.RangeIterator(tt.opts)
+			require.NoError(t, err)
+			defer it.Close()
+			keys := make([]string, 0)
+			for it.Next() {
+				keys = append(keys, string(it.Key()))
+				require.Equal(t, "v"+string(it.Key()), string(it.Value()))
+			}
+			require.NoError(t, it.Err())
+			require.Equal(t, tt.expected, keys)
+		})
+	}
+
+	// Keys deleted while iterating are skipped
+	it, err := store.RangeIterator(kvstore.IterOptions{})
+	require.NoError(t, err)
+	defer it.Close()
+	require.True(t, it.Next())
+	require.NoError(t, store.Delete([]byte("b1")))
+	require.True(t, it.Next())
+	require.Equal(t, []byte("b2"), it.Key())
+}
+
+// writeRandom writes, overwrites and deletes keys of the store, returning its
+// expected contents
+func writeRandom(t *testing.T, store kvstore.MapStore, n int) map[string]string {
+	t.Helper()
+	expected := make(map[string]string)
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("key-%d", i%(n/3))
+		switch i % 5 {
+		case 4:
+			require.NoError(t, store.Delete([]byte(key)))
+			delete(expected, key)
+		default:
+			value := fmt.Sprintf("value-%d", i)
+			require.NoError(t, store.Set([]byte(key), []byte(value)))
+			expected[key] = value
+		}
+	}
+	return expected
+}
+
+// requireContents checks that the store holds exactly the expected key-value
+// pairs
+func requireContents(t *testing.T, store logstore.LogKVStore, expected map[string]string) {
+	t.Helper()
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, len(expected), length)
+	for key, value := range expected {
+		got, err := store.Get([]byte(key))
+		require.NoError(t, err)
+		require.Equal(t, value, string(got))
+	}
+	it, err := store.RangeIterator(kvstore.IterOptions{})
+	require.NoError(t, err)
+	defer it.Close()
+	var prev []byte
+	for it.Next() {
+		require.Contains(t, expected, string(it.Key()))
+		require.Less(t, bytes.Compare(prev, it.Key()), 0)
+		prev = append(prev[:0], it.Key()...)
+	}
+	require.NoError(t, it.Err())
+}
@@ -0,0 +1,97 @@
+package logstore
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SyncPolicy controls when the writes to the log are synced to disk
+type SyncPolicy string
+
+const (
+	// SyncAlways syncs the log after every write, so that a write which
+	// returned is never lost
+	SyncAlways SyncPolicy = "always"
+	// SyncPeriodic syncs the log in the background every SyncInterval, so
+	// that at most the writes of the last interval can be lost on a crash
+	SyncPeriodic SyncPolicy = "periodic"
+	// SyncNever leaves syncing the log to the operating system, only syncing
+	// it when segments are sealed and when the store is stopped
+	SyncNever SyncPolicy = "never"
+)
+
+const (
+	defaultMaxSegmentSize = 64 << 20
+	defaultSyncInterval   = time.Second
+)
+
+// Config holds the options of the store. Zero values keep the defaults of the
+// store, so a Config can be partially populated, e.g. when loaded from a
+// configuration file.
+type Config struct {
+	// MaxSegmentSize is the size (in bytes) above which the active segment is
+	// sealed and a new one is started, defaults to 64MiB
+	MaxSegmentSize int64 `json:"max_segment_size,omitempty" yaml:"max_segment_size,omitempty"`
+	// Sync is the policy used to sync writes to disk, defaults to SyncAlways
+	Sync SyncPolicy `json:"sync,omitempty" yaml:"sync,omitempty"`
+	// SyncInterval is the interval between syncs of the SyncPeriodic policy,
+	// defaults to one second
+	SyncInterval time.Duration `json:"sync_interval,omitempty" yaml:"sync_interval,omitempty"`
+}
+
+// Option is a function that configures the store created by NewKVStore
+type Option func(*Config)
+
+// WithConfig returns an Option that replaces the configuration of the store
+// with the one provided. Options provided after it are applied on top.
+func WithConfig(cfg Config) Option {
+	return func(c *Config) { *c = cfg }
+}
+
+// WithMaxSegmentSize returns an Option that sets the size (in bytes) above
+// which the active segment is sealed
+func WithMaxSegmentSize(size int64) Option {
+	return func(c *Config) { c.MaxSegmentSize = size }
+}
+
+// WithSyncPolicy returns an Option that sets when writes are synced to disk
+func WithSyncPolicy(policy SyncPolicy) Option {
+	return func(c *Config) { c.Sync = policy }
+}
+
+// WithSyncInterval returns an Option that sets the interval between syncs of
+// the SyncPeriodic policy
+func WithSyncInterval(interval time.Duration) Option {
+	return func(c *Config) { c.SyncInterval = interval }
+}
+
+// newConfig applies the options provided on top of the defaults and
+// validates the resulting configuration
+func newConfig(opts ...Option) (Config, error) {
+	cfg := Config{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.MaxSegmentSize == 0 {
+		cfg.MaxSegmentSize = defaultMaxSegmentSize
+	}
+	if cfg.Sync == "" {
+		cfg.Sync = SyncAlways
+	}
+	if cfg.SyncInterval == 0 {
+		cfg.SyncInterval = defaultSyncInterval
+	}
+	if cfg.MaxSegmentSize < 0 {
+		return cfg, errors.Join(ErrLogStoreInvalidOption, fmt.Errorf("negative segment size %d", cfg.MaxSegmentSize))
+	}
+	if cfg.SyncInterval < 0 {
+		return cfg, errors.Join(ErrLogStoreInvalidOption, fmt.Errorf("negative sync interval %s", cfg.SyncInterval))
+	}
+	switch cfg.Sync {
+	case SyncAlways, SyncPeriodic, SyncNever:
+	default:
+		return cfg, errors.Join(ErrLogStoreInvalidOption, fmt.Errorf("unknown sync policy %q", cfg.Sync))
+	}
+	return cfg, nil
+}
@@ -0,0 +1,121 @@
+package logstore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Records are appended to the segments as:
+//
+//	[crc (4)][kind (1)][key length (4)][value length (4)][key][value]
+//
+// where the CRC-32C covers every byte following it and all the integers are
+// big endian. Deletions are recorded as tombstones without a value.
+const (
+	recordHeaderSize = 4 + 1 + 4 + 4
+
+	// The maximum sizes of the keys and values of a record
+	maxKeySize   = 1 << 20
+	maxValueSize = 1 << 30
+
+	kindPut    byte = 1
+	kindDelete byte = 2
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errTornRecord is returned when a segment ends with an incomplete or invalid
+// record, as left behind by a crash in the middle of a write
+var errTornRecord = errors.New("torn record")
+
+// location is the position of the latest record of a key in the log
+type location struct {
+	segment  uint32
+	offset   int64
+	keyLen   uint32
+	valueLen uint32
+}
+
+// size returns the size of the record at the location
+func (loc location) size() int64 {
+	return recordHeaderSize + int64(loc.keyLen) + int64(loc.valueLen)
+}
+
+// encodeRecord returns the encoding of a record
+func encodeRecord(kind byte, key, value []byte) []byte {
+	rec := make([]byte, recordHeaderSize+len(key)+len(value))
+	rec[4] = kind
+	binary.BigEndian.PutUint32(rec[5:], uint32(len(key)))
+	binary.BigEndian.PutUint32(rec[9:], uint32(len(value)))
+	copy(rec[recordHeaderSize:], key)
+	copy(rec[recordHeaderSize+len(key):], value)
+	binary.BigEndian.PutUint32(rec, crc32.Checksum(rec[4:], crcTable))
+	return rec
+}
+
+// decodeRecord validates an encoded record, returning its kind, key and value
+func decodeRecord(rec []byte) (kind byte, key, value []byte, ok bool) {
+	if len(rec) < recordHeaderSize {
+		return 0, nil, nil, false
+	}
+	kind = rec[4]
+	keyLen := int64(binary.BigEndian.Uint32(rec[5:]))
+	valueLen := int64(binary.BigEndian.Uint32(rec[9:]))
+	if (kind != kindPut && kind != kindDelete) ||
+		int64(len(rec)) != recordHeaderSize+keyLen+valueLen ||
+		binary.BigEndian.Uint32(rec) != crc32.Checksum(rec[4:], crcTable) {
+		return 0, nil, nil, false
+	}
+	key = rec[recordHeaderSize : recordHeaderSize+keyLen]
+	value = rec[recordHeaderSize+keyLen:]
+	return kind, key, value, true
+}
+
+// scanSegment reads the records of a segment in order, calling fn with the
+// kind, key and location of each of them. It returns the size of the valid
+// prefix of the segment, along with errTornRecord if it is followed by an
+// incomplete or invalid record.
+func scanSegment(file *os.File, id uint32, fn func(kind byte, key []byte, loc location)) (int64, error) {
+	r := bufio.NewReaderSize(io.NewSectionReader(file, 0, 1<<62), 1<<16)
+	header := make([]byte, recordHeaderSize)
+	var offset int64
+	var rec []byte
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return offset, nil
+			}
+			if err == io.ErrUnexpectedEOF {
+				return offset, errTornRecord
+			}
+			return offset, err
+		}
+		keyLen := binary.BigEndian.Uint32(header[5:])
+		valueLen := binary.BigEndian.Uint32(header[9:])
+		if keyLen > maxKeySize || valueLen > maxValueSize {
+			return offset, errTornRecord
+		}
+		size := recordHeaderSize + int(keyLen) + int(valueLen)
+		if cap(rec) < size {
+			rec = make([]byte, size)
+		}
+		rec = rec[:size]
+		copy(rec, header)
+		if _, err := io.ReadFull(r, rec[recordHeaderSize:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return offset, errTornRecord
+			}
+			return offset, err
+		}
+		kind, key, _, ok := decodeRecord(rec)
+		if !ok {
+			return offset, errTornRecord
+		}
+		fn(kind, key, location{segment: id, offset: offset, keyLen: keyLen, valueLen: valueLen})
+		offset += int64(size)
+	}
+}
@@ -0,0 +1,73 @@
+package logstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	segmentExt = ".log"
+	hintExt    = ".hint"
+)
+
+// segmentPath returns the path of the segment with the given ID
+func segmentPath(dir string, id uint32) string {
+	return filepath.Join(dir, fmt.Sprintf("%010d%s", id, segmentExt))
+}
+
+// hintPath returns the path of the hint file of the segment with the given ID
+func hintPath(dir string, id uint32) string {
+	return filepath.Join(dir, fmt.Sprintf("%010d%s", id, hintExt))
+}
+
+// listSegments returns the IDs of the segments in the directory, in the order
+// they were written
+func listSegments(dir string) ([]uint32, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint32, 0, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), segmentExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		id, err := strconv.ParseUint(name, 10, 32)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint32(id))
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// removeSegment removes a segment and its hint file, along with any partially
+// written hint file
+func removeSegment(dir string, id uint32) error {
+	for _, path := range []string{hintPath(dir, id) + ".tmp", hintPath(dir, id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Remove(segmentPath(dir, id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// syncDir syncs a directory so that the files created in or removed from it
+// are persisted
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,14 @@
+package memdb
+
+import (
+	"errors"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+var (
+	// ErrMemDBKeyNotFound is returned when a key is not present in the store
+	ErrMemDBKeyNotFound = kvstore.ErrKeyNotFound
+	// ErrMemDBEmptyKey is returned when the given key is empty
+	ErrMemDBEmptyKey = errors.New("key is empty")
+)
@@ -0,0 +1,5 @@
+// Package memdb provides a thread-safe, ordered in-memory key-value store that
+// can be used as the nodestore for the SM(S)T. Unlike the simplemap it
+// supports prefix and range iteration like the on-disk stores, and O(1)
+// point-in-time snapshots backed by a persistent (copy-on-write) treap.
+package memdb
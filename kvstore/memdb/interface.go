@@ -0,0 +1,42 @@
+package memdb
+
+import (
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the MemDBKVStore can be used as an SMT node store
+var (
+	_ kvstore.MapStore         = (MemDBKVStore)(nil)
+	_ kvstore.IterableMapStore = (MemDBKVStore)(nil)
+	_ kvstore.PrefixDeleter    = (MemDBKVStore)(nil)
+)
+
+// MemDBKVStore is an interface that defines an ordered in-memory key-value
+// store that can be used standalone or as the node store for an SMT. It is
+// safe for concurrent use and offers the same accessors as the on-disk stores.
+type MemDBKVStore interface {
+	kvstore.MapStore
+
+	// --- Data methods ---
+
+	// Snapshot returns a point-in-time copy of the store in O(1). The snapshot
+	// and the store share their contents until either of them is modified,
+	// after which they evolve independently.
+	Snapshot() MemDBKVStore
+
+	// --- Accessors ---
+
+	// GetAll returns all keys and values with the given prefix in the specified order
+	GetAll(prefixKey []byte, descending bool) (keys, values [][]byte, err error)
+	// Exists returns true if the key exists
+	Exists(key []byte) (bool, error)
+	// Iterator returns an iterator over the keys with the given prefix, starting
+	// at the key provided (if any) in the specified order
+	Iterator(prefix, start []byte, descending bool) (kvstore.Iterator, error)
+	// RangeIterator returns an iterator over the keys satisfying the options
+	RangeIterator(opts kvstore.IterOptions) (kvstore.Iterator, error)
+	// DeleteRange removes all the keys in the range [start, end)
+	DeleteRange(start, end []byte) error
+	// DeletePrefix removes all the keys starting with the prefix
+	DeletePrefix(prefix []byte) error
+}
@@ -0,0 +1,109 @@
+package memdb
+
+import (
+	"bytes"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+var _ kvstore.Iterator = &memDBIterator{}
+
+// memDBIterator traverses the treap captured when it was created in order,
+// using an explicit stack of the nodes left to visit
+type memDBIterator struct {
+	// The range of keys [lower, upper) visited, a nil bound is unbounded
+	lower, upper []byte
+	descending   bool
+	limit        int
+	count        int
+	stack        []*node
+	current      *node
+}
+
+// Iterator returns an iterator over the keys with the given prefix, starting
+// at the key provided (if any) in the specified order
+func (store *memDBKVStore) Iterator(prefix, start []byte, descending bool) (kvstore.Iterator, error) {
+	return store.RangeIterator(kvstore.IterOptions{
+		Prefix:     prefix,
+		Start:      start,
+		Descending: descending,
+	})
+}
+
+// RangeIterator returns an iterator over the keys satisfying the options. The
+// iterator reads from a snapshot of the store taken when it is created, so it
+// is not affected by concurrent writes.
+func (store *memDBKVStore) RangeIterator(opts kvstore.IterOptions) (kvstore.Iterator, error) {
+	lower, upper := opts.Bounds()
+	it := &memDBIterator{
+		lower:      lower,
+		upper:      upper,
+		descending: opts.Descending,
+		limit:      opts.Limit,
+	}
+	// Push the path to the first key in the range
+	for n := store.snapshotRoot(); n != nil; {
+		if it.descending {
+			if upper == nil || bytes.Compare(n.key, upper) < 0 {
+				it.stack = append(it.stack, n)
+				n = n.right
+			} else {
+				n = n.left
+			}
+		} else {
+			if lower == nil || bytes.Compare(n.key, lower) >= 0 {
+				it.stack = append(it.stack, n)
+				n = n.left
+			} else {
+				n = n.right
+			}
+		}
+	}
+	return it, nil
+}
+
+// Next satisfies the kvstore.Iterator#Next interface
+func (it *memDBIterator) Next() bool {
+	it.current = nil
+	if len(it.stack) == 0 || (it.limit > 0 && it.count >= it.limit) {
+		it.stack = nil
+		return false
+	}
+	n := it.stack[len(it.stack)-1]
+	it.stack = it.stack[:len(it.stack)-1]
+	if it.descending {
+		if it.lower != nil && bytes.Compare(n.key, it.lower) < 0 {
+			it.stack = nil
+			return false
+		}
+		for child := n.left; child != nil; child = child.right {
+			it.stack = append(it.stack, child)
+		}
+	} else {
+		if it.upper != nil && bytes.Compare(n.key, it.upper) >= 0 {
+			it.stack = nil
+			return false
+		}
+		for child := n.right; child != nil; child = child.left {
+			it.stack = append(it.stack, child)
+		}
+	}
+	it.current = n
+	it.count++
+	return true
+}
+
+// Key satisfies the kvstore.Iterator#Key interface
+func (it *memDBIterator) Key() []byte { return it.current.key }
+
+// Value satisfies the kvstore.Iterator#Value interface
+func (it *memDBIterator) Value() []byte { return it.current.value }
+
+// Err satisfies the kvstore.Iterator#Err interface
+func (it *memDBIterator) Err() error { return nil }
+
+// Close satisfies the kvstore.Iterator#Close interface
+func (it *memDBIterator) Close() error {
+	it.stack, it.current = nil, nil
+	return nil
+}
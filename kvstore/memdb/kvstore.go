@@ -0,0 +1,139 @@
+package memdb
+
+import (
+	"sync"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+var _ MemDBKVStore = &memDBKVStore{}
+
+// memDBKVStore is an in-memory store backed by a persistent treap. The lock
+// only guards the root pointer: published nodes are immutable, so readers and
+// iterators can traverse the tree they captured without holding the lock.
+type memDBKVStore struct {
+	mu   sync.RWMutex
+	root *node
+}
+
+// NewKVStore creates a new, empty MemDBKVStore
+func NewKVStore() MemDBKVStore {
+	return &memDBKVStore{}
+}
+
+// Get returns a copy of the value for a given key
+func (store *memDBKVStore) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrMemDBEmptyKey
+	}
+	n := get(store.snapshotRoot(), key)
+	if n == nil {
+		return nil, ErrMemDBKeyNotFound
+	}
+	return copyBytes(n.value), nil
+}
+
+// Set sets/updates the value for a given key, storing a copy of the value
+func (store *memDBKVStore) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return ErrMemDBEmptyKey
+	}
+	key, value = copyBytes(key), copyBytes(value)
+	if value == nil {
+		value = []byte{}
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.root = insert(store.root, key, value)
+	return nil
+}
+
+// Delete removes a key, deleting a missing key is not an error
+func (store *memDBKVStore) Delete(key []byte) error {
+	if len(key) == 0 {
+		return ErrMemDBEmptyKey
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.root, _ = remove(store.root, key)
+	return nil
+}
+
+// Len returns the number of key-value pairs in the store
+func (store *memDBKVStore) Len() (int, error) {
+	return sizeOf(store.snapshotRoot()), nil
+}
+
+// ClearAll deletes all key-value pairs in the store
+func (store *memDBKVStore) ClearAll() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.root = nil
+	return nil
+}
+
+// Snapshot returns a point-in-time copy of the store in O(1)
+func (store *memDBKVStore) Snapshot() MemDBKVStore {
+	return &memDBKVStore{root: store.snapshotRoot()}
+}
+
+// GetAll returns all keys and values with the given prefix in the specified order
+// if the prefix []byte{} is given then all key-value pairs are returned
+func (store *memDBKVStore) GetAll(prefix []byte, descending bool) (keys, values [][]byte, err error) {
+	it, err := store.Iterator(prefix, nil, descending)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer it.Close()
+	keys = make([][]byte, 0)
+	values = make([][]byte, 0)
+	for it.Next() {
+		keys = append(keys, copyBytes(it.Key()))
+		values = append(values, copyBytes(it.Value()))
+	}
+	return keys, values, it.Err()
+}
+
+// Exists checks whether the key exists in the store with a non-empty value,
+// matching the behaviour of the on-disk stores
+func (store *memDBKVStore) Exists(key []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, ErrMemDBEmptyKey
+	}
+	n := get(store.snapshotRoot(), key)
+	return n != nil && len(n.value) > 0, nil
+}
+
+// DeleteRange removes all the keys in the range [start, end). A nil end
+// leaves the range unbounded.
+func (store *memDBKVStore) DeleteRange(start, end []byte) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	less, rest := split(store.root, start)
+	var greater *node
+	if end != nil {
+		_, greater = split(rest, end)
+	}
+	store.root = merge(less, greater)
+	return nil
+}
+
+// DeletePrefix removes all the keys starting with the prefix
+func (store *memDBKVStore) DeletePrefix(prefix []byte) error {
+	return store.DeleteRange(prefix, kvstore.PrefixEnd(prefix))
+}
+
+// snapshotRoot returns the current root of the treap
+func (store *memDBKVStore) snapshotRoot() *node {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	return store.root
+}
+
+// copyBytes returns a copy of the slice provided
+func copyBytes(bz []byte) []byte {
+	if bz == nil {
+		return nil
+	}
+	return append(make([]byte, 0, len(bz)), bz...)
+}
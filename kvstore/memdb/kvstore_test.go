@@ -0,0 +1,247 @@
+package memdb_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/memdb"
+)
+
+func TestMemDB_KVStore_BasicOperations(t *testing.T) {
+	store := memdb.NewKVStore()
+
+	require.ErrorIs(t, store.Set(nil, []byte("value")), memdb.ErrMemDBEmptyKey)
+	_, err := store.Get([]byte{})
+	require.ErrorIs(t, err, memdb.ErrMemDBEmptyKey)
+	require.ErrorIs(t, store.Delete(nil), memdb.ErrMemDBEmptyKey)
+
+	_, err = store.Get([]byte("foo"))
+	require.ErrorIs(t, err, memdb.ErrMemDBKeyNotFound)
+	require.NoError(t, store.Delete([]byte("foo")))
+
+	value := []byte("bar")
+	require.NoError(t, store.Set([]byte("foo"), value))
+	// Neither the stored nor the returned values alias the caller's slices
+	value[0] = 'x'
+	got, err := store.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), got)
+	got[0] = 'y'
+	got, err = store.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), got)
+
+	exists, err := store.Exists([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.NoError(t, store.Set([]byte("empty"), nil))
+	exists, err = store.Exists([]byte("empty"))
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, 2, length)
+	require.NoError(t, store.ClearAll())
+	length, err = store.Len()
+	require.NoError(t, err)
+	require.Zero(t, length)
+}
+
+func TestMemDB_KVStore_MatchesMap(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	store := memdb.NewKVStore()
+	reference := make(map[string][]byte)
+	for i := 0; i < 20_000; i++ {
+		key := []byte(fmt.Sprintf("key-%03d", r.Intn(500)))
+		switch r.Intn(10) {
+		case 0, 1, 2:
+			require.NoError(t, store.Delete(key))
+			delete(reference, string(key))
+		case 3:
+			start := []byte(fmt.Sprintf("key-%03d", r.Intn(500)))
+			end := []byte(fmt.Sprintf("key-%03d", r.Intn(500)))
+			require.NoError(t, store.DeleteRange(start, end))
+			for k := range reference {
+				if bytes.Compare([]byte(k), start) >= 0 && bytes.Compare([]byte(k), end) < 0 {
+					delete(reference, k)
+				}
+			}
+		default:
+			value := []byte(fmt.Sprintf("value-%d", i))
+			require.NoError(t, store.Set(key, value))
+			reference[string(key)] = value
+		}
+	}
+
+	expectedKeys := make([]string, 0, len(reference))
+	for k := range reference {
+		expectedKeys = append(expectedKeys, k)
+	}
+	sort.Strings(expectedKeys)
+	keys, values, err := store.GetAll(nil, false)
+	require.NoError(t, err)
+	require.Len(t, keys, len(expectedKeys))
+	for i, key := range keys {
+		require.Equal(t, expectedKeys[i], string(key))
+		require.Equal(t, reference[string(key)], values[i])
+	}
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, len(reference), length)
+}
+
+func TestMemDB_KVStore_Iterator(t *testing.T) {
+	store := memdb.NewKVStore()
+	for _, key := range []string{"a", "b", "ba", "bb", "c"} {
+		require.NoError(t, store.Set([]byte(key), []byte("value-"+key)))
+	}
+
+	tests := []struct {
+		desc string
+		opts kvstore.IterOptions
+		want []string
+	}{
+		{
+			desc: "all keys descending",
+			opts: kvstore.IterOptions{Descending: true},
+			want: []string{"c", "bb", "ba", "b", "a"},
+		},
+		{
+			desc: "prefix ascending",
+			opts: kvstore.IterOptions{Prefix: []byte("b")},
+			want: []string{"b", "ba", "bb"},
+		},
+		{
+			desc: "start descending includes the start key",
+			opts: kvstore.IterOptions{Start: []byte("ba"), Descending: true},
+			want: []string{"ba", "b", "a"},
+		},
+		{
+			desc: "bounds and limit",
+			opts: kvstore.IterOptions{LowerBound: []byte("b0"), UpperBound: []byte("d"), Limit: 2},
+			want: []string{"ba", "bb"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			it, err := store.RangeIterator(tt.opts)
+			require.NoError(t, err)
+			var got []string
+			for it.Next() {
+				got = append(got, string(it.Key()))
+				require.Equal(t, "value-"+string(it.Key()), string(it.Value()))
+			}
+			require.NoError(t, it.Err())
+			require.NoError(t, it.Close())
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	require.NoError(t, store.DeletePrefix([]byte("b")))
+	keys, _, err := store.GetAll(nil, true)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("c"), []byte("a")}, keys)
+}
+
+func TestMemDB_KVStore_Snapshot(t *testing.T) {
+	store := memdb.NewKVStore()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%02d", i)), []byte("original")))
+	}
+	snapshot := store.Snapshot()
+
+	// Writes to the store are not visible in the snapshot and vice versa
+	require.NoError(t, store.Set([]byte("key-00"), []byte("modified")))
+	require.NoError(t, store.DeletePrefix([]byte("key-1")))
+	require.NoError(t, snapshot.Set([]byte("snapshot"), []byte("only")))
+
+	value, err := snapshot.Get([]byte("key-00"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("original"), value)
+	length, err := snapshot.Len()
+	require.NoError(t, err)
+	require.Equal(t, 101, length)
+
+	length, err = store.Len()
+	require.NoError(t, err)
+	require.Equal(t, 90, length)
+	_, err = store.Get([]byte("snapshot"))
+	require.ErrorIs(t, err, memdb.ErrMemDBKeyNotFound)
+
+	// An iterator is not affected by writes made after its creation
+	it, err := store.Iterator(nil, nil, false)
+	require.NoError(t, err)
+	require.NoError(t, store.ClearAll())
+	count := 0
+	for it.Next() {
+		count++
+	}
+	require.Equal(t, 90, count)
+}
+
+func TestMemDB_KVStore_Concurrent(t *testing.T) {
+	store := memdb.NewKVStore()
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := []byte(fmt.Sprintf("key-%d-%d", w, i))
+				require.NoError(t, store.Set(key, key))
+				if i%10 == 0 {
+					_, _, err := store.GetAll([]byte(fmt.Sprintf("key-%d-", w)), false)
+					require.NoError(t, err)
+					_ = store.Snapshot()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, 8*500, length)
+}
+
+func TestMemDB_KVStore_SMT(t *testing.T) {
+	store := memdb.NewKVStore()
+	trie := smt.NewSparseMerkleTrie(store, sha256.New())
+	for i := 0; i < 100; i++ {
+		require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+	}
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+
+	// A snapshot preserves the committed trie while the store keeps changing
+	snapshot := store.Snapshot()
+	for i := 0; i < 50; i++ {
+		require.NoError(t, trie.Delete([]byte(fmt.Sprintf("key-%d", i))))
+	}
+	require.NoError(t, trie.Commit())
+
+	imported := smt.ImportSparseMerkleTrie(snapshot, sha256.New(), root)
+	proof, err := imported.Prove([]byte("key-7"))
+	require.NoError(t, err)
+	valid, err := smt.VerifyProof(proof, root, []byte("key-7"), []byte("value"), trie.Spec())
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func TestMemDB_KVStore_Conformance(t *testing.T) {
+	kvstoretest.RunMapStoreSuite(t,
+		func(t *testing.T) kvstore.MapStore { return memdb.NewKVStore() },
+		kvstoretest.WithKeyNotFoundError(memdb.ErrMemDBKeyNotFound),
+		kvstoretest.WithEmptyKeyError(memdb.ErrMemDBEmptyKey),
+	)
+}
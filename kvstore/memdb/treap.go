@@ -0,0 +1,154 @@
+package memdb
+
+import (
+	"bytes"
+	"math/rand/v2"
+)
+
+// node is a node of a persistent treap: a binary search tree ordered by key
+// and a max-heap ordered by priority. Nodes reachable from a published root
+// are never modified. Every update copies the nodes along the path it
+// touches, so previous roots (i.e. snapshots) remain valid.
+type node struct {
+	key, value  []byte
+	priority    uint64
+	size        int
+	left, right *node
+}
+
+// newNode returns a new leaf node with a random priority
+func newNode(key, value []byte) *node {
+	return &node{key: key, value: value, priority: rand.Uint64(), size: 1}
+}
+
+// sizeOf returns the number of nodes in the subtree
+func sizeOf(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// clone returns a copy of the node which can be modified freely
+func (n *node) clone() *node {
+	c := *n
+	return &c
+}
+
+// update recomputes the size of a node after its children changed
+func (n *node) update() *node {
+	n.size = 1 + sizeOf(n.left) + sizeOf(n.right)
+	return n
+}
+
+// get returns the node with the given key, if any
+func get(n *node, key []byte) *node {
+	for n != nil {
+		switch cmp := bytes.Compare(key, n.key); {
+		case cmp < 0:
+			n = n.left
+		case cmp > 0:
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// insert returns the root of a treap where the key is set to the value
+func insert(n *node, key, value []byte) *node {
+	if n == nil {
+		return newNode(key, value)
+	}
+	c := n.clone()
+	switch cmp := bytes.Compare(key, n.key); {
+	case cmp < 0:
+		c.left = insert(n.left, key, value)
+		if c.left.priority > c.priority {
+			// c.left is a fresh copy, so it can be rotated in place
+			return rotateRight(c)
+		}
+	case cmp > 0:
+		c.right = insert(n.right, key, value)
+		if c.right.priority > c.priority {
+			return rotateLeft(c)
+		}
+	default:
+		c.value = value
+	}
+	return c.update()
+}
+
+// remove returns the root of a treap without the key, and whether the key
+// was present
+func remove(n *node, key []byte) (*node, bool) {
+	if n == nil {
+		return nil, false
+	}
+	var removed bool
+	c := n.clone()
+	switch cmp := bytes.Compare(key, n.key); {
+	case cmp < 0:
+		if c.left, removed = remove(n.left, key); !removed {
+			return n, false
+		}
+	case cmp > 0:
+		if c.right, removed = remove(n.right, key); !removed {
+			return n, false
+		}
+	default:
+		return merge(n.left, n.right), true
+	}
+	return c.update(), true
+}
+
+// split returns the roots of two treaps holding the keys less than the key
+// and the keys greater than or equal to it
+func split(n *node, key []byte) (less, greater *node) {
+	if n == nil {
+		return nil, nil
+	}
+	c := n.clone()
+	if bytes.Compare(n.key, key) < 0 {
+		c.right, greater = split(n.right, key)
+		return c.update(), greater
+	}
+	less, c.left = split(n.left, key)
+	return less, c.update()
+}
+
+// merge returns the root of a treap holding the keys of both treaps, where
+// every key of the first treap is less than every key of the second
+func merge(less, greater *node) *node {
+	if less == nil {
+		return greater
+	}
+	if greater == nil {
+		return less
+	}
+	if less.priority > greater.priority {
+		c := less.clone()
+		c.right = merge(less.right, greater)
+		return c.update()
+	}
+	c := greater.clone()
+	c.left = merge(less, greater.left)
+	return c.update()
+}
+
+// rotateRight lifts the left child of a freshly copied node
+func rotateRight(n *node) *node {
+	l := n.left
+	n.left = l.right
+	l.right = n.update()
+	return l.update()
+}
+
+// rotateLeft lifts the right child of a freshly copied node
+func rotateLeft(n *node) *node {
+	r := n.right
+	n.right = r.left
+	r.left = n.update()
+	return r.update()
+}
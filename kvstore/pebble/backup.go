@@ -0,0 +1,438 @@
+package pebble
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// The backup stream format is documented in docs/pebble-store.md. It is made
+// of a header, a sequence of records and a trailer:
+//
+//	header:  magic (8 bytes) | version (1 byte) | kind (1 byte)
+//	record:  op (1 byte) | uvarint key length | key [| uvarint value length | value]
+//	trailer: opEnd (1 byte) | CRC-32C of all the preceding bytes (4 bytes, big endian)
+const (
+	backupMagic   = "SMTPEBBK"
+	backupVersion = byte(1)
+
+	// Kinds of backups
+	backupKindFull        = byte(0)
+	backupKindIncremental = byte(1)
+	// The state of the most recent backup, made of the digests of its values,
+	// which cannot be restored
+	backupKindState = byte(2)
+
+	// Record operations
+	backupOpEnd    = byte(0)
+	backupOpSet    = byte(1)
+	backupOpDelete = byte(2)
+
+	// maxBackupFieldSize is the largest key or value accepted when restoring,
+	// guarding against huge allocations caused by a corrupted stream
+	maxBackupFieldSize = 1 << 30
+
+	// backupStateFile is the name of the file holding the state of the most
+	// recent backup in the database directory
+	backupStateFile = "SMT-BACKUP-STATE"
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Backup writes a backup of the store to the provided writer. A full backup
+// contains every key-value pair in the store, while an incremental backup only
+// contains the changes made since the previous backup of the store. If no
+// backup was previously taken, a full backup is written.
+//
+// The keys and value digests of the most recent backup are recorded in the
+// backupStateFile of the database directory in order to compute incremental
+// backups, so they survive the store being reopened without holding on to any
+// version of the database.
+func (store *pebbleKVStore) Backup(w io.Writer, incremental bool) error {
+	if err := store.backup(w, incremental); err != nil {
+		return errors.Join(ErrPebbleUnableToBackup, err)
+	}
+	return nil
+}
+
+// Restore loads a backup written by `Backup` from the reader provided. The
+// records are applied atomically, only once the checksum of the entire stream
+// has been verified. Full backups should be restored into an empty store, and
+// incremental backups restored in the order they were taken.
+func (store *pebbleKVStore) Restore(r io.Reader) error {
+	batch := store.db.NewBatch()
+	defer batch.Close()
+	if err := readBackup(r, batch); err != nil {
+		return errors.Join(ErrPebbleUnableToRestore, err)
+	}
+	if err := batch.Commit(store.writeOptions); err != nil {
+		return errors.Join(ErrPebbleUnableToRestore, err)
+	}
+	return nil
+}
+
+// Checkpoint creates a consistent on-disk copy of the database in the given
+// directory, which must not already exist. The checkpoint can be opened as a
+// regular store with `NewKVStore`. In-memory stores cannot be checkpointed.
+func (store *pebbleKVStore) Checkpoint(dir string) error {
+	if store.inMemory {
+		return ErrPebbleUnableToCheckpoint
+	}
+	if err := store.db.Checkpoint(dir, pebble.WithFlushedWAL()); err != nil {
+		return errors.Join(ErrPebbleUnableToCheckpoint, err)
+	}
+	return nil
+}
+
+// backup writes the backup stream along with the state of the new backup to a
+// temporary file, which only replaces the previous state once the stream was
+// written entirely
+func (store *pebbleKVStore) backup(w io.Writer, incremental bool) error {
+	snapshot := store.db.NewSnapshot()
+	defer snapshot.Close()
+
+	statePath := store.fs.PathJoin(store.dir, backupStateFile)
+	var previous *backupStateReader
+	if incremental {
+		file, err := store.fs.Open(statePath)
+		switch {
+		case err == nil:
+			defer file.Close()
+			previous = newBackupStateReader(file)
+		case !errors.Is(err, os.ErrNotExist):
+			return err
+		}
+	}
+
+	tmpPath := statePath + ".tmp"
+	state, err := store.fs.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	err = writeBackup(w, previous, snapshot, state)
+	if err == nil {
+		err = state.Sync()
+	}
+	if closeErr := state.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = store.fs.Rename(tmpPath, statePath)
+	}
+	if err != nil {
+		return errors.Join(err, store.fs.Remove(tmpPath))
+	}
+	return nil
+}
+
+// writeBackup writes the records needed to go from the state of the previous
+// backup (if any) to the current snapshot, and the state of the new backup
+func writeBackup(w io.Writer, previous *backupStateReader, current *pebble.Snapshot, state io.Writer) error {
+	// Without a previous backup every key is written, resulting in a full
+	// backup
+	kind := backupKindFull
+	prevValid := false
+	if previous != nil {
+		kind = backupKindIncremental
+		var err error
+		if prevValid, err = previous.start(); err != nil {
+			return err
+		}
+	}
+	out, err := newBackupWriter(w, kind)
+	if err != nil {
+		return err
+	}
+	stateOut, err := newBackupWriter(state, backupKindState)
+	if err != nil {
+		return err
+	}
+
+	currentIter, err := current.NewIter(nil)
+	if err != nil {
+		return err
+	}
+	defer currentIter.Close()
+
+	// Merge the previous state with the current snapshot, writing the keys
+	// that were added, modified or deleted since the previous backup
+	currValid := currentIter.First()
+	for prevValid || currValid {
+		var digest []byte
+		if currValid {
+			digest = backupValueDigest(currentIter.Value())
+		}
+		cmp := 0
+		switch {
+		case !prevValid:
+			cmp = 1
+		case !currValid:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(previous.key, currentIter.Key())
+		}
+		switch {
+		case cmp < 0:
+			err = out.record(backupOpDelete, previous.key, nil)
+		case cmp > 0:
+			err = out.record(backupOpSet, currentIter.Key(), currentIter.Value())
+		default:
+			if !bytes.Equal(previous.digest, digest) {
+				err = out.record(backupOpSet, currentIter.Key(), currentIter.Value())
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if cmp <= 0 {
+			if prevValid, err = previous.next(); err != nil {
+				return err
+			}
+		}
+		if cmp >= 0 {
+			if err := stateOut.record(backupOpSet, currentIter.Key(), digest); err != nil {
+				return err
+			}
+			currValid = currentIter.Next()
+		}
+	}
+	if err := currentIter.Error(); err != nil {
+		return err
+	}
+	if err := out.close(); err != nil {
+		return err
+	}
+	return stateOut.close()
+}
+
+// writeBackupRecord writes a single set or delete record
+func writeBackupRecord(w io.Writer, op byte, key, value []byte) error {
+	buf := make([]byte, 0, 1+2*binary.MaxVarintLen64+len(key)+len(value))
+	buf = append(buf, op)
+	buf = binary.AppendUvarint(buf, uint64(len(key)))
+	buf = append(buf, key...)
+	if op == backupOpSet {
+		buf = binary.AppendUvarint(buf, uint64(len(value)))
+		buf = append(buf, value...)
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// readBackup reads the records of a backup stream into the batch provided,
+// verifying the header and the trailing checksum
+func readBackup(r io.Reader, batch *pebble.Batch) error {
+	in := newCRCReader(r)
+	kind, err := readBackupHeader(in)
+	if err != nil {
+		return err
+	}
+	if kind != backupKindFull && kind != backupKindIncremental {
+		return ErrPebbleInvalidBackup
+	}
+
+	for {
+		op, err := in.ReadByte()
+		if err != nil {
+			return err
+		}
+		if op == backupOpEnd {
+			break
+		}
+		if op != backupOpSet && op != backupOpDelete {
+			return ErrPebbleInvalidBackup
+		}
+		key, err := readBackupField(in)
+		if err != nil {
+			return err
+		}
+		if op == backupOpDelete {
+			if err := batch.Delete(key, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := readBackupField(in)
+		if err != nil {
+			return err
+		}
+		if err := batch.Set(key, value, nil); err != nil {
+			return err
+		}
+	}
+
+	return readBackupChecksum(in)
+}
+
+// readBackupHeader reads the header of a backup stream and returns its kind
+func readBackupHeader(in *crcReader) (byte, error) {
+	header := make([]byte, len(backupMagic)+2)
+	if _, err := io.ReadFull(in, header); err != nil {
+		return 0, err
+	}
+	if string(header[:len(backupMagic)]) != backupMagic {
+		return 0, ErrPebbleInvalidBackup
+	}
+	if header[len(backupMagic)] != backupVersion {
+		return 0, ErrPebbleUnsupportedBackupVersion
+	}
+	return header[len(backupMagic)+1], nil
+}
+
+// readBackupChecksum reads the trailing checksum of a backup stream, once the
+// end of its records was read, and verifies it
+func readBackupChecksum(in *crcReader) error {
+	expected := in.crc.Sum32()
+	var checksum uint32
+	if err := binary.Read(in.r, binary.BigEndian, &checksum); err != nil {
+		return err
+	}
+	if checksum != expected {
+		return ErrPebbleInvalidBackup
+	}
+	return nil
+}
+
+// readBackupField reads a length-prefixed key or value
+func readBackupField(in *crcReader) ([]byte, error) {
+	size, err := binary.ReadUvarint(in)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxBackupFieldSize {
+		return nil, ErrPebbleInvalidBackup
+	}
+	field := make([]byte, size)
+	if _, err := io.ReadFull(in, field); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// backupWriter writes a backup stream, computing its checksum
+type backupWriter struct {
+	bw  *bufio.Writer
+	crc hash.Hash32
+	out io.Writer
+}
+
+// newBackupWriter writes the header of a backup stream of the given kind and
+// returns a writer for its records
+func newBackupWriter(w io.Writer, kind byte) (*backupWriter, error) {
+	bw := bufio.NewWriter(w)
+	crc := crc32.New(crc32cTable)
+	out := &backupWriter{bw: bw, crc: crc, out: io.MultiWriter(bw, crc)}
+	header := append([]byte(backupMagic), backupVersion, kind)
+	if _, err := out.out.Write(header); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// record writes a single set or delete record
+func (out *backupWriter) record(op byte, key, value []byte) error {
+	return writeBackupRecord(out.out, op, key, value)
+}
+
+// close writes the end of the records and the checksum of the stream
+func (out *backupWriter) close() error {
+	if _, err := out.out.Write([]byte{backupOpEnd}); err != nil {
+		return err
+	}
+	if err := binary.Write(out.bw, binary.BigEndian, out.crc.Sum32()); err != nil {
+		return err
+	}
+	return out.bw.Flush()
+}
+
+// backupStateReader reads the keys and value digests of the state of a
+// backup one at a time, in ascending order of the keys. The checksum of the
+// state is verified once its last record is read.
+type backupStateReader struct {
+	in     *crcReader
+	key    []byte
+	digest []byte
+}
+
+// newBackupStateReader returns a reader for the state file provided
+func newBackupStateReader(r io.Reader) *backupStateReader {
+	return &backupStateReader{in: newCRCReader(r)}
+}
+
+// start reads the header of the state and its first record, returning false
+// if it holds no records
+func (s *backupStateReader) start() (bool, error) {
+	kind, err := readBackupHeader(s.in)
+	if err != nil {
+		return false, err
+	}
+	if kind != backupKindState {
+		return false, ErrPebbleInvalidBackup
+	}
+	return s.next()
+}
+
+// next reads the next record of the state, returning false once the records
+// are exhausted
+func (s *backupStateReader) next() (bool, error) {
+	op, err := s.in.ReadByte()
+	if err != nil {
+		return false, err
+	}
+	switch op {
+	case backupOpEnd:
+		return false, readBackupChecksum(s.in)
+	case backupOpSet:
+	default:
+		return false, ErrPebbleInvalidBackup
+	}
+	if s.key, err = readBackupField(s.in); err != nil {
+		return false, err
+	}
+	if s.digest, err = readBackupField(s.in); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// backupValueDigest returns the digest of a value recorded in the state of a
+// backup
+func backupValueDigest(value []byte) []byte {
+	digest := sha256.Sum256(value)
+	return digest[:]
+}
+
+// crcReader computes the checksum of all the bytes read through it
+type crcReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+}
+
+// newCRCReader returns a buffered crcReader reading from the reader provided
+func newCRCReader(r io.Reader) *crcReader {
+	return &crcReader{r: bufio.NewReader(r), crc: crc32.New(crc32cTable)}
+}
+
+// Read satisfies the io.Reader interface
+func (cr *crcReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.crc.Write(p[:n])
+	return n, err
+}
+
+// ReadByte satisfies the io.ByteReader interface
+func (cr *crcReader) ReadByte() (byte, error) {
+	b, err := cr.r.ReadByte()
+	if err == nil {
+		cr.crc.Write([]byte{b})
+	}
+	return b, err
+}
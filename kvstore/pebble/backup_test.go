@@ -0,0 +1,156 @@
+package pebble_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/pebble"
+)
+
+func TestPebble_KVStore_BackupAndRestore(t *testing.T) {
+	store, err := pebble.NewKVStore("")
+	require.NoError(t, err)
+	defer store.Stop()
+	setupStore(t, store)
+	for i := 0; i < 20; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+	}
+
+	full := new(bytes.Buffer)
+	require.NoError(t, store.Backup(full, false))
+
+	// Add, modify and delete keys after the full backup
+	require.NoError(t, store.Set([]byte("new"), []byte("value")))
+	require.NoError(t, store.Set([]byte("foo"), []byte("modified")))
+	require.NoError(t, store.Delete([]byte("baz")))
+	incremental := new(bytes.Buffer)
+	require.NoError(t, store.Backup(incremental, true))
+	require.Less(t, incremental.Len(), full.Len())
+
+	restored, err := pebble.NewKVStore("")
+	require.NoError(t, err)
+	defer restored.Stop()
+	require.NoError(t, restored.Restore(bytes.NewReader(full.Bytes())))
+	require.NoError(t, restored.Restore(bytes.NewReader(incremental.Bytes())))
+	requireSameContents(t, store, restored)
+
+	// An incremental backup without changes restores nothing
+	empty := new(bytes.Buffer)
+	require.NoError(t, store.Backup(empty, true))
+	require.NoError(t, restored.Restore(empty))
+	requireSameContents(t, store, restored)
+}
+
+func TestPebble_KVStore_IncrementalBackupAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	store, err := pebble.NewKVStore(dir)
+	require.NoError(t, err)
+	setupStore(t, store)
+	full := new(bytes.Buffer)
+	require.NoError(t, store.Backup(full, false))
+	require.NoError(t, store.Stop())
+
+	// The state of the last backup survives the store being reopened
+	store, err = pebble.NewKVStore(dir)
+	require.NoError(t, err)
+	defer store.Stop()
+	require.NoError(t, store.Set([]byte("new"), []byte("value")))
+	require.NoError(t, store.Delete([]byte("baz")))
+	incremental := new(bytes.Buffer)
+	require.NoError(t, store.Backup(incremental, true))
+	require.Less(t, incremental.Len(), full.Len())
+
+	restored, err := pebble.NewKVStore("")
+	require.NoError(t, err)
+	defer restored.Stop()
+	require.NoError(t, restored.Restore(bytes.NewReader(full.Bytes())))
+	require.NoError(t, restored.Restore(bytes.NewReader(incremental.Bytes())))
+	requireSameContents(t, store, restored)
+
+	// A corrupted state fails incremental backups until a full backup is taken
+	statePath := filepath.Join(dir, "SMT-BACKUP-STATE")
+	state, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	state[len(state)-10] ^= 0xff
+	require.NoError(t, os.WriteFile(statePath, state, 0o600))
+	err = store.Backup(new(bytes.Buffer), true)
+	require.ErrorIs(t, err, pebble.ErrPebbleUnableToBackup)
+	require.ErrorIs(t, err, pebble.ErrPebbleInvalidBackup)
+	require.NoError(t, store.Backup(new(bytes.Buffer), false))
+	empty := new(bytes.Buffer)
+	require.NoError(t, store.Backup(empty, true))
+	require.NoError(t, restored.Restore(empty))
+	requireSameContents(t, store, restored)
+}
+
+func TestPebble_KVStore_RestoreInvalid(t *testing.T) {
+	store, err := pebble.NewKVStore("")
+	require.NoError(t, err)
+	defer store.Stop()
+	setupStore(t, store)
+	backup := new(bytes.Buffer)
+	require.NoError(t, store.Backup(backup, false))
+
+	restored, err := pebble.NewKVStore("")
+	require.NoError(t, err)
+	defer restored.Stop()
+
+	// A corrupted record must be detected by the checksum
+	corrupted := append([]byte{}, backup.Bytes()...)
+	corrupted[len(corrupted)-10] ^= 0xff
+	err = restored.Restore(bytes.NewReader(corrupted))
+	require.ErrorIs(t, err, pebble.ErrPebbleUnableToRestore)
+	require.ErrorIs(t, err, pebble.ErrPebbleInvalidBackup)
+
+	// A truncated stream must not apply any of its records
+	err = restored.Restore(bytes.NewReader(backup.Bytes()[:backup.Len()/2]))
+	require.ErrorIs(t, err, pebble.ErrPebbleUnableToRestore)
+	length, err := restored.Len()
+	require.NoError(t, err)
+	require.Zero(t, length)
+
+	err = restored.Restore(bytes.NewReader([]byte("not a backup stream")))
+	require.ErrorIs(t, err, pebble.ErrPebbleInvalidBackup)
+}
+
+func TestPebble_KVStore_Checkpoint(t *testing.T) {
+	memStore, err := pebble.NewKVStore("")
+	require.NoError(t, err)
+	defer memStore.Stop()
+	require.ErrorIs(t, memStore.Checkpoint(t.TempDir()), pebble.ErrPebbleUnableToCheckpoint)
+
+	store, err := pebble.NewKVStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Stop()
+	setupStore(t, store)
+
+	dir := filepath.Join(t.TempDir(), "checkpoint")
+	require.NoError(t, store.Checkpoint(dir))
+	// Writes made after the checkpoint are not part of it
+	require.NoError(t, store.Set([]byte("after"), []byte("checkpoint")))
+
+	checkpoint, err := pebble.NewKVStore(dir)
+	require.NoError(t, err)
+	defer checkpoint.Stop()
+	exists, err := checkpoint.Exists([]byte("after"))
+	require.NoError(t, err)
+	require.False(t, exists)
+	require.NoError(t, store.Delete([]byte("after")))
+	requireSameContents(t, store, checkpoint)
+}
+
+// requireSameContents ensures both stores hold the same key-value pairs
+func requireSameContents(t *testing.T, expected, actual pebble.PebbleKVStore) {
+	t.Helper()
+	expectedKeys, expectedValues, err := expected.GetAll(nil, false)
+	require.NoError(t, err)
+	actualKeys, actualValues, err := actual.GetAll(nil, false)
+	require.NoError(t, err)
+	require.Equal(t, expectedKeys, actualKeys)
+	require.Equal(t, expectedValues, actualValues)
+}
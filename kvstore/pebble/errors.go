@@ -7,6 +7,9 @@ var (
 	// ErrPebbleOpeningStore is returned when there's an error opening the Pebble database.
 	ErrPebbleOpeningStore = errors.New("error opening the store")
 
+	// ErrPebbleInvalidOption is returned when the options provided to open the store are invalid.
+	ErrPebbleInvalidOption = errors.New("invalid store option")
+
 	// ErrPebbleUnableToSetValue is returned when a value cannot be set in the store.
 	ErrPebbleUnableToSetValue = errors.New("unable to set value")
 
@@ -31,6 +34,18 @@ var (
 	// ErrPebbleClosingStore is returned when there's an error closing the database connection.
 	ErrPebbleClosingStore = errors.New("unable to close database")
 
+	// ErrPebbleUnableToCheckpoint is returned when a checkpoint of the database cannot be created.
+	ErrPebbleUnableToCheckpoint = errors.New("unable to checkpoint database")
+
+	// ErrPebbleInvalidBackup is returned when a backup stream is malformed or its checksum does not match.
+	ErrPebbleInvalidBackup = errors.New("invalid backup stream")
+
+	// ErrPebbleUnsupportedBackupVersion is returned when a backup stream was written with an unknown format version.
+	ErrPebbleUnsupportedBackupVersion = errors.New("unsupported backup version")
+
+	// ErrPebbleCompacting is returned when there's an error compacting the database.
+	ErrPebbleCompacting = errors.New("unable to compact database")
+
 	// ErrPebbleGettingStoreLength is returned when there's an error getting the number of key-value pairs in the database.
 	ErrPebbleGettingStoreLength = errors.New("unable to get database length")
 )
@@ -1,12 +1,22 @@
 package pebble
 
 import (
+	"io"
+
+	"github.com/cockroachdb/pebble"
+
 	"github.com/pokt-network/smt/kvstore"
 )
 
 // Ensure the PebbleKVStore can be used as an SMT node store
 var _ kvstore.MapStore = (PebbleKVStore)(nil)
 
+// Ensure the PebbleKVStore can be iterated over and supports prefix deletion
+var (
+	_ kvstore.IterableMapStore = (PebbleKVStore)(nil)
+	_ kvstore.PrefixDeleter    = (PebbleKVStore)(nil)
+)
+
 // PebbleKVStore is an interface that defines a key-value store
 // that can be used standalone or as the node store for an SMT.
 // This is a superset of the MapStore interface that offers more
@@ -16,7 +26,19 @@ type PebbleKVStore interface {
 
 	// --- Lifecycle methods ---
 	Stop() error
+	// --- Data methods ---
+	Backup(writer io.Writer, incremental bool) error
+	Restore(io.Reader) error
+	Checkpoint(dir string) error
+	// --- Maintenance methods ---
+	Compact(start, end []byte) error
+	Metrics() *pebble.Metrics
+	Size() int64
 	// --- Accessors ---
 	GetAll(prefixKey []byte, descending bool) (keys, values [][]byte, err error)
 	Exists(key []byte) (bool, error)
+	DeleteRange(start, end []byte) error
+	DeletePrefix(prefix []byte) error
+	Iterator(prefix, start []byte, descending bool) (kvstore.Iterator, error)
+	RangeIterator(opts kvstore.IterOptions) (kvstore.Iterator, error)
 }
@@ -0,0 +1,100 @@
+package pebble
+
+import (
+	"errors"
+
+	"github.com/cockroachdb/pebble"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+var _ kvstore.Iterator = &pebbleIterator{}
+
+// pebbleIterator streams the entries of a pebble iterator, which reads from
+// an implicit snapshot of the database taken when it was created
+type pebbleIterator struct {
+	iter       *pebble.Iterator
+	descending bool
+	limit      int
+	count      int
+	started    bool
+	done       bool
+	closed     bool
+	err        error
+}
+
+// Iterator returns an iterator over the keys with the given prefix, starting
+// at the key provided (if any) in the specified order
+func (store *pebbleKVStore) Iterator(prefix, start []byte, descending bool) (kvstore.Iterator, error) {
+	return store.RangeIterator(kvstore.IterOptions{
+		Prefix:     prefix,
+		Start:      start,
+		Descending: descending,
+	})
+}
+
+// RangeIterator returns an iterator over the keys satisfying the options
+func (store *pebbleKVStore) RangeIterator(opts kvstore.IterOptions) (kvstore.Iterator, error) {
+	lower, upper := opts.Bounds()
+	iter, err := store.db.NewIter(&pebble.IterOptions{
+		LowerBound: lower,
+		UpperBound: upper,
+	})
+	if err != nil {
+		return nil, errors.Join(ErrPebbleIteratingStore, err)
+	}
+	return &pebbleIterator{
+		iter:       iter,
+		descending: opts.Descending,
+		limit:      opts.Limit,
+	}, nil
+}
+
+// Next satisfies the kvstore.Iterator#Next interface
+func (it *pebbleIterator) Next() bool {
+	if it.done || it.closed || it.err != nil || (it.limit > 0 && it.count >= it.limit) {
+		return false
+	}
+	var valid bool
+	switch {
+	case !it.started && it.descending:
+		valid = it.iter.Last()
+	case !it.started:
+		valid = it.iter.First()
+	case it.descending:
+		valid = it.iter.Prev()
+	default:
+		valid = it.iter.Next()
+	}
+	it.started = true
+	if !valid {
+		it.done = true
+		if err := it.iter.Error(); err != nil {
+			it.err = errors.Join(ErrPebbleIteratingStore, err)
+		}
+		return false
+	}
+	it.count++
+	return true
+}
+
+// Key satisfies the kvstore.Iterator#Key interface
+func (it *pebbleIterator) Key() []byte { return it.iter.Key() }
+
+// Value satisfies the kvstore.Iterator#Value interface
+func (it *pebbleIterator) Value() []byte { return it.iter.Value() }
+
+// Err satisfies the kvstore.Iterator#Err interface
+func (it *pebbleIterator) Err() error { return it.err }
+
+// Close satisfies the kvstore.Iterator#Close interface
+func (it *pebbleIterator) Close() error {
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+	if err := it.iter.Close(); err != nil {
+		return errors.Join(ErrPebbleIteratingStore, err)
+	}
+	return nil
+}
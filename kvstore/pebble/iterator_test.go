@@ -0,0 +1,121 @@
+package pebble_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/pebble"
+)
+
+func TestPebble_KVStore_Iterator(t *testing.T) {
+	store, err := pebble.NewKVStore("")
+	require.NoError(t, err)
+	defer store.Stop()
+	for _, key := range []string{"a", "b", "ba", "bb", "c"} {
+		require.NoError(t, store.Set([]byte(key), []byte("value-"+key)))
+	}
+
+	tests := []struct {
+		desc string
+		opts kvstore.IterOptions
+		want []string
+	}{
+		{
+			desc: "all keys ascending",
+			want: []string{"a", "b", "ba", "bb", "c"},
+		},
+		{
+			desc: "all keys descending",
+			opts: kvstore.IterOptions{Descending: true},
+			want: []string{"c", "bb", "ba", "b", "a"},
+		},
+		{
+			desc: "prefix ascending",
+			opts: kvstore.IterOptions{Prefix: []byte("b")},
+			want: []string{"b", "ba", "bb"},
+		},
+		{
+			desc: "prefix descending",
+			opts: kvstore.IterOptions{Prefix: []byte("b"), Descending: true},
+			want: []string{"bb", "ba", "b"},
+		},
+		{
+			desc: "start ascending",
+			opts: kvstore.IterOptions{Start: []byte("b0")},
+			want: []string{"ba", "bb", "c"},
+		},
+		{
+			desc: "start descending includes the start key",
+			opts: kvstore.IterOptions{Start: []byte("ba"), Descending: true},
+			want: []string{"ba", "b", "a"},
+		},
+		{
+			desc: "bounds and limit",
+			opts: kvstore.IterOptions{LowerBound: []byte("b"), UpperBound: []byte("c"), Limit: 2},
+			want: []string{"b", "ba"},
+		},
+		{
+			desc: "bounds descending",
+			opts: kvstore.IterOptions{LowerBound: []byte("b"), UpperBound: []byte("c"), Descending: true},
+			want: []string{"bb", "ba", "b"},
+		},
+		{
+			desc: "empty range",
+			opts: kvstore.IterOptions{Prefix: []byte("d")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			it, err := store.RangeIterator(tt.opts)
+			require.NoError(t, err)
+			defer it.Close()
+			var got []string
+			for it.Next() {
+				got = append(got, string(it.Key()))
+				require.Equal(t, "value-"+string(it.Key()), string(it.Value()))
+			}
+			require.NoError(t, it.Err())
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPebble_KVStore_IteratorPagination(t *testing.T) {
+	store, err := pebble.NewKVStore("")
+	require.NoError(t, err)
+	defer store.Stop()
+	for _, key := range []string{"k1", "k2", "k3", "k4", "k5"} {
+		require.NoError(t, store.Set([]byte(key), []byte(key)))
+	}
+
+	for _, descending := range []bool{false, true} {
+		var pages [][]string
+		var start []byte
+		for {
+			it, err := store.Iterator([]byte("k"), start, descending)
+			require.NoError(t, err)
+			var page []string
+			for len(page) < 2 && it.Next() {
+				page = append(page, string(it.Key()))
+			}
+			// The first key of the next page, if any
+			start = nil
+			if it.Next() {
+				start = append([]byte{}, it.Key()...)
+			}
+			require.NoError(t, it.Err())
+			require.NoError(t, it.Close())
+			pages = append(pages, page)
+			if start == nil {
+				break
+			}
+		}
+		if descending {
+			require.Equal(t, [][]string{{"k5", "k4"}, {"k3", "k2"}, {"k1"}}, pages)
+		} else {
+			require.Equal(t, [][]string{{"k1", "k2"}, {"k3", "k4"}, {"k5"}}, pages)
+		}
+	}
+}
@@ -5,27 +5,52 @@ import (
 
 	"github.com/cockroachdb/pebble"
 	"github.com/cockroachdb/pebble/vfs"
+
+	"github.com/pokt-network/smt/kvstore"
 )
 
 var _ PebbleKVStore = &pebbleKVStore{}
 
 type pebbleKVStore struct {
-	db *pebble.DB
+	db           *pebble.DB
+	inMemory     bool
+	writeOptions *pebble.WriteOptions
+	// The filesystem and directory of the database, holding the state of the
+	// most recent backup
+	fs  vfs.FS
+	dir string
 }
 
 // NewKVStore creates a new PebbleKVStore instance.
-// If path is empty, it creates an in-memory store.
-// TODO_CONSIDERATION: consider exposing the low-level options (`pebble.Options{}`)
-// via a config file to make it easier to test under different load conditions.
-func NewKVStore(path string) (PebbleKVStore, error) {
-	store := &pebbleKVStore{}
-
-	opts := &pebble.Options{}
-	if path == "" {
-		opts.FS = vfs.NewMem()
+// If path is empty, it creates an in-memory store. The low-level options of
+// the database can be configured with the options provided, see `Config` for
+// details.
+func NewKVStore(path string, opts ...Option) (PebbleKVStore, error) {
+	var cfg Config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	store := &pebbleKVStore{
+		inMemory:     path == "",
+		writeOptions: writeOptions(cfg),
+		fs:           vfs.Default,
+		dir:          path,
+	}
+
+	pebbleOpts, cache, err := pebbleOptions(store.inMemory, cfg)
+	if err != nil {
+		return nil, errors.Join(ErrPebbleOpeningStore, err)
+	}
+	if cache != nil {
+		// The database holds its own reference to the cache once opened
+		defer cache.Unref()
+	}
+	if store.inMemory {
+		store.fs = vfs.NewMem()
 	}
+	pebbleOpts.FS = store.fs
 
-	db, err := pebble.Open(path, opts)
+	db, err := pebble.Open(path, pebbleOpts)
 	if err != nil {
 		return nil, errors.Join(ErrPebbleOpeningStore, err)
 	}
@@ -38,7 +63,7 @@ func (store *pebbleKVStore) Set(key, value []byte) error {
 	if key == nil {
 		return ErrPebbleUnableToSetValue
 	}
-	err := store.db.Set(key, value, pebble.Sync)
+	err := store.db.Set(key, value, store.writeOptions)
 	if err != nil {
 		return errors.Join(ErrPebbleUnableToSetValue, err)
 	}
@@ -53,7 +78,7 @@ func (store *pebbleKVStore) Get(key []byte) ([]byte, error) {
 	value, closer, err := store.db.Get(key)
 	if err != nil {
 		if err == pebble.ErrNotFound {
-			return nil, ErrPebbleUnableToGetValue
+			return nil, errors.Join(ErrPebbleUnableToGetValue, kvstore.ErrKeyNotFound)
 		}
 		return nil, errors.Join(ErrPebbleUnableToGetValue, err)
 	}
@@ -66,7 +91,7 @@ func (store *pebbleKVStore) Delete(key []byte) error {
 	if key == nil {
 		return ErrPebbleUnableToDeleteValue
 	}
-	err := store.db.Delete(key, pebble.Sync)
+	err := store.db.Delete(key, store.writeOptions)
 	if err != nil {
 		return errors.Join(ErrPebbleUnableToDeleteValue, err)
 	}
@@ -120,7 +145,7 @@ func (store *pebbleKVStore) ClearAll() error {
 	iter, _ := store.db.NewIter(nil)
 	defer iter.Close()
 	for iter.First(); iter.Valid(); iter.Next() {
-		if err := store.db.Delete(iter.Key(), pebble.Sync); err != nil {
+		if err := store.db.Delete(iter.Key(), store.writeOptions); err != nil {
 			return errors.Join(ErrPebbleClearingStore, err)
 		}
 	}
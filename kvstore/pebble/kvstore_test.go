@@ -6,6 +6,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
 	"github.com/pokt-network/smt/kvstore/pebble"
 )
 
@@ -422,3 +424,16 @@ func setupStore(t *testing.T, store pebble.PebbleKVStore) {
 	err = store.Set([]byte("baz"), []byte("bin"))
 	require.NoError(t, err)
 }
+
+func TestPebble_KVStore_Conformance(t *testing.T) {
+	kvstoretest.RunMapStoreSuite(t,
+		func(t *testing.T) kvstore.MapStore {
+			store, err := pebble.NewKVStore("")
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, store.Stop()) })
+			return store
+		},
+		kvstoretest.WithKeyNotFoundError(pebble.ErrPebbleUnableToGetValue, kvstore.ErrKeyNotFound),
+		kvstoretest.WithEmptyKeyStored(),
+	)
+}
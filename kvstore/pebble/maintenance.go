@@ -0,0 +1,46 @@
+package pebble
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// Compact compacts the keys in the range [start, end), dropping the keys that
+// were overwritten or deleted. A nil start or end leaves the range unbounded
+// on that side, so `Compact(nil, nil)` compacts the entire database.
+func (store *pebbleKVStore) Compact(start, end []byte) error {
+	iter, err := store.db.NewIter(&pebble.IterOptions{LowerBound: start, UpperBound: end})
+	if err != nil {
+		return errors.Join(ErrPebbleCompacting, err)
+	}
+	// Narrow unbounded ranges down to the keys present in the database
+	if start == nil && iter.First() {
+		start = append([]byte{}, iter.Key()...)
+	}
+	if end == nil && iter.Last() {
+		end = append(append([]byte{}, iter.Key()...), 0)
+	}
+	if err := errors.Join(iter.Error(), iter.Close()); err != nil {
+		return errors.Join(ErrPebbleCompacting, err)
+	}
+	if start == nil || end == nil || bytes.Compare(start, end) >= 0 {
+		// The range is empty, there is nothing to compact
+		return nil
+	}
+	if err := store.db.Compact(start, end, true); err != nil {
+		return errors.Join(ErrPebbleCompacting, err)
+	}
+	return nil
+}
+
+// Metrics returns the internal metrics of the database
+func (store *pebbleKVStore) Metrics() *pebble.Metrics {
+	return store.db.Metrics()
+}
+
+// Size returns the disk space (in bytes) used by the database
+func (store *pebbleKVStore) Size() int64 {
+	return int64(store.db.Metrics().DiskSpaceUsage())
+}
@@ -0,0 +1,37 @@
+package pebble_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/pebble"
+)
+
+func TestPebble_KVStore_Maintenance(t *testing.T) {
+	store, err := pebble.NewKVStore(t.TempDir(), pebble.WithSyncWrites(false))
+	require.NoError(t, err)
+	defer store.Stop()
+
+	// Compacting an empty database is a no-op
+	require.NoError(t, store.Compact(nil, nil))
+
+	value := make([]byte, 1024)
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, store.Set([]byte(fmt.Sprintf("key-%04d", i)), value))
+	}
+	for i := 0; i < 500; i++ {
+		require.NoError(t, store.Delete([]byte(fmt.Sprintf("key-%04d", i))))
+	}
+
+	require.NoError(t, store.Compact([]byte("key-0000"), []byte("key-0500")))
+	require.NoError(t, store.Compact(nil, nil))
+	metrics := store.Metrics()
+	require.NotZero(t, metrics.Compact.Count)
+	require.Positive(t, store.Size())
+
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, 500, length)
+}
@@ -0,0 +1,140 @@
+package pebble
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// Compression is the compression algorithm used for the data blocks stored on
+// disk
+type Compression string
+
+const (
+	// CompressionDefault keeps the default compression of the database
+	CompressionDefault Compression = ""
+	// CompressionNone disables compression
+	CompressionNone Compression = "none"
+	// CompressionSnappy compresses data blocks with Snappy
+	CompressionSnappy Compression = "snappy"
+	// CompressionZstd compresses data blocks with Zstandard
+	CompressionZstd Compression = "zstd"
+)
+
+// Logger is the interface used by the database to report internal events.
+// Pebble treats errors passed to Fatalf as unrecoverable, so implementations
+// must not return from it.
+type Logger = pebble.Logger
+
+// Config holds the low-level options of the store. Zero values keep the
+// defaults of the store, so a Config can be partially populated, e.g. when
+// loaded from a configuration file.
+type Config struct {
+	// SyncWrites makes every write wait for the data to be synced to disk,
+	// defaults to true
+	SyncWrites *bool `json:"sync_writes,omitempty" yaml:"sync_writes,omitempty"`
+	// BlockCacheSize is the size (in bytes) of the cache of data blocks
+	BlockCacheSize int64 `json:"block_cache_size,omitempty" yaml:"block_cache_size,omitempty"`
+	// MemTableSize is the size (in bytes) of each memtable
+	MemTableSize int64 `json:"mem_table_size,omitempty" yaml:"mem_table_size,omitempty"`
+	// Compression is the compression algorithm of the data blocks
+	Compression Compression `json:"compression,omitempty" yaml:"compression,omitempty"`
+	// ReadOnly opens an existing persistent database in read-only mode
+	ReadOnly bool `json:"read_only,omitempty" yaml:"read_only,omitempty"`
+	// Logger receives the database logs, pebble's default logger is used if nil
+	Logger Logger `json:"-" yaml:"-"`
+}
+
+// Option is a function that configures the store created by NewKVStore
+type Option func(*Config)
+
+// WithConfig returns an Option that replaces the configuration of the store
+// with the one provided. Options provided after it are applied on top.
+func WithConfig(cfg Config) Option {
+	return func(c *Config) { *c = cfg }
+}
+
+// WithSyncWrites returns an Option that sets whether every write waits for
+// the data to be synced to disk
+func WithSyncWrites(sync bool) Option {
+	return func(c *Config) { c.SyncWrites = &sync }
+}
+
+// WithBlockCacheSize returns an Option that sets the size (in bytes) of the
+// cache of data blocks
+func WithBlockCacheSize(size int64) Option {
+	return func(c *Config) { c.BlockCacheSize = size }
+}
+
+// WithMemTableSize returns an Option that sets the size (in bytes) of each
+// memtable
+func WithMemTableSize(size int64) Option {
+	return func(c *Config) { c.MemTableSize = size }
+}
+
+// WithCompression returns an Option that sets the compression algorithm of
+// the data blocks
+func WithCompression(compression Compression) Option {
+	return func(c *Config) { c.Compression = compression }
+}
+
+// WithReadOnly returns an Option that opens an existing persistent database
+// in read-only mode
+func WithReadOnly() Option {
+	return func(c *Config) { c.ReadOnly = true }
+}
+
+// WithLogger returns an Option that sets the logger receiving the database
+// logs
+func WithLogger(logger Logger) Option {
+	return func(c *Config) { c.Logger = logger }
+}
+
+// pebbleOptions returns the pebble options for the store being created. The
+// caller must release the returned block cache, if any, once the database is
+// opened.
+func pebbleOptions(inMemory bool, cfg Config) (*pebble.Options, *pebble.Cache, error) {
+	opts := &pebble.Options{
+		ReadOnly: cfg.ReadOnly,
+		Logger:   cfg.Logger,
+	}
+	if inMemory && cfg.ReadOnly {
+		return nil, nil, errors.Join(ErrPebbleInvalidOption, errors.New("an in-memory store cannot be read-only"))
+	}
+	if cfg.MemTableSize > 0 {
+		opts.MemTableSize = uint64(cfg.MemTableSize)
+	}
+	var compression pebble.Compression
+	switch cfg.Compression {
+	case CompressionDefault:
+	case CompressionNone:
+		compression = pebble.NoCompression
+	case CompressionSnappy:
+		compression = pebble.SnappyCompression
+	case CompressionZstd:
+		compression = pebble.ZstdCompression
+	default:
+		return nil, nil, errors.Join(ErrPebbleInvalidOption, fmt.Errorf("unknown compression: %s", cfg.Compression))
+	}
+	opts.EnsureDefaults()
+	if compression != pebble.DefaultCompression {
+		for i := range opts.Levels {
+			opts.Levels[i].Compression = compression
+		}
+	}
+	var cache *pebble.Cache
+	if cfg.BlockCacheSize > 0 {
+		cache = pebble.NewCache(cfg.BlockCacheSize)
+		opts.Cache = cache
+	}
+	return opts, cache, nil
+}
+
+// writeOptions returns the options used for every write to the store
+func writeOptions(cfg Config) *pebble.WriteOptions {
+	if cfg.SyncWrites != nil && !*cfg.SyncWrites {
+		return pebble.NoSync
+	}
+	return pebble.Sync
+}
@@ -0,0 +1,81 @@
+package pebble_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/pebble"
+)
+
+func TestPebble_KVStore_Options(t *testing.T) {
+	logger := &recordingLogger{}
+	dir := t.TempDir()
+	store, err := pebble.NewKVStore(dir,
+		pebble.WithSyncWrites(false),
+		pebble.WithBlockCacheSize(8<<20),
+		pebble.WithMemTableSize(4<<20),
+		pebble.WithCompression(pebble.CompressionZstd),
+		pebble.WithLogger(logger),
+	)
+	require.NoError(t, err)
+	setupStore(t, store)
+	require.NoError(t, store.Stop())
+
+	// Reopen the database in read-only mode, replaying the WAL is logged
+	store, err = pebble.NewKVStore(dir, pebble.WithReadOnly(), pebble.WithLogger(logger))
+	require.NoError(t, err)
+	defer store.Stop()
+	require.NotZero(t, logger.count())
+	value, err := store.Get([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), value)
+	require.Error(t, store.Set([]byte("foo"), []byte("baz")))
+}
+
+func TestPebble_KVStore_OptionsConfig(t *testing.T) {
+	var cfg pebble.Config
+	err := json.Unmarshal([]byte(`{"sync_writes": false, "block_cache_size": 1048576, "compression": "snappy"}`), &cfg)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.SyncWrites)
+	require.False(t, *cfg.SyncWrites)
+	require.Equal(t, int64(1<<20), cfg.BlockCacheSize)
+	require.Equal(t, pebble.CompressionSnappy, cfg.Compression)
+
+	store, err := pebble.NewKVStore("", pebble.WithConfig(cfg), pebble.WithMemTableSize(2<<20))
+	require.NoError(t, err)
+	setupStore(t, store)
+	require.NoError(t, store.Stop())
+
+	_, err = pebble.NewKVStore("", pebble.WithCompression("lz4"))
+	require.ErrorIs(t, err, pebble.ErrPebbleOpeningStore)
+	require.ErrorIs(t, err, pebble.ErrPebbleInvalidOption)
+
+	_, err = pebble.NewKVStore("", pebble.WithReadOnly())
+	require.ErrorIs(t, err, pebble.ErrPebbleInvalidOption)
+}
+
+// recordingLogger counts the log messages it receives
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) Infof(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Fatalf(format string, args ...interface{}) {
+	panic(fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.messages)
+}
@@ -0,0 +1,43 @@
+package pebble
+
+import (
+	"errors"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// DeleteRange removes all the keys in the range [start, end) atomically with a
+// single range tombstone. A nil end leaves the range unbounded.
+func (store *pebbleKVStore) DeleteRange(start, end []byte) error {
+	if start == nil {
+		start = []byte{}
+	}
+	if end == nil {
+		// Range tombstones need an end key, so use the key right after the
+		// last key of the database
+		iter, err := store.db.NewIter(nil)
+		if err != nil {
+			return errors.Join(ErrPebbleUnableToDeleteValue, err)
+		}
+		if iter.Last() {
+			end = append(append([]byte{}, iter.Key()...), 0)
+		}
+		if err := errors.Join(iter.Error(), iter.Close()); err != nil {
+			return errors.Join(ErrPebbleUnableToDeleteValue, err)
+		}
+		if end == nil {
+			// The database is empty
+			return nil
+		}
+	}
+	if err := store.db.DeleteRange(start, end, store.writeOptions); err != nil {
+		return errors.Join(ErrPebbleUnableToDeleteValue, err)
+	}
+	return nil
+}
+
+// DeletePrefix removes all the keys starting with the prefix atomically with a
+// single range tombstone
+func (store *pebbleKVStore) DeletePrefix(prefix []byte) error {
+	return store.DeleteRange(prefix, kvstore.PrefixEnd(prefix))
+}
@@ -0,0 +1,14 @@
+package prefixed
+
+import (
+	"errors"
+)
+
+var (
+	// ErrPrefixedEmptyPrefix is returned when the namespace prefix is empty
+	ErrPrefixedEmptyPrefix = errors.New("namespace prefix is empty")
+	// ErrPrefixedNilStore is returned when no underlying store is provided
+	ErrPrefixedNilStore = errors.New("underlying store is nil")
+	// ErrPrefixedEmptyKey is returned when the given key is empty
+	ErrPrefixedEmptyKey = errors.New("key is empty")
+)
@@ -0,0 +1,4 @@
+// Package prefixed provides a MapStore wrapper that namespaces every key of
+// the store it wraps under a fixed prefix. It can be used to host many
+// independent tries in a single database, and to drop a whole trie at once.
+package prefixed
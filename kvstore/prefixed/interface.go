@@ -0,0 +1,29 @@
+package prefixed
+
+import (
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the PrefixedKVStore can be used as an SMT node store, and can itself
+// be wrapped to nest namespaces
+var (
+	_ kvstore.MapStore         = (PrefixedKVStore)(nil)
+	_ kvstore.IterableMapStore = (PrefixedKVStore)(nil)
+	_ kvstore.PrefixDeleter    = (PrefixedKVStore)(nil)
+)
+
+// PrefixedKVStore is a MapStore whose keys are all stored under a namespace
+// prefix in an underlying store. Its Len and ClearAll methods only cover the
+// keys in its own namespace, and its iterators return keys without the prefix.
+//
+// The prefixes of stores sharing an underlying store must not be prefixes of
+// one another (e.g. use fixed-length prefixes or terminate them with a
+// separator), otherwise their namespaces overlap.
+type PrefixedKVStore interface {
+	kvstore.IterableMapStore
+
+	// Prefix returns the namespace prefix of the store
+	Prefix() []byte
+	// DeletePrefix removes all the keys of the namespace starting with the prefix
+	DeletePrefix(prefix []byte) error
+}
@@ -0,0 +1,164 @@
+package prefixed
+
+import (
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// deleteBatchSize is the number of keys collected and deleted at once when
+// the underlying store cannot delete a prefix natively
+const deleteBatchSize = 1000
+
+var _ PrefixedKVStore = &prefixedKVStore{}
+
+// prefixedKVStore namespaces every key of the underlying store with a prefix
+type prefixedKVStore struct {
+	store  kvstore.IterableMapStore
+	prefix []byte
+}
+
+// NewKVStore creates a new PrefixedKVStore storing all of its keys under the
+// given prefix in the store provided.
+func NewKVStore(store kvstore.IterableMapStore, prefix []byte) (PrefixedKVStore, error) {
+	if store == nil {
+		return nil, ErrPrefixedNilStore
+	}
+	if len(prefix) == 0 {
+		return nil, ErrPrefixedEmptyPrefix
+	}
+	return &prefixedKVStore{
+		store:  store,
+		prefix: append([]byte{}, prefix...),
+	}, nil
+}
+
+// Prefix returns the namespace prefix of the store
+func (p *prefixedKVStore) Prefix() []byte {
+	return p.prefix
+}
+
+// Get returns the value for a given key
+func (p *prefixedKVStore) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrPrefixedEmptyKey
+	}
+	return p.store.Get(p.prefixed(key))
+}
+
+// Set sets/updates the value for a given key
+func (p *prefixedKVStore) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return ErrPrefixedEmptyKey
+	}
+	return p.store.Set(p.prefixed(key), value)
+}
+
+// Delete removes a key
+func (p *prefixedKVStore) Delete(key []byte) error {
+	if len(key) == 0 {
+		return ErrPrefixedEmptyKey
+	}
+	return p.store.Delete(p.prefixed(key))
+}
+
+// Len returns the number of key-value pairs in the namespace
+func (p *prefixedKVStore) Len() (int, error) {
+	it, err := p.store.RangeIterator(kvstore.IterOptions{Prefix: p.prefix})
+	if err != nil {
+		return 0, err
+	}
+	defer it.Close()
+	count := 0
+	for it.Next() {
+		count++
+	}
+	return count, it.Err()
+}
+
+// ClearAll deletes all key-value pairs in the namespace, leaving the rest of
+// the underlying store untouched
+func (p *prefixedKVStore) ClearAll() error {
+	return p.deleteUnderlyingPrefix(p.prefix)
+}
+
+// DeletePrefix removes all the keys of the namespace starting with the prefix
+func (p *prefixedKVStore) DeletePrefix(prefix []byte) error {
+	return p.deleteUnderlyingPrefix(p.prefixed(prefix))
+}
+
+// RangeIterator returns an iterator over the keys of the namespace satisfying
+// the options. The keys returned do not include the namespace prefix.
+func (p *prefixedKVStore) RangeIterator(opts kvstore.IterOptions) (kvstore.Iterator, error) {
+	underlying := kvstore.IterOptions{
+		Prefix:     p.prefixed(opts.Prefix),
+		Descending: opts.Descending,
+		Limit:      opts.Limit,
+	}
+	if opts.LowerBound != nil {
+		underlying.LowerBound = p.prefixed(opts.LowerBound)
+	}
+	if opts.UpperBound != nil {
+		underlying.UpperBound = p.prefixed(opts.UpperBound)
+	}
+	if opts.Start != nil {
+		underlying.Start = p.prefixed(opts.Start)
+	}
+	it, err := p.store.RangeIterator(underlying)
+	if err != nil {
+		return nil, err
+	}
+	return &prefixedIterator{Iterator: it, prefixLen: len(p.prefix)}, nil
+}
+
+// deleteUnderlyingPrefix deletes all the keys of the underlying store starting
+// with the prefix, natively if the underlying store supports it
+func (p *prefixedKVStore) deleteUnderlyingPrefix(prefix []byte) error {
+	if deleter, ok := p.store.(kvstore.PrefixDeleter); ok {
+		return deleter.DeletePrefix(prefix)
+	}
+	for {
+		// Collect a batch of keys before deleting them, as stores are not
+		// required to support writes while iterating
+		it, err := p.store.RangeIterator(kvstore.IterOptions{Prefix: prefix, Limit: deleteBatchSize})
+		if err != nil {
+			return err
+		}
+		keys := make([][]byte, 0, deleteBatchSize)
+		for it.Next() {
+			keys = append(keys, append([]byte{}, it.Key()...))
+		}
+		if err := it.Err(); err != nil {
+			it.Close()
+			return err
+		}
+		if err := it.Close(); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := p.store.Delete(key); err != nil {
+				return err
+			}
+		}
+		if len(keys) < deleteBatchSize {
+			return nil
+		}
+	}
+}
+
+// prefixed returns the key of the underlying store for the given key
+func (p *prefixedKVStore) prefixed(key []byte) []byte {
+	prefixed := make([]byte, 0, len(p.prefix)+len(key))
+	prefixed = append(prefixed, p.prefix...)
+	return append(prefixed, key...)
+}
+
+// prefixedIterator strips the namespace prefix from the keys of an iterator
+// over the underlying store
+type prefixedIterator struct {
+	kvstore.Iterator
+	prefixLen int
+}
+
+// Key satisfies the kvstore.Iterator#Key interface
+func (it *prefixedIterator) Key() []byte {
+	return it.Iterator.Key()[it.prefixLen:]
+}
@@ -0,0 +1,161 @@
+package prefixed_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/prefixed"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestPrefixed_KVStore_Invalid(t *testing.T) {
+	_, err := prefixed.NewKVStore(nil, []byte("ns/"))
+	require.ErrorIs(t, err, prefixed.ErrPrefixedNilStore)
+	_, err = prefixed.NewKVStore(simplemap.NewSimpleMap(), nil)
+	require.ErrorIs(t, err, prefixed.ErrPrefixedEmptyPrefix)
+}
+
+func TestPrefixed_KVStore_Conformance(t *testing.T) {
+	kvstoretest.RunMapStoreSuite(t,
+		func(t *testing.T) kvstore.MapStore {
+			// Keys outside of the namespace must not be visible to the store
+			underlying := simplemap.NewSimpleMap()
+			require.NoError(t, underlying.Set([]byte("other/key"), []byte("value")))
+			store, err := prefixed.NewKVStore(underlying, []byte("ns/"))
+			require.NoError(t, err)
+			return store
+		},
+		kvstoretest.WithKeyNotFoundError(simplemap.ErrKVStoreKeyNotFound),
+		// The wrapped simple map does not copy values
+		kvstoretest.WithSharedValues(),
+		kvstoretest.WithEmptyKeyError(prefixed.ErrPrefixedEmptyKey),
+	)
+}
+
+func TestPrefixed_KVStore_Namespaces(t *testing.T) {
+	for desc, underlying := range map[string]kvstore.IterableMapStore{
+		"native prefix deletion":    simplemap.NewSimpleMap(),
+		"iterative prefix deletion": &iterableOnly{simplemap.NewSimpleMap()},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			require.NoError(t, underlying.Set([]byte("unprefixed"), []byte("value")))
+			first, err := prefixed.NewKVStore(underlying, []byte("first/"))
+			require.NoError(t, err)
+			second, err := prefixed.NewKVStore(underlying, []byte("second/"))
+			require.NoError(t, err)
+
+			// The same key in different namespaces must not collide
+			require.NoError(t, first.Set([]byte("key"), []byte("first")))
+			require.NoError(t, second.Set([]byte("key"), []byte("second")))
+			value, err := first.Get([]byte("key"))
+			require.NoError(t, err)
+			require.Equal(t, []byte("first"), value)
+			value, err = underlying.Get([]byte("second/key"))
+			require.NoError(t, err)
+			require.Equal(t, []byte("second"), value)
+
+			for i := 0; i < 2500; i++ {
+				require.NoError(t, first.Set([]byte(fmt.Sprintf("key-%d", i)), []byte("value")))
+			}
+			requireLen(t, first, 2501)
+			requireLen(t, second, 1)
+			requireLen(t, underlying, 2503)
+
+			require.NoError(t, first.Delete([]byte("key")))
+			_, err = first.Get([]byte("key"))
+			require.ErrorIs(t, err, simplemap.ErrKVStoreKeyNotFound)
+			requireLen(t, first, 2500)
+
+			// Clearing a namespace leaves the rest of the store untouched
+			require.NoError(t, first.ClearAll())
+			requireLen(t, first, 0)
+			requireLen(t, second, 1)
+			requireLen(t, underlying, 2)
+		})
+	}
+}
+
+func TestPrefixed_KVStore_Iterator(t *testing.T) {
+	underlying := simplemap.NewSimpleMap()
+	store, err := prefixed.NewKVStore(underlying, []byte("ns/"))
+	require.NoError(t, err)
+	require.NoError(t, underlying.Set([]byte("nr/b"), []byte("outside")))
+	require.NoError(t, underlying.Set([]byte("nt/b"), []byte("outside")))
+	for _, key := range []string{"a", "b", "ba", "c"} {
+		require.NoError(t, store.Set([]byte(key), []byte(key)))
+	}
+
+	it, err := store.RangeIterator(kvstore.IterOptions{
+		LowerBound: []byte("b"),
+		Descending: true,
+	})
+	require.NoError(t, err)
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+		require.Equal(t, it.Key(), it.Value())
+	}
+	require.NoError(t, it.Err())
+	require.NoError(t, it.Close())
+	require.Equal(t, []string{"c", "ba", "b"}, keys)
+
+	// Namespaces can be nested and deleted by prefix
+	nested, err := prefixed.NewKVStore(store, []byte("b"))
+	require.NoError(t, err)
+	requireLen(t, nested, 2)
+	require.NoError(t, store.DeletePrefix([]byte("b")))
+	requireLen(t, nested, 0)
+	requireLen(t, store, 2)
+}
+
+func TestPrefixed_KVStore_SMTs(t *testing.T) {
+	underlying := simplemap.NewSimpleMap()
+	var roots [][]byte
+	var stores []prefixed.PrefixedKVStore
+	for i := 0; i < 3; i++ {
+		store, err := prefixed.NewKVStore(underlying, []byte(fmt.Sprintf("session-%d/", i)))
+		require.NoError(t, err)
+		trie := smt.NewSparseMerkleTrie(store, sha256.New())
+		for j := 0; j < 50; j++ {
+			require.NoError(t, trie.Update([]byte(fmt.Sprintf("key-%d", j)), []byte(fmt.Sprintf("value-%d-%d", i, j))))
+		}
+		require.NoError(t, trie.Commit())
+		roots = append(roots, trie.Root())
+		stores = append(stores, store)
+	}
+
+	// Dropping a trie leaves the others intact
+	require.NoError(t, stores[1].ClearAll())
+	for _, i := range []int{0, 2} {
+		trie := smt.ImportSparseMerkleTrie(stores[i], sha256.New(), roots[i])
+		value, err := trie.Get([]byte("key-7"))
+		require.NoError(t, err)
+		require.Equal(t, sha256Sum([]byte(fmt.Sprintf("value-%d-7", i))), value)
+	}
+	trie := smt.ImportSparseMerkleTrie(stores[1], sha256.New(), roots[1])
+	_, err := trie.Get([]byte("key-7"))
+	require.Error(t, err)
+}
+
+// iterableOnly hides the native prefix deletion of the store it wraps
+type iterableOnly struct {
+	kvstore.IterableMapStore
+}
+
+func requireLen(t *testing.T, store kvstore.MapStore, expected int) {
+	t.Helper()
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, expected, length)
+}
+
+func sha256Sum(data []byte) []byte {
+	digest := sha256.Sum256(data)
+	return digest[:]
+}
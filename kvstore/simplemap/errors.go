@@ -2,11 +2,29 @@ package simplemap
 
 import (
 	"errors"
+
+	"github.com/pokt-network/smt/kvstore"
 )
 
 var (
 	// ErrKVStoreKeyNotFound is returned when a key is not present in the trie.
-	ErrKVStoreKeyNotFound = errors.New("key already empty")
+	// It matches kvstore.ErrKeyNotFound when using errors.Is.
+	ErrKVStoreKeyNotFound error = &keyNotFoundError{"key already empty"}
 	// ErrKVStoreEmptyKey is returned when the given key is empty.
 	ErrKVStoreEmptyKey = errors.New("key is empty")
 )
+
+// keyNotFoundError keeps the original message of ErrKVStoreKeyNotFound while
+// matching kvstore.ErrKeyNotFound
+type keyNotFoundError struct {
+	msg string
+}
+
+func (e *keyNotFoundError) Error() string {
+	return e.msg
+}
+
+// Is reports whether the target is kvstore.ErrKeyNotFound
+func (e *keyNotFoundError) Is(target error) bool {
+	return target == kvstore.ErrKeyNotFound
+}
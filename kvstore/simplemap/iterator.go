@@ -0,0 +1,68 @@
+package simplemap
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure that the SimpleMap can be iterated over
+var _ kvstore.IterableMapStore = (SimpleMap)(nil)
+
+// simpleMapIterator iterates over a sorted copy of the entries of the map
+// taken when the iterator was created
+type simpleMapIterator struct {
+	keys   []string
+	values [][]byte
+	idx    int
+}
+
+// RangeIterator returns an iterator over the keys satisfying the options.
+// The matching entries are copied and sorted when the iterator is created,
+// so later writes to the map are not visible through the iterator.
+func (sm *simpleMap) RangeIterator(opts kvstore.IterOptions) (kvstore.Iterator, error) {
+	lower, upper := opts.Bounds()
+	keys := make([]string, 0)
+	for key := range sm.m {
+		if bytes.Compare([]byte(key), lower) >= 0 && (upper == nil || bytes.Compare([]byte(key), upper) < 0) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if opts.Descending {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+	if opts.Limit > 0 && len(keys) > opts.Limit {
+		keys = keys[:opts.Limit]
+	}
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = sm.m[key]
+	}
+	return &simpleMapIterator{keys: keys, values: values, idx: -1}, nil
+}
+
+// Next satisfies the kvstore.Iterator#Next interface
+func (it *simpleMapIterator) Next() bool {
+	if it.idx+1 >= len(it.keys) {
+		it.idx = len(it.keys)
+		return false
+	}
+	it.idx++
+	return true
+}
+
+// Key satisfies the kvstore.Iterator#Key interface
+func (it *simpleMapIterator) Key() []byte { return []byte(it.keys[it.idx]) }
+
+// Value satisfies the kvstore.Iterator#Value interface
+func (it *simpleMapIterator) Value() []byte { return it.values[it.idx] }
+
+// Err satisfies the kvstore.Iterator#Err interface
+func (it *simpleMapIterator) Err() error { return nil }
+
+// Close satisfies the kvstore.Iterator#Close interface
+func (it *simpleMapIterator) Close() error { return nil }
@@ -1,19 +1,40 @@
 package simplemap
 
 import (
+	"bytes"
+	"strings"
+
 	"github.com/pokt-network/smt/kvstore"
 )
 
 // Ensure that the SimpleMap can be used as an SMT node store
 var _ kvstore.MapStore = (*simpleMap)(nil)
 
+// Ensure that the simpleMap implements the SimpleMap interface
+var _ SimpleMap = (*simpleMap)(nil)
+
+// SimpleMap is an in-memory key-value store that can be used as an SMT node
+// store. This is a superset of the MapStore interface offering the bulk
+// deletions supported by the persistent stores.
+type SimpleMap interface {
+	kvstore.MapStore
+
+	// DeleteRange removes all the keys in the range [start, end), a nil end
+	// leaves the range unbounded
+	DeleteRange(start, end []byte) error
+	// DeletePrefix removes all the keys starting with the prefix
+	DeletePrefix(prefix []byte) error
+	// RangeIterator returns an iterator over the keys satisfying the options
+	RangeIterator(opts kvstore.IterOptions) (kvstore.Iterator, error)
+}
+
 // simpleMap is a simple in-memory map.
 type simpleMap struct {
 	m map[string][]byte
 }
 
 // NewSimpleMap creates a new SimpleMap instance.
-func NewSimpleMap() kvstore.MapStore {
+func NewSimpleMap() SimpleMap {
 	return &simpleMap{
 		m: make(map[string][]byte),
 	}
@@ -21,7 +42,7 @@ func NewSimpleMap() kvstore.MapStore {
 
 // NewSimpleMap creates a new SimpleMap instance using the map provided.
 // This is useful for testing & debugging purposes.
-func NewSimpleMapWithMap(m map[string][]byte) kvstore.MapStore {
+func NewSimpleMapWithMap(m map[string][]byte) SimpleMap {
 	return &simpleMap{
 		m: m,
 	}
@@ -73,3 +94,23 @@ func (sm *simpleMap) ClearAll() error {
 	sm.m = make(map[string][]byte)
 	return nil
 }
+
+// DeleteRange deletes all the keys in the range [start, end).
+func (sm *simpleMap) DeleteRange(start, end []byte) error {
+	for key := range sm.m {
+		if bytes.Compare([]byte(key), start) >= 0 && (end == nil || bytes.Compare([]byte(key), end) < 0) {
+			delete(sm.m, key)
+		}
+	}
+	return nil
+}
+
+// DeletePrefix deletes all the keys starting with the prefix.
+func (sm *simpleMap) DeletePrefix(prefix []byte) error {
+	for key := range sm.m {
+		if strings.HasPrefix(key, string(prefix)) {
+			delete(sm.m, key)
+		}
+	}
+	return nil
+}
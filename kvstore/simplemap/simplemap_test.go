@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
 )
 
 func TestSimpleMap_Get(t *testing.T) {
@@ -55,6 +56,15 @@ func TestSimpleMap_Get(t *testing.T) {
 	}
 }
 
+func TestSimpleMap_KeyNotFoundError(t *testing.T) {
+	store := NewSimpleMap()
+
+	_, err := store.Get([]byte("nonexistent"))
+	require.Equal(t, ErrKVStoreKeyNotFound, err)
+	require.EqualError(t, err, "key already empty")
+	require.ErrorIs(t, err, kvstore.ErrKeyNotFound)
+}
+
 func TestSimpleMap_Set(t *testing.T) {
 	store := NewSimpleMap()
 
@@ -178,3 +188,125 @@ func TestSimpleMap_ClearAll(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 0, len)
 }
+
+func TestSimpleMap_DeleteRange(t *testing.T) {
+	tests := []struct {
+		desc         string
+		start, end   []byte
+		expectedKeys []string
+	}{
+		{
+			desc:         "Delete bounded range",
+			start:        []byte("b"),
+			end:          []byte("c"),
+			expectedKeys: []string{"a", "c"},
+		},
+		{
+			desc:         "Delete unbounded range",
+			start:        []byte("ba"),
+			end:          nil,
+			expectedKeys: []string{"a", "b"},
+		},
+		{
+			desc:         "Delete everything",
+			start:        nil,
+			end:          nil,
+			expectedKeys: []string{},
+		},
+		{
+			desc:         "Delete empty range",
+			start:        []byte("d"),
+			end:          []byte("e"),
+			expectedKeys: []string{"a", "b", "ba", "bb", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			store := newPopulatedSimpleMap(t)
+			require.NoError(t, store.DeleteRange(tt.start, tt.end))
+			requireKeys(t, store, tt.expectedKeys)
+		})
+	}
+}
+
+func TestSimpleMap_DeletePrefix(t *testing.T) {
+	store := newPopulatedSimpleMap(t)
+	require.NoError(t, store.DeletePrefix([]byte("b")))
+	requireKeys(t, store, []string{"a", "c"})
+
+	require.NoError(t, store.DeletePrefix(nil))
+	requireKeys(t, store, []string{})
+}
+
+// newPopulatedSimpleMap returns a SimpleMap holding a few keys
+func newPopulatedSimpleMap(t *testing.T) SimpleMap {
+	t.Helper()
+	store := NewSimpleMap()
+	for _, key := range []string{"a", "b", "ba", "bb", "c"} {
+		require.NoError(t, store.Set([]byte(key), []byte("value")))
+	}
+	return store
+}
+
+// requireKeys ensures the store holds exactly the keys provided
+func requireKeys(t *testing.T, store SimpleMap, keys []string) {
+	t.Helper()
+	for _, key := range keys {
+		_, err := store.Get([]byte(key))
+		require.NoError(t, err)
+	}
+	length, err := store.Len()
+	require.NoError(t, err)
+	require.Equal(t, len(keys), length)
+}
+
+func TestSimpleMap_RangeIterator(t *testing.T) {
+	store := newPopulatedSimpleMap(t)
+
+	tests := []struct {
+		desc         string
+		opts         kvstore.IterOptions
+		expectedKeys []string
+	}{
+		{
+			desc:         "Iterate over all keys",
+			expectedKeys: []string{"a", "b", "ba", "bb", "c"},
+		},
+		{
+			desc:         "Iterate over prefix in descending order",
+			opts:         kvstore.IterOptions{Prefix: []byte("b"), Descending: true},
+			expectedKeys: []string{"bb", "ba", "b"},
+		},
+		{
+			desc:         "Iterate from start with limit",
+			opts:         kvstore.IterOptions{Start: []byte("b"), Limit: 2},
+			expectedKeys: []string{"b", "ba"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			it, err := store.RangeIterator(tt.opts)
+			require.NoError(t, err)
+			keys := make([]string, 0)
+			for it.Next() {
+				keys = append(keys, string(it.Key()))
+				require.Equal(t, []byte("value"), it.Value())
+			}
+			require.NoError(t, it.Err())
+			require.NoError(t, it.Close())
+			require.False(t, it.Next())
+			require.Equal(t, tt.expectedKeys, keys)
+		})
+	}
+}
+
+func TestSimpleMap_Conformance(t *testing.T) {
+	kvstoretest.RunMapStoreSuite(t,
+		func(t *testing.T) kvstore.MapStore { return NewSimpleMap() },
+		kvstoretest.WithKeyNotFoundError(ErrKVStoreKeyNotFound),
+		kvstoretest.WithEmptyKeyError(ErrKVStoreEmptyKey),
+		kvstoretest.WithSharedValues(),
+	)
+}
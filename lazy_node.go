@@ -6,6 +6,9 @@ var _ trieNode = (*lazyNode)(nil)
 // lazyNode represents an uncached persisted node
 type lazyNode struct {
 	digest []byte
+	// The depth of the node in the trie, used to check the consistency of
+	// the node once resolved
+	depth int
 }
 
 // Persisted satisfied the trieNode#Persisted interface
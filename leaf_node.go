@@ -9,6 +9,7 @@ type leafNode struct {
 	valueHash []byte
 	persisted bool
 	digest    []byte
+	accessed  uint64
 }
 
 // Persisted satisfied the trieNode#Persisted interface
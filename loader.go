@@ -0,0 +1,257 @@
+package smt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// Ensure the slice iterator satisfies the LeafIterator interface
+var _ LeafIterator = (*sliceLeafIterator)(nil)
+
+// BulkLeaf is a single leaf consumed by the bulk loader.
+type BulkLeaf struct {
+	// Path of the leaf in the trie (i.e. the digest of its key)
+	Path []byte
+	// ValueHash is the digest of the value stored in the leaf
+	ValueHash []byte
+	// Weight of the leaf, only used when building a sum trie
+	Weight uint64
+}
+
+// LeafIterator provides the leaves consumed by the bulk loader. The leaves
+// MUST be returned in strictly increasing order of their paths.
+type LeafIterator interface {
+	// Next advances the iterator to the next leaf, returning false once the
+	// iterator is exhausted or an error was encountered
+	Next() bool
+	// Leaf returns the leaf the iterator currently points to
+	Leaf() BulkLeaf
+	// Err returns the error, if any, encountered during iteration
+	Err() error
+}
+
+// sliceLeafIterator is a LeafIterator over an in-memory slice of leaves
+type sliceLeafIterator struct {
+	leaves []BulkLeaf
+	idx    int
+}
+
+// NewSliceLeafIterator returns a LeafIterator over the leaves provided, which
+// must already be sorted by path.
+func NewSliceLeafIterator(leaves []BulkLeaf) LeafIterator {
+	return &sliceLeafIterator{leaves: leaves, idx: -1}
+}
+
+// Next satisfies the LeafIterator#Next interface
+func (it *sliceLeafIterator) Next() bool {
+	if it.idx+1 >= len(it.leaves) {
+		return false
+	}
+	it.idx++
+	return true
+}
+
+// Leaf satisfies the LeafIterator#Leaf interface
+func (it *sliceLeafIterator) Leaf() BulkLeaf { return it.leaves[it.idx] }
+
+// Err satisfies the LeafIterator#Err interface
+func (it *sliceLeafIterator) Err() error { return nil }
+
+// BuildSparseMerkleTrie builds a new SMT from the sorted leaves provided,
+// writing its nodes directly into the node store, and returns the root hash of
+// the trie. The trie can then be loaded with `ImportSparseMerkleTrie`.
+//
+// Unlike inserting the leaves one by one with `Update`, the trie is built
+// bottom-up while streaming the leaves, so only the nodes along the right-most
+// path of the trie are held in memory at any given time.
+func BuildSparseMerkleTrie(
+	nodes kvstore.MapStore,
+	hasher hash.Hash,
+	leaves LeafIterator,
+	options ...TrieSpecOption,
+) (MerkleRoot, error) {
+	spec := NewTrieSpec(hasher, false, options...)
+	root, err := buildTrie(&spec, nodes, leaves)
+	if err != nil {
+		return nil, err
+	}
+	return MerkleRoot(root), nil
+}
+
+// BuildSparseMerkleSumTrie builds a new SMST from the sorted leaves provided,
+// writing its nodes directly into the node store, and returns the root hash of
+// the trie. The trie can then be loaded with `ImportSparseMerkleSumTrie`.
+//
+// See `BuildSparseMerkleTrie` for more details.
+func BuildSparseMerkleSumTrie(
+	nodes kvstore.MapStore,
+	hasher hash.Hash,
+	leaves LeafIterator,
+	options ...TrieSpecOption,
+) (MerkleSumRoot, error) {
+	spec := NewTrieSpec(hasher, true, options...)
+	root, err := buildTrie(&spec, nodes, leaves)
+	if err != nil {
+		return nil, err
+	}
+	return MerkleSumRoot(root), nil
+}
+
+// buildTrie streams all the leaves into a trie builder and returns the digest
+// of the resulting trie's root
+func buildTrie(spec *TrieSpec, nodes kvstore.MapStore, leaves LeafIterator) ([]byte, error) {
+	builder := &trieBuilder{spec: spec, nodes: nodes}
+	if err := builder.addLeaves(leaves); err != nil {
+		return nil, err
+	}
+	top, err := builder.finish()
+	if err != nil {
+		return nil, err
+	}
+	if top == nil {
+		return spec.placeholder(), nil
+	}
+	return builder.wrap(top, 0)
+}
+
+// builtSubtrie is the root of a subtrie whose nodes have already been
+// written to the node store.
+type builtSubtrie struct {
+	// The digest of the root node of the subtrie
+	digest []byte
+	// The path of the right-most leaf in the subtrie, used both to order the
+	// subtries and to encode extension nodes above them
+	path []byte
+	// The depth of the inner node at the root of the subtrie, or -1 if the
+	// subtrie is a single leaf
+	depth int
+}
+
+// pendingBranch is a subtrie waiting for its right sibling to be built before
+// both of them can be joined by an inner node at the given depth.
+type pendingBranch struct {
+	depth int
+	left  *builtSubtrie
+}
+
+// trieBuilder builds a trie bottom-up from subtries (usually single leaves)
+// provided in order of their paths. It only holds the pending left siblings
+// along the right-most path of the trie, so its memory usage is bounded by
+// the depth of the trie rather than by the number of leaves.
+type trieBuilder struct {
+	spec    *TrieSpec
+	nodes   kvstore.MapStore
+	pending []pendingBranch
+	current *builtSubtrie
+}
+
+// addLeaves writes every leaf provided by the iterator to the node store and
+// adds it to the trie being built
+func (b *trieBuilder) addLeaves(leaves LeafIterator) error {
+	for leaves.Next() {
+		leaf := leaves.Leaf()
+		if len(leaf.Path) != b.spec.ph.PathSize() {
+			return ErrInvalidLeafPath
+		}
+		value := leaf.ValueHash
+		if b.spec.sumTrie {
+			var weightBz [sumSizeBytes]byte
+			binary.BigEndian.PutUint64(weightBz[:], leaf.Weight)
+			var countBz [countSizeBytes]byte
+			binary.BigEndian.PutUint64(countBz[:], 1)
+			value = make([]byte, 0, len(leaf.ValueHash)+sumSizeBytes+countSizeBytes)
+			value = append(value, leaf.ValueHash...)
+			value = append(value, weightBz[:]...)
+			value = append(value, countBz[:]...)
+		}
+		path := make([]byte, len(leaf.Path))
+		copy(path, leaf.Path)
+		digest, preimage := b.spec.digestLeaf(path, value)
+		if err := b.spec.storeSet(b.nodes, digest, preimage); err != nil {
+			return err
+		}
+		if err := b.add(&builtSubtrie{digest: digest, path: path, depth: -1}); err != nil {
+			return err
+		}
+	}
+	return leaves.Err()
+}
+
+// add appends the subtrie provided to the right of all the subtries added so
+// far, joining any pending subtries that branch below the point at which the
+// new subtrie diverges from its left neighbour.
+func (b *trieBuilder) add(sub *builtSubtrie) (err error) {
+	if b.current == nil {
+		b.current = sub
+		return nil
+	}
+	if bytes.Compare(b.current.path, sub.path) >= 0 {
+		return ErrUnsortedLeaves
+	}
+	// The depth at which the new subtrie branches off from its left neighbour
+	branchDepth := countCommonPrefixBits(b.current.path, sub.path, 0)
+	for len(b.pending) > 0 && b.pending[len(b.pending)-1].depth > branchDepth {
+		last := b.pending[len(b.pending)-1]
+		b.pending = b.pending[:len(b.pending)-1]
+		if b.current, err = b.branch(last.depth, last.left, b.current); err != nil {
+			return err
+		}
+	}
+	b.pending = append(b.pending, pendingBranch{depth: branchDepth, left: b.current})
+	b.current = sub
+	return nil
+}
+
+// finish joins all the pending subtries and returns the top of the trie, or
+// nil if no subtries were added
+func (b *trieBuilder) finish() (top *builtSubtrie, err error) {
+	for len(b.pending) > 0 {
+		last := b.pending[len(b.pending)-1]
+		b.pending = b.pending[:len(b.pending)-1]
+		if b.current, err = b.branch(last.depth, last.left, b.current); err != nil {
+			return nil, err
+		}
+	}
+	top, b.current = b.current, nil
+	return top, nil
+}
+
+// branch writes the inner node at the given depth joining the left and right
+// subtries provided, and returns the resulting subtrie
+func (b *trieBuilder) branch(depth int, left, right *builtSubtrie) (*builtSubtrie, error) {
+	leftDigest, err := b.wrap(left, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	rightDigest, err := b.wrap(right, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	digest, preimage := b.spec.digestInnerNode(leftDigest, rightDigest)
+	if err := b.spec.storeSet(b.nodes, digest, preimage); err != nil {
+		return nil, err
+	}
+	return &builtSubtrie{digest: digest, path: right.path, depth: depth}, nil
+}
+
+// wrap returns the digest of the subtrie provided when placed at the given
+// depth. If the subtrie's root is an inner node deeper than that, an extension
+// node covering the gap is written to the node store and its digest returned.
+func (b *trieBuilder) wrap(sub *builtSubtrie, depth int) ([]byte, error) {
+	if sub.depth < 0 || sub.depth == depth {
+		return sub.digest, nil
+	}
+	ext := &extensionNode{
+		path:       sub.path,
+		pathBounds: [2]byte{byte(depth), byte(sub.depth)},
+		child:      &lazyNode{digest: sub.digest, depth: sub.depth},
+	}
+	digest := b.spec.digest(ext)
+	if err := b.spec.storeSet(b.nodes, digest, b.spec.encode(ext)); err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
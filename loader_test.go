@@ -0,0 +1,115 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+func TestBuildSparseMerkleTrie(t *testing.T) {
+	for _, numLeaves := range []int{0, 1, 2, 3, 100, 1000} {
+		keys, values := randomBatch(t, numLeaves, int64(numLeaves))
+
+		expected := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+		require.NoError(t, expected.UpdateBatch(keys, values))
+
+		leaves := make([]BulkLeaf, len(keys))
+		for i := range keys {
+			leaves[i] = BulkLeaf{
+				Path:      expected.ph.Path(keys[i]),
+				ValueHash: expected.valueHash(values[i]),
+			}
+		}
+		sortBulkLeaves(leaves)
+
+		nodes := simplemap.NewSimpleMap()
+		root, err := BuildSparseMerkleTrie(nodes, sha256.New(), NewSliceLeafIterator(leaves))
+		require.NoError(t, err)
+		require.Equal(t, expected.Root(), root)
+
+		// The trie must be fully usable once imported from the node store
+		smt := ImportSparseMerkleTrie(nodes, sha256.New(), root)
+		for i := range keys {
+			value, err := smt.Get(keys[i])
+			require.NoError(t, err)
+			require.Equal(t, smt.valueHash(values[i]), value)
+
+			proof, err := smt.Prove(keys[i])
+			require.NoError(t, err)
+			valid, err := VerifyProof(proof, root, keys[i], values[i], smt.Spec())
+			require.NoError(t, err)
+			require.True(t, valid)
+		}
+		require.NoError(t, smt.Update([]byte("new key"), []byte("new value")))
+		require.NoError(t, expected.Update([]byte("new key"), []byte("new value")))
+		require.Equal(t, expected.Root(), smt.Root())
+	}
+}
+
+func TestBuildSparseMerkleSumTrie(t *testing.T) {
+	keys, values := randomBatch(t, 500, 42)
+	weights := make([]uint64, len(keys))
+	for i := range weights {
+		weights[i] = uint64(i * 3)
+	}
+
+	expected := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, expected.UpdateBatch(keys, values, weights))
+
+	leaves := make([]BulkLeaf, len(keys))
+	for i := range keys {
+		leaves[i] = BulkLeaf{
+			Path:      expected.ph.Path(keys[i]),
+			ValueHash: expected.valueHash(values[i]),
+			Weight:    weights[i],
+		}
+	}
+	sortBulkLeaves(leaves)
+
+	nodes := simplemap.NewSimpleMap()
+	root, err := BuildSparseMerkleSumTrie(nodes, sha256.New(), NewSliceLeafIterator(leaves))
+	require.NoError(t, err)
+	require.Equal(t, expected.Root(), root)
+	require.Equal(t, expected.MustSum(), root.MustSum())
+	require.Equal(t, uint64(len(keys)), root.MustCount())
+
+	smst := ImportSparseMerkleSumTrie(nodes, sha256.New(), root)
+	for i := range keys {
+		valueHash, weight, err := smst.Get(keys[i])
+		require.NoError(t, err)
+		require.Equal(t, smst.valueHash(values[i]), valueHash)
+		require.Equal(t, weights[i], weight)
+	}
+}
+
+func TestBuildSparseMerkleTrie_InvalidLeaves(t *testing.T) {
+	spec := NewTrieSpec(sha256.New(), false)
+	a, b := spec.ph.Path([]byte("a")), spec.ph.Path([]byte("b"))
+	if bytes.Compare(a, b) > 0 {
+		a, b = b, a
+	}
+
+	unsorted := NewSliceLeafIterator([]BulkLeaf{{Path: b}, {Path: a}})
+	_, err := BuildSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), unsorted)
+	require.ErrorIs(t, err, ErrUnsortedLeaves)
+
+	duplicates := NewSliceLeafIterator([]BulkLeaf{{Path: a}, {Path: a}})
+	_, err = BuildSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), duplicates)
+	require.ErrorIs(t, err, ErrUnsortedLeaves)
+
+	short := NewSliceLeafIterator([]BulkLeaf{{Path: a[:8]}})
+	_, err = BuildSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), short)
+	require.ErrorIs(t, err, ErrInvalidLeafPath)
+}
+
+// sortBulkLeaves sorts the leaves provided by path
+func sortBulkLeaves(leaves []BulkLeaf) {
+	sort.Slice(leaves, func(i, j int) bool {
+		return bytes.Compare(leaves[i].Path, leaves[j].Path) < 0
+	})
+}
@@ -0,0 +1,242 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// importTrie returns a new trie reading the nodes of the test trie directly
+// from its node store
+func (tt *verifyingTestTrie) importTrie() *SMT {
+	if tt.spec.sumTrie {
+		return ImportSparseMerkleSumTrie(tt.nodes, sha256.New(), tt.root, tt.opts...).SMT
+	}
+	return ImportSparseMerkleTrie(tt.nodes, sha256.New(), tt.root, tt.opts...)
+}
+
+// operationErrors runs every operation of the trie for each of its keys, each
+// on a newly imported trie, and returns the errors returned by any of them
+func (tt *verifyingTestTrie) operationErrors(t *testing.T) []error {
+	t.Helper()
+	var errs []error
+	record := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, key := range tt.keys {
+		_, err := tt.importTrie().Get(key)
+		record(err)
+		_, err = tt.importTrie().Prove(key)
+		record(err)
+		_, err = tt.importTrie().ProveClosest(tt.spec.ph.Path(key))
+		record(err)
+		record(tt.importTrie().Update(key, []byte("updated")))
+		record(tt.importTrie().Delete(key))
+		_, err = tt.importTrie().GetMany([][]byte{key})
+		record(err)
+	}
+	return errs
+}
+
+// requireNodeErrors checks that the node stored under the digest provided is
+// reported by the operations of the trie, and only that node
+func requireNodeErrors(t *testing.T, tt *verifyingTestTrie, digest []byte, target error) []error {
+	t.Helper()
+	errs := tt.operationErrors(t)
+	require.NotEmpty(t, errs)
+	for _, err := range errs {
+		// Corrupt paths can lead a deletion to a different leaf
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		var nodeErr *NodeError
+		require.ErrorAs(t, err, &nodeErr)
+		require.Equal(t, digest, nodeErr.Digest)
+		require.ErrorIs(t, err, target)
+	}
+	return errs
+}
+
+func TestNodeError_MalformedRecords(t *testing.T) {
+	malformations := map[string]func(spec *TrieSpec, data []byte) []byte{
+		"empty": func(*TrieSpec, []byte) []byte {
+			return []byte{}
+		},
+		"truncated": func(_ *TrieSpec, data []byte) []byte {
+			return data[:prefixLen+1]
+		},
+		"unknown prefix": func(_ *TrieSpec, data []byte) []byte {
+			bad := append([]byte{}, data...)
+			bad[0] = 0xff
+			return bad
+		},
+		"trailing byte": func(_ *TrieSpec, data []byte) []byte {
+			// Leaves of an SMT can hold values of any length
+			if isLeafNode(data) {
+				return nil
+			}
+			return append(append([]byte{}, data...), 0)
+		},
+		"leaf count": func(spec *TrieSpec, data []byte) []byte {
+			if !spec.sumTrie || !isLeafNode(data) {
+				return nil
+			}
+			bad := append([]byte{}, data...)
+			binary.BigEndian.PutUint64(bad[len(bad)-countSizeBytes:], 2)
+			return bad
+		},
+	}
+
+	for _, sumTrie := range []bool{false, true} {
+		for name, malform := range malformations {
+			t.Run(fmt.Sprintf("sumTrie=%t/%s", sumTrie, name), func(t *testing.T) {
+				tt := newVerifyingTestTrie(t, sumTrie)
+				digests, nodes := tt.storedNodes(t)
+				for i, digest := range digests {
+					bad := malform(tt.spec, nodes[i])
+					if bad == nil {
+						continue
+					}
+					require.NoError(t, tt.nodes.Set(digest, bad))
+					requireNodeErrors(t, tt, digest, ErrInvalidNodeEncoding)
+					require.NoError(t, tt.nodes.Set(digest, nodes[i]))
+				}
+			})
+		}
+	}
+}
+
+func TestNodeError_MissingNode(t *testing.T) {
+	for _, sumTrie := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sumTrie=%t", sumTrie), func(t *testing.T) {
+			tt := newVerifyingTestTrie(t, sumTrie)
+			digests, nodes := tt.storedNodes(t)
+			for i, digest := range digests {
+				require.NoError(t, tt.nodes.Delete(digest))
+				for _, err := range requireNodeErrors(t, tt, digest, ErrMissingNode) {
+					// The error of the node store is preserved
+					if !errors.Is(err, ErrKeyNotFound) {
+						require.ErrorIs(t, err, kvstore.ErrKeyNotFound)
+						require.NotErrorIs(t, err, ErrCorruptNode)
+					}
+				}
+				require.NoError(t, tt.nodes.Set(digest, nodes[i]))
+			}
+		})
+	}
+}
+
+func TestNodeError_InconsistentExtensionNodes(t *testing.T) {
+	tt := newVerifyingTestTrie(t, false)
+	digests, nodes := tt.storedNodes(t)
+	pathSize := tt.spec.ph.PathSize()
+	childIdx := prefixLen + 2 + pathSize
+
+	var leafDigest []byte
+	for i, data := range nodes {
+		if isLeafNode(data) {
+			leafDigest = digests[i]
+			break
+		}
+	}
+
+	extensions := 0
+	for i, digest := range digests {
+		data := nodes[i]
+		if !isExtNode(data) {
+			continue
+		}
+		extensions++
+
+		// An extension node which does not start at its depth in the trie
+		start, end := data[prefixLen], data[prefixLen+1]
+		if start+1 < end {
+			bad := append([]byte{}, data...)
+			bad[prefixLen]++
+			require.True(t, tt.spec.validEncoding(bad))
+			require.NoError(t, tt.nodes.Set(digest, bad))
+			for _, err := range requireNodeErrors(t, tt, digest, ErrCorruptNode) {
+				require.NotErrorIs(t, err, ErrInvalidNodeEncoding)
+			}
+		}
+
+		// An extension node whose child is a leaf
+		bad := append([]byte{}, data...)
+		copy(bad[childIdx:], leafDigest)
+		require.True(t, tt.spec.validEncoding(bad))
+		require.NoError(t, tt.nodes.Set(digest, bad))
+		_, err := tt.importTrie().Get(tt.keys[0])
+		if err != nil {
+			var nodeErr *NodeError
+			require.ErrorAs(t, err, &nodeErr)
+			require.Equal(t, digest, nodeErr.Digest)
+			require.ErrorIs(t, err, ErrCorruptNode)
+		}
+		tt.operationErrors(t)
+
+		require.NoError(t, tt.nodes.Set(digest, data))
+	}
+	require.NotZero(t, extensions)
+}
+
+func TestNodeError_SumLeafValue(t *testing.T) {
+	_, _, err := decodeSumLeafValue([]byte{1, 2, 3})
+	require.ErrorIs(t, err, ErrInvalidNodeEncoding)
+
+	value := make([]byte, sha256.Size+sumSizeBytes+countSizeBytes)
+	binary.BigEndian.PutUint64(value[len(value)-countSizeBytes:], 3)
+	_, _, err = decodeSumLeafValue(value)
+	require.ErrorIs(t, err, ErrInvalidNodeEncoding)
+
+	// Values inserted through the SMT of an SMST are reported by its getters
+	smst := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, smst.SMT.Update([]byte("key"), []byte("raw")))
+	_, _, err = smst.Get([]byte("key"))
+	require.ErrorIs(t, err, ErrInvalidNodeEncoding)
+	_, _, err = smst.GetMany([][]byte{[]byte("key")})
+	require.ErrorIs(t, err, ErrInvalidNodeEncoding)
+}
+
+func TestNodeError_InvalidDepth(t *testing.T) {
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(), WithPathHasher(newNilPathHasher(64)))
+	// Push the leaf of the zero path below the deepest extension node bounds
+	zero := make([]byte, 64)
+	require.NoError(t, trie.Update(zero, []byte("zero")))
+	for i := 0; i <= 0xff+1; i++ {
+		path := make([]byte, 64)
+		flipPathBit(path, i)
+		require.NoError(t, trie.Update(path, []byte("value")))
+	}
+	root := trie.Root()
+
+	// A path sharing a longer prefix with it would require an extension node
+	// starting below that depth
+	path := make([]byte, 64)
+	flipPathBit(path, 400)
+	require.ErrorIs(t, trie.Update(path, []byte("value")), ErrInvalidDepth)
+	require.Equal(t, root, trie.Root())
+
+	// The same applies to batches inserted below the leaf
+	other := make([]byte, 64)
+	flipPathBit(other, 400)
+	flipPathBit(other, 401)
+	err := trie.UpdateBatch([][]byte{path, other}, [][]byte{[]byte("value"), []byte("value")})
+	require.ErrorIs(t, err, ErrInvalidDepth)
+	require.Equal(t, root, trie.Root())
+}
+
+func TestNodeError_Error(t *testing.T) {
+	err := error(&NodeError{Digest: []byte{0xab, 0xcd}, Err: ErrInvalidNodeEncoding})
+	require.Equal(t, "node abcd: corrupt node: invalid encoding", err.Error())
+	require.ErrorIs(t, err, ErrCorruptNode)
+	require.ErrorIs(t, fmt.Errorf("wrapped: %w", err), ErrInvalidNodeEncoding)
+}
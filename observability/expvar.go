@@ -0,0 +1,112 @@
+package observability
+
+import (
+	"expvar"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore/instrumented"
+)
+
+// Ensure the ExpvarObserver can observe both tries and instrumented stores
+var (
+	_ smt.TrieObserver      = (*ExpvarObserver)(nil)
+	_ instrumented.Observer = (*ExpvarObserver)(nil)
+)
+
+// ExpvarObserver counts the events it observes in an expvar.Map. Durations
+// are accumulated in nanoseconds under the keys ending with "_ns", so that
+// mean latencies can be derived from the matching counts.
+//
+// The following counters are maintained for tries:
+//
+//	node_resolutions, node_resolution_errors, node_resolution_ns
+//	store_reads, store_read_bytes, store_read_ns
+//	store_writes, store_write_bytes, store_deletes, store_write_ns
+//	store_errors
+//	commits, commit_errors, commit_nodes_written, commit_orphans_deleted, commit_ns
+//	proofs, proof_errors, proof_ns
+//
+// and for every operation of instrumented stores (get, set, delete, len and
+// clear_all):
+//
+//	kvstore_<op>, kvstore_<op>_errors, kvstore_<op>_bytes, kvstore_<op>_ns
+type ExpvarObserver struct {
+	vars *expvar.Map
+}
+
+// NewExpvarObserver creates a new ExpvarObserver counting events in the map
+// provided, e.g. one published with expvar.NewMap("smt").
+func NewExpvarObserver(vars *expvar.Map) *ExpvarObserver {
+	return &ExpvarObserver{vars: vars}
+}
+
+// NodeResolved satisfies the smt.TrieObserver#NodeResolved interface
+func (o *ExpvarObserver) NodeResolved(event smt.NodeResolvedEvent) {
+	o.vars.Add("node_resolutions", 1)
+	o.vars.Add("node_resolution_ns", int64(event.Duration))
+	if event.Err != nil {
+		o.vars.Add("node_resolution_errors", 1)
+	}
+}
+
+// StoreRead satisfies the smt.TrieObserver#StoreRead interface
+func (o *ExpvarObserver) StoreRead(event smt.StoreEvent) {
+	o.vars.Add("store_reads", 1)
+	o.vars.Add("store_read_bytes", int64(event.Size))
+	o.vars.Add("store_read_ns", int64(event.Duration))
+	if event.Err != nil {
+		o.vars.Add("store_errors", 1)
+	}
+}
+
+// StoreWrite satisfies the smt.TrieObserver#StoreWrite interface
+func (o *ExpvarObserver) StoreWrite(event smt.StoreEvent) {
+	if event.Op == smt.StoreOpDelete {
+		o.vars.Add("store_deletes", 1)
+	} else {
+		o.vars.Add("store_writes", 1)
+		o.vars.Add("store_write_bytes", int64(event.Size))
+	}
+	o.vars.Add("store_write_ns", int64(event.Duration))
+	if event.Err != nil {
+		o.vars.Add("store_errors", 1)
+	}
+}
+
+// Committed satisfies the smt.TrieObserver#Committed interface
+func (o *ExpvarObserver) Committed(event smt.CommitEvent) {
+	o.vars.Add("commits", 1)
+	o.vars.Add("commit_nodes_written", int64(event.NodesWritten))
+	o.vars.Add("commit_orphans_deleted", int64(event.OrphansDeleted))
+	o.vars.Add("commit_ns", int64(event.Duration))
+	if event.Err != nil {
+		o.vars.Add("commit_errors", 1)
+	}
+}
+
+// ProofGenerated satisfies the smt.TrieObserver#ProofGenerated interface
+func (o *ExpvarObserver) ProofGenerated(event smt.ProofEvent) {
+	o.vars.Add("proofs", 1)
+	o.vars.Add("proof_ns", int64(event.Duration))
+	if event.Err != nil {
+		o.vars.Add("proof_errors", 1)
+	}
+}
+
+// StoreOperation satisfies the instrumented.Observer#StoreOperation interface
+func (o *ExpvarObserver) StoreOperation(event instrumented.Event) {
+	prefix := "kvstore_" + string(event.Op)
+	o.vars.Add(prefix, 1)
+	o.vars.Add(prefix+"_bytes", int64(event.Bytes))
+	o.vars.Add(prefix+"_ns", int64(event.Duration))
+	if event.Err != nil {
+		o.vars.Add(prefix+"_errors", 1)
+	}
+}
+
+// PublishStats publishes the statistics of an instrumented store under the
+// name provided, computed whenever the variable is read. Like expvar.Publish,
+// it panics if the name is already in use.
+func PublishStats(name string, store instrumented.InstrumentedKVStore) {
+	expvar.Publish(name, expvar.Func(func() any { return store.Stats() }))
+}
@@ -0,0 +1,5 @@
+// Package observability provides adapters exporting the events of the trie
+// observer (smt.TrieObserver) and of the instrumented node store
+// (instrumented.Observer) through the standard library: structured logs with
+// log/slog and metrics with expvar.
+package observability
@@ -0,0 +1,108 @@
+package observability_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore/instrumented"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+	"github.com/pokt-network/smt/observability"
+)
+
+func TestSlogObserver(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	observer := observability.NewSlogObserver(logger, slog.LevelDebug)
+
+	faulty := kvstoretest.NewFaultyMapStore(simplemap.NewSimpleMap())
+	nodes, err := instrumented.NewKVStore(faulty, instrumented.WithObserver(observer))
+	require.NoError(t, err)
+	trie := smt.NewSparseMerkleTrie(nodes, sha256.New(), smt.WithObserver(observer))
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Commit())
+	root := trie.Root()
+	_, err = trie.Prove([]byte("foo"))
+	require.NoError(t, err)
+	faulty.FailAfter(kvstoretest.OpSet, 0)
+	require.NoError(t, trie.Update([]byte("baz"), []byte("qux")))
+	require.Error(t, trie.Commit())
+
+	records := make([]map[string]any, 0)
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record))
+		records = append(records, record)
+	}
+	messages := make(map[string]int)
+	for _, record := range records {
+		messages[record["msg"].(string)]++
+	}
+	require.Equal(t, map[string]int{
+		"kvstore operation":   2,
+		"smt store write":     2,
+		"smt commit":          2,
+		"smt proof generated": 1,
+	}, messages)
+
+	// The first commit succeeds, the second fails
+	require.Equal(t, "DEBUG", records[2]["level"])
+	require.Equal(t, float64(1), records[2]["nodes_written"])
+	require.Equal(t, hex.EncodeToString(root), records[2]["root"])
+	last := records[len(records)-1]
+	require.Equal(t, "smt commit", last["msg"])
+	require.Equal(t, "ERROR", last["level"])
+	require.Contains(t, last["error"], kvstoretest.ErrInjectedFault.Error())
+}
+
+func TestExpvarObserver(t *testing.T) {
+	vars := new(expvar.Map).Init()
+	observer := observability.NewExpvarObserver(vars)
+
+	nodes, err := instrumented.NewKVStore(simplemap.NewSimpleMap(), instrumented.WithObserver(observer))
+	require.NoError(t, err)
+	trie := smt.NewSparseMerkleTrie(nodes, sha256.New(), smt.WithObserver(observer))
+	require.NoError(t, trie.Update([]byte("foo"), []byte("bar")))
+	require.NoError(t, trie.Update([]byte("baz"), []byte("qux")))
+	require.NoError(t, trie.Commit())
+	require.NoError(t, trie.Delete([]byte("baz")))
+	require.NoError(t, trie.Commit())
+
+	imported := smt.ImportSparseMerkleTrie(nodes, sha256.New(), trie.Root(), smt.WithObserver(observer))
+	_, err = imported.Prove([]byte("foo"))
+	require.NoError(t, err)
+
+	counter := func(key string) int64 {
+		t.Helper()
+		v, ok := vars.Get(key).(*expvar.Int)
+		require.True(t, ok, key)
+		return v.Value()
+	}
+	require.Equal(t, int64(2), counter("commits"))
+	// Two leaves and their parent are written, then the deleted leaf and the
+	// parent are orphaned, leaving the remaining leaf as the root
+	require.Equal(t, int64(3), counter("commit_nodes_written"))
+	require.Equal(t, int64(2), counter("commit_orphans_deleted"))
+	require.Equal(t, counter("commit_nodes_written"), counter("store_writes"))
+	require.Equal(t, counter("commit_orphans_deleted"), counter("store_deletes"))
+	require.Equal(t, int64(1), counter("proofs"))
+	require.Equal(t, int64(1), counter("node_resolutions"))
+	require.Equal(t, int64(1), counter("store_reads"))
+	require.Equal(t, counter("store_writes"), counter("kvstore_set"))
+	require.Equal(t, counter("store_write_bytes"), counter("kvstore_set_bytes"))
+	require.Equal(t, counter("store_reads"), counter("kvstore_get"))
+	require.Nil(t, vars.Get("commit_errors"))
+
+	observability.PublishStats("smt_test_nodes", nodes)
+	var stats map[string]map[string]any
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("smt_test_nodes").String()), &stats))
+	require.Equal(t, float64(counter("kvstore_set")), stats["set"]["Count"])
+}
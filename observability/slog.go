@@ -0,0 +1,100 @@
+package observability
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore/instrumented"
+)
+
+// Ensure the SlogObserver can observe both tries and instrumented stores
+var (
+	_ smt.TrieObserver      = (*SlogObserver)(nil)
+	_ instrumented.Observer = (*SlogObserver)(nil)
+)
+
+// SlogObserver logs every event it observes with a slog.Logger. Successful
+// events are logged at the level of the observer, while failures are always
+// logged at the error level.
+type SlogObserver struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// NewSlogObserver creates a new SlogObserver logging successful events with
+// the logger provided at the given level, e.g. slog.LevelDebug.
+func NewSlogObserver(logger *slog.Logger, level slog.Level) *SlogObserver {
+	return &SlogObserver{logger: logger, level: level}
+}
+
+// NodeResolved satisfies the smt.TrieObserver#NodeResolved interface
+func (o *SlogObserver) NodeResolved(event smt.NodeResolvedEvent) {
+	o.log("smt node resolved", event.Err,
+		slog.String("digest", hex.EncodeToString(event.Digest)),
+		slog.String("type", string(event.Type)),
+		slog.Duration("duration", event.Duration),
+	)
+}
+
+// StoreRead satisfies the smt.TrieObserver#StoreRead interface
+func (o *SlogObserver) StoreRead(event smt.StoreEvent) {
+	o.logStoreEvent("smt store read", event)
+}
+
+// StoreWrite satisfies the smt.TrieObserver#StoreWrite interface
+func (o *SlogObserver) StoreWrite(event smt.StoreEvent) {
+	o.logStoreEvent("smt store write", event)
+}
+
+// Committed satisfies the smt.TrieObserver#Committed interface
+func (o *SlogObserver) Committed(event smt.CommitEvent) {
+	o.log("smt commit", event.Err,
+		slog.String("root", hex.EncodeToString(event.Root)),
+		slog.Int("nodes_written", event.NodesWritten),
+		slog.Int("orphans_deleted", event.OrphansDeleted),
+		slog.Duration("duration", event.Duration),
+	)
+}
+
+// ProofGenerated satisfies the smt.TrieObserver#ProofGenerated interface
+func (o *SlogObserver) ProofGenerated(event smt.ProofEvent) {
+	o.log("smt proof generated", event.Err,
+		slog.String("type", string(event.Type)),
+		slog.String("path", hex.EncodeToString(event.Path)),
+		slog.Int("side_nodes", event.SideNodes),
+		slog.Duration("duration", event.Duration),
+	)
+}
+
+// StoreOperation satisfies the instrumented.Observer#StoreOperation interface
+func (o *SlogObserver) StoreOperation(event instrumented.Event) {
+	o.log("kvstore operation", event.Err,
+		slog.String("op", string(event.Op)),
+		slog.String("key", hex.EncodeToString(event.Key)),
+		slog.Int("bytes", event.Bytes),
+		slog.Duration("duration", event.Duration),
+	)
+}
+
+// logStoreEvent logs an operation made by a trie on its node store
+func (o *SlogObserver) logStoreEvent(msg string, event smt.StoreEvent) {
+	o.log(msg, event.Err,
+		slog.String("op", string(event.Op)),
+		slog.String("key", hex.EncodeToString(event.Key)),
+		slog.Int("size", event.Size),
+		slog.Duration("duration", event.Duration),
+	)
+}
+
+// log logs an event at the level of the observer, or at the error level along
+// with the error provided if it is not nil
+func (o *SlogObserver) log(msg string, err error, attrs ...slog.Attr) {
+	level := o.level
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	o.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
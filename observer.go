@@ -0,0 +1,219 @@
+package smt
+
+import (
+	"time"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+// TrieObserver receives events describing the work done by a trie, e.g. to
+// record metrics, log or trace its operations. It is installed with the
+// WithObserver option.
+//
+// The methods of the observer are called synchronously by the trie and must
+// not call back into it. The byte slices of the events must not be modified
+// and are only valid for the duration of the call.
+type TrieObserver interface {
+	// NodeResolved is called when a node is resolved from the node store
+	NodeResolved(event NodeResolvedEvent)
+	// StoreRead is called after every read from the node store
+	StoreRead(event StoreEvent)
+	// StoreWrite is called after every write to, or deletion from, the node
+	// store
+	StoreWrite(event StoreEvent)
+	// Committed is called at the end of every commit
+	Committed(event CommitEvent)
+	// ProofGenerated is called at the end of every proof generation
+	ProofGenerated(event ProofEvent)
+}
+
+// NodeType identifies the type of a trie node
+type NodeType string
+
+const (
+	// NodeTypeLeaf identifies leaf nodes
+	NodeTypeLeaf NodeType = "leaf"
+	// NodeTypeInner identifies inner nodes
+	NodeTypeInner NodeType = "inner"
+	// NodeTypeExtension identifies extension nodes
+	NodeTypeExtension NodeType = "extension"
+)
+
+// StoreOp identifies an operation on the node store
+type StoreOp string
+
+const (
+	// StoreOpGet identifies reads from the node store
+	StoreOpGet StoreOp = "get"
+	// StoreOpSet identifies writes to the node store
+	StoreOpSet StoreOp = "set"
+	// StoreOpDelete identifies deletions from the node store
+	StoreOpDelete StoreOp = "delete"
+)
+
+// ProofType identifies the method used to generate a proof
+type ProofType string
+
+const (
+	// ProofTypeProve identifies proofs generated by Prove
+	ProofTypeProve ProofType = "prove"
+	// ProofTypeClosest identifies proofs generated by ProveClosest
+	ProofTypeClosest ProofType = "closest"
+)
+
+// NodeResolvedEvent describes the resolution of a node from the node store
+type NodeResolvedEvent struct {
+	// Digest is the digest of the node
+	Digest []byte
+	// Type is the type of the node resolved, empty on failure
+	Type NodeType
+	// Duration is the time taken to read and parse the node
+	Duration time.Duration
+	// Err is the error which caused the resolution to fail, if any
+	Err error
+}
+
+// StoreEvent describes an operation on the node store
+type StoreEvent struct {
+	// Op is the operation performed
+	Op StoreOp
+	// Key is the key of the operation, i.e. the digest of a node
+	Key []byte
+	// Size is the size of the value read or written, zero for deletions
+	Size int
+	// Duration is the time taken by the node store
+	Duration time.Duration
+	// Err is the error returned by the node store, if any
+	Err error
+}
+
+// CommitEvent describes a commit of the trie
+type CommitEvent struct {
+	// Root is the root hash of the trie after the commit, nil on failure
+	Root []byte
+	// NodesWritten is the number of dirty nodes written to the node store,
+	// including those written ahead of the commit since the previous one
+	// because of `WithMaxDirtyNodes`
+	NodesWritten int
+	// OrphansDeleted is the number of orphaned nodes deleted from the node
+	// store
+	OrphansDeleted int
+	// Duration is the time taken by the commit
+	Duration time.Duration
+	// Err is the error which caused the commit to fail, if any
+	Err error
+}
+
+// ProofEvent describes the generation of a proof
+type ProofEvent struct {
+	// Type is the method used to generate the proof
+	Type ProofType
+	// Path is the path the proof was generated for
+	Path []byte
+	// SideNodes is the number of side nodes of the proof
+	SideNodes int
+	// Duration is the time taken to generate the proof
+	Duration time.Duration
+	// Err is the error which caused the proof generation to fail, if any
+	Err error
+}
+
+// NoopTrieObserver implements the TrieObserver interface ignoring every
+// event. It can be embedded by observers only interested in some events.
+type NoopTrieObserver struct{}
+
+var _ TrieObserver = NoopTrieObserver{}
+
+// NodeResolved satisfies the TrieObserver#NodeResolved interface
+func (NoopTrieObserver) NodeResolved(NodeResolvedEvent) {}
+
+// StoreRead satisfies the TrieObserver#StoreRead interface
+func (NoopTrieObserver) StoreRead(StoreEvent) {}
+
+// StoreWrite satisfies the TrieObserver#StoreWrite interface
+func (NoopTrieObserver) StoreWrite(StoreEvent) {}
+
+// Committed satisfies the TrieObserver#Committed interface
+func (NoopTrieObserver) Committed(CommitEvent) {}
+
+// ProofGenerated satisfies the TrieObserver#ProofGenerated interface
+func (NoopTrieObserver) ProofGenerated(ProofEvent) {}
+
+// storeGet reads a node from the node store, notifying the observer if any
+func (spec *TrieSpec) storeGet(nodes kvstore.MapStore, key []byte) ([]byte, error) {
+	if spec.observer == nil {
+		return nodes.Get(key)
+	}
+	start := time.Now()
+	value, err := nodes.Get(key)
+	spec.observer.StoreRead(StoreEvent{
+		Op:       StoreOpGet,
+		Key:      key,
+		Size:     len(value),
+		Duration: time.Since(start),
+		Err:      err,
+	})
+	return value, err
+}
+
+// storeSet writes a node to the node store, notifying the observer if any
+func (spec *TrieSpec) storeSet(nodes kvstore.MapStore, key, value []byte) error {
+	if spec.observer == nil {
+		return nodes.Set(key, value)
+	}
+	start := time.Now()
+	err := nodes.Set(key, value)
+	spec.observer.StoreWrite(StoreEvent{
+		Op:       StoreOpSet,
+		Key:      key,
+		Size:     len(value),
+		Duration: time.Since(start),
+		Err:      err,
+	})
+	return err
+}
+
+// storeDelete deletes a node from the node store, notifying the observer if
+// any
+func (spec *TrieSpec) storeDelete(nodes kvstore.MapStore, key []byte) error {
+	if spec.observer == nil {
+		return nodes.Delete(key)
+	}
+	start := time.Now()
+	err := nodes.Delete(key)
+	spec.observer.StoreWrite(StoreEvent{
+		Op:       StoreOpDelete,
+		Key:      key,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+	return err
+}
+
+// nodeType returns the type of a node, or an empty type for empty subtries
+// and lazy nodes
+func nodeType(node trieNode) NodeType {
+	switch node.(type) {
+	case *leafNode:
+		return NodeTypeLeaf
+	case *innerNode:
+		return NodeTypeInner
+	case *extensionNode:
+		return NodeTypeExtension
+	}
+	return ""
+}
+
+// observeProof notifies the observer of the generation of a proof
+func (smt *SMT) observeProof(proofType ProofType, path []byte, start time.Time, proof *SparseMerkleProof, err error) {
+	event := ProofEvent{
+		Type:     proofType,
+		Path:     path,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if proof != nil && err == nil {
+		event.SideNodes = len(proof.SideNodes)
+	}
+	smt.observer.ProofGenerated(event)
+}
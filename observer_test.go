@@ -0,0 +1,187 @@
+package smt_test
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt"
+	"github.com/pokt-network/smt/kvstore/kvstoretest"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// recordingObserver records every event it observes
+type recordingObserver struct {
+	resolutions []smt.NodeResolvedEvent
+	reads       []smt.StoreEvent
+	writes      []smt.StoreEvent
+	commits     []smt.CommitEvent
+	proofs      []smt.ProofEvent
+}
+
+func (o *recordingObserver) NodeResolved(event smt.NodeResolvedEvent) {
+	o.resolutions = append(o.resolutions, event)
+}
+
+func (o *recordingObserver) StoreRead(event smt.StoreEvent) { o.reads = append(o.reads, event) }
+
+func (o *recordingObserver) StoreWrite(event smt.StoreEvent) { o.writes = append(o.writes, event) }
+
+func (o *recordingObserver) Committed(event smt.CommitEvent) { o.commits = append(o.commits, event) }
+
+func (o *recordingObserver) ProofGenerated(event smt.ProofEvent) { o.proofs = append(o.proofs, event) }
+
+func TestSMT_Observer(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	observer := &recordingObserver{}
+	trie := smt.NewSparseMerkleTrie(nodes, sha256.New(), smt.WithObserver(observer))
+	for i := 0; i < faultTestKeys; i++ {
+		require.NoError(t, trie.Update(faultTestKey(i), []byte("value")))
+	}
+	require.NoError(t, trie.Commit())
+
+	// Every node of the trie is written by the first commit
+	stored, err := nodes.Len()
+	require.NoError(t, err)
+	require.Len(t, observer.commits, 1)
+	commit := observer.commits[0]
+	require.NoError(t, commit.Err)
+	require.Equal(t, stored, commit.NodesWritten)
+	require.Zero(t, commit.OrphansDeleted)
+	require.Equal(t, []byte(trie.Root()), commit.Root)
+	require.Len(t, observer.writes, stored)
+	for _, write := range observer.writes {
+		require.Equal(t, smt.StoreOpSet, write.Op)
+		value, err := nodes.Get(write.Key)
+		require.NoError(t, err)
+		require.Len(t, value, write.Size)
+	}
+
+	// Updating a key orphans the nodes along its path
+	require.NoError(t, trie.Update(faultTestKey(0), []byte("other")))
+	require.NoError(t, trie.Commit())
+	require.Len(t, observer.commits, 2)
+	commit = observer.commits[1]
+	require.Positive(t, commit.OrphansDeleted)
+	require.Equal(t, commit.OrphansDeleted, commit.NodesWritten)
+
+	// Imported tries resolve their nodes from the store
+	observer = &recordingObserver{}
+	trie = smt.ImportSparseMerkleTrie(nodes, sha256.New(), trie.Root(), smt.WithObserver(observer))
+	proof, err := trie.Prove(faultTestKey(1))
+	require.NoError(t, err)
+	require.NotEmpty(t, observer.resolutions)
+	require.Len(t, observer.reads, len(observer.resolutions))
+	for i, resolution := range observer.resolutions {
+		require.NoError(t, resolution.Err)
+		require.NotEmpty(t, resolution.Type)
+		require.Equal(t, observer.reads[i].Key, resolution.Digest)
+		require.Equal(t, smt.StoreOpGet, observer.reads[i].Op)
+	}
+	path := sha256.Sum256(faultTestKey(1))
+	require.Equal(t, []smt.ProofEvent{{
+		Type:      smt.ProofTypeProve,
+		Path:      path[:],
+		SideNodes: len(proof.SideNodes),
+		Duration:  observer.proofs[0].Duration,
+	}}, observer.proofs)
+
+	closestPath := sha256.Sum256([]byte("path"))
+	closest, err := trie.ProveClosest(closestPath[:])
+	require.NoError(t, err)
+	require.Len(t, observer.proofs, 2)
+	require.Equal(t, smt.ProofTypeClosest, observer.proofs[1].Type)
+	require.Equal(t, len(closest.ClosestProof.SideNodes), observer.proofs[1].SideNodes)
+}
+
+func TestSMST_Observer(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	observer := &recordingObserver{}
+	trie := smt.NewSparseMerkleSumTrie(nodes, sha256.New(), smt.WithObserver(observer))
+	for i := 0; i < faultTestKeys; i++ {
+		require.NoError(t, trie.Update(faultTestKey(i), []byte("value"), uint64(i)))
+	}
+	require.NoError(t, trie.Commit())
+
+	stored, err := nodes.Len()
+	require.NoError(t, err)
+	require.Len(t, observer.commits, 1)
+	require.NoError(t, observer.commits[0].Err)
+	require.Equal(t, stored, observer.commits[0].NodesWritten)
+	require.Equal(t, []byte(trie.Root()), observer.commits[0].Root)
+	require.Len(t, observer.writes, stored)
+	require.Equal(t, stored, observer.storeSets())
+
+	observer = &recordingObserver{}
+	trie = smt.ImportSparseMerkleSumTrie(nodes, sha256.New(), trie.Root(), smt.WithObserver(observer))
+	_, err = trie.Prove(faultTestKey(1))
+	require.NoError(t, err)
+	require.NotEmpty(t, observer.resolutions)
+	require.Len(t, observer.reads, len(observer.resolutions))
+	for i, resolution := range observer.resolutions {
+		require.NoError(t, resolution.Err)
+		require.Equal(t, observer.reads[i].Key, resolution.Digest)
+	}
+	require.Len(t, observer.proofs, 1)
+}
+
+// storeSets returns the number of `Set` operations observed
+func (o *recordingObserver) storeSets() int {
+	sets := 0
+	for _, write := range o.writes {
+		if write.Op == smt.StoreOpSet {
+			sets++
+		}
+	}
+	return sets
+}
+
+func TestSMT_Observer_DirtyFlush(t *testing.T) {
+	observer := &recordingObserver{}
+	trie := smt.NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New(),
+		smt.WithObserver(observer), smt.WithMaxDirtyNodes(8))
+	for i := 0; i < faultTestKeys; i++ {
+		require.NoError(t, trie.Update(faultTestKey(i), []byte("value")))
+	}
+	// Some nodes were written ahead of the commit
+	flushed := observer.storeSets()
+	require.Positive(t, flushed)
+	require.NoError(t, trie.Commit())
+
+	// Every commit reports the nodes written since the previous one
+	require.Len(t, observer.commits, 1)
+	require.Greater(t, observer.commits[0].NodesWritten, observer.storeSets()-flushed)
+	require.Equal(t, observer.storeSets(), observer.commits[0].NodesWritten)
+
+	for i := 0; i < faultTestKeys; i++ {
+		require.NoError(t, trie.Update(faultTestKey(i), []byte("other")))
+	}
+	require.NoError(t, trie.Commit())
+	require.Len(t, observer.commits, 2)
+	require.Equal(t, observer.storeSets(), observer.commits[0].NodesWritten+observer.commits[1].NodesWritten)
+}
+
+func TestSMT_Observer_Errors(t *testing.T) {
+	store, root := newFaultyTrieStore(t)
+	observer := &recordingObserver{}
+	trie := smt.ImportSparseMerkleTrie(store, sha256.New(), root, smt.WithObserver(observer))
+
+	store.FailAfter(kvstoretest.OpGet, 0)
+	_, err := trie.Prove(faultTestKey(0))
+	require.ErrorIs(t, err, kvstoretest.ErrInjectedFault)
+	require.Len(t, observer.resolutions, 1)
+	require.ErrorIs(t, observer.resolutions[0].Err, kvstoretest.ErrInjectedFault)
+	require.Empty(t, observer.resolutions[0].Type)
+	require.Len(t, observer.proofs, 1)
+	require.ErrorIs(t, observer.proofs[0].Err, kvstoretest.ErrInjectedFault)
+
+	store.Reset()
+	require.NoError(t, trie.Update(faultTestKey(0), []byte("other")))
+	store.FailAfter(kvstoretest.OpSet, 2)
+	require.ErrorIs(t, trie.Commit(), kvstoretest.ErrInjectedFault)
+	require.Len(t, observer.commits, 1)
+	require.ErrorIs(t, observer.commits[0].Err, kvstoretest.ErrInjectedFault)
+	require.Nil(t, observer.commits[0].Root)
+	require.Equal(t, 2, observer.commits[0].NodesWritten)
+}
@@ -13,3 +13,29 @@ func WithPathHasher(ph PathHasher) TrieSpecOption {
 func WithValueHasher(vh ValueHasher) TrieSpecOption {
 	return func(ts *TrieSpec) { ts.vh = vh }
 }
+
+// WithMaxCachedNodes returns an Option that bounds the number of clean nodes
+// resolved from the node store which the trie keeps in memory. Once the bound
+// is exceeded, the least recently accessed clean subtries are evicted and
+// lazily resolved again when needed. A value of zero (the default) disables
+// eviction.
+func WithMaxCachedNodes(n int) TrieSpecOption {
+	return func(ts *TrieSpec) { ts.maxCachedNodes = n }
+}
+
+// WithMaxDirtyNodes returns an Option that bounds the number of dirty nodes
+// the trie keeps in memory. Once the bound is exceeded, all dirty nodes are
+// written to the node store by the next mutation, ahead of the next Commit.
+// Orphaned nodes are still only deleted by Commit, so the last committed trie
+// remains intact in the node store. A value of zero (the default) disables
+// early flushing.
+func WithMaxDirtyNodes(n int) TrieSpecOption {
+	return func(ts *TrieSpec) { ts.maxDirtyNodes = n }
+}
+
+// WithObserver returns an Option that installs an observer notified of the
+// node resolutions, node store operations, commits and proof generations of
+// the trie. See TrieObserver for details.
+func WithObserver(observer TrieObserver) TrieSpecOption {
+	return func(ts *TrieSpec) { ts.observer = observer }
+}
@@ -0,0 +1,292 @@
+package smt
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// defaultRenderDigestSize is the default number of bytes of the digests and
+// paths shown when rendering a trie
+const defaultRenderDigestSize = 4
+
+// RenderOption configures `WriteDOT` and `WriteASCII`
+type RenderOption func(*renderConfig)
+
+type renderConfig struct {
+	prefix     []byte
+	prefixBits int
+	resolve    bool
+	digestSize int
+}
+
+// WithRenderPrefix only renders the subtrie holding the paths whose first
+// bits match the ones of the path provided
+func WithRenderPrefix(path []byte, bits int) RenderOption {
+	return func(cfg *renderConfig) {
+		cfg.prefix = path
+		cfg.prefixBits = bits
+	}
+}
+
+// WithRenderResolve reads the nodes which are not held in memory from the node
+// store to render them, without caching them in the trie. By default they are
+// rendered as lazy nodes.
+func WithRenderResolve() RenderOption {
+	return func(cfg *renderConfig) { cfg.resolve = true }
+}
+
+// WithRenderDigestSize sets the number of bytes of the digests and leaf paths
+// shown, 4 by default. Negative sizes are treated as zero.
+func WithRenderDigestSize(size int) RenderOption {
+	return func(cfg *renderConfig) { cfg.digestSize = size }
+}
+
+// renderedNode is a node of the trie as rendered, nil for an empty subtrie
+type renderedNode struct {
+	// The type of the node, or "lazy" for a node which was not resolved from
+	// the node store
+	nodeType  string
+	digest    string
+	persisted bool
+	// The properties specific to the type of the node
+	details  []string
+	children []renderedEdge
+}
+
+// renderedEdge links a rendered node to one of its children
+type renderedEdge struct {
+	// The bit of the path leading to the child of an inner node, empty for
+	// the child of an extension node
+	label string
+	child *renderedNode
+}
+
+// WriteDOT renders the trie, or the subtrie selected with
+// `WithRenderPrefix`, as a Graphviz DOT digraph. Dirty nodes are drawn in red
+// and lazy nodes with a dotted outline.
+func (smt *SMT) WriteDOT(w io.Writer, opts ...RenderOption) error {
+	root, err := smt.renderTrie(opts)
+	if err != nil {
+		return err
+	}
+	out := &renderWriter{w: w}
+	out.printf("digraph trie {\n")
+	out.printf("\tnode [fontname=\"monospace\"];\n")
+	ids := 0
+	var write func(node *renderedNode) int
+	write = func(node *renderedNode) int {
+		id := ids
+		ids++
+		if node == nil {
+			out.printf("\tn%d [label=\"empty\" shape=point];\n", id)
+			return id
+		}
+		label := append([]string{node.nodeType, node.digest, persistedState(node.persisted)}, node.details...)
+		out.printf("\tn%d [label=%q shape=%s%s];\n", id, strings.Join(label, "\n"), dotShape(node.nodeType), dotStyle(node))
+		for _, edge := range node.children {
+			child := write(edge.child)
+			if edge.label == "" {
+				out.printf("\tn%d -> n%d;\n", id, child)
+			} else {
+				out.printf("\tn%d -> n%d [label=%q];\n", id, child, edge.label)
+			}
+		}
+		return id
+	}
+	write(root)
+	out.printf("}\n")
+	return out.err
+}
+
+// WriteASCII renders the trie, or the subtrie selected with
+// `WithRenderPrefix`, as an indented tree with one node per line.
+func (smt *SMT) WriteASCII(w io.Writer, opts ...RenderOption) error {
+	root, err := smt.renderTrie(opts)
+	if err != nil {
+		return err
+	}
+	out := &renderWriter{w: w}
+	var write func(node *renderedNode, indent string)
+	write = func(node *renderedNode, indent string) {
+		if node == nil {
+			out.printf("empty\n")
+			return
+		}
+		line := append([]string{node.nodeType, node.digest, persistedState(node.persisted)}, node.details...)
+		out.printf("%s\n", strings.Join(line, " "))
+		for i, edge := range node.children {
+			branch, next := "|-- ", "|   "
+			if i == len(node.children)-1 {
+				branch, next = "`-- ", "    "
+			}
+			label := ""
+			if edge.label != "" {
+				label = edge.label + ": "
+			}
+			out.printf("%s%s%s", indent, branch, label)
+			write(edge.child, indent+next)
+		}
+	}
+	write(root, "")
+	return out.err
+}
+
+// renderTrie returns the rendering of the trie, or of the subtrie selected by
+// the options provided
+func (smt *SMT) renderTrie(opts []RenderOption) (*renderedNode, error) {
+	cfg := renderConfig{digestSize: defaultRenderDigestSize}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	// A negative digest size would be out of the bounds of the slices
+	cfg.digestSize = max(cfg.digestSize, 0)
+	bits := min(cfg.prefixBits, len(cfg.prefix)*8)
+	node, depth, err := smt.findPrefix(cfg.prefix, bits)
+	if err != nil {
+		return nil, err
+	}
+	return smt.renderNode(node, depth, &cfg)
+}
+
+// findPrefix returns the root of the subtrie holding the paths whose first
+// bits match the ones of the path provided, along with its depth. The nodes
+// read from the node store along the way are not cached.
+func (smt *SMT) findPrefix(path []byte, bits int) (trieNode, int, error) {
+	node, depth := smt.root, 0
+	for depth < bits {
+		var err error
+		if node, err = smt.readNode(node); err != nil {
+			return nil, 0, err
+		}
+		switch n := node.(type) {
+		case *leafNode:
+			if match, _ := equalPrefixBits(n.path, path, depth, bits); !match {
+				return nil, depth, nil
+			}
+			return n, depth, nil
+		case *extensionNode:
+			end := min(n.pathEnd(), bits)
+			if match, _ := equalPrefixBits(n.path, path, depth, end); !match {
+				return nil, depth, nil
+			}
+			if end < n.pathEnd() {
+				return n, depth, nil
+			}
+			node, depth = n.child, n.pathEnd()
+		case *innerNode:
+			if getPathBit(path, depth) == leftChildBit {
+				node = n.leftChild
+			} else {
+				node = n.rightChild
+			}
+			depth++
+		default:
+			return nil, depth, nil
+		}
+	}
+	return node, depth, nil
+}
+
+// renderNode renders the subtrie found at the given depth
+func (smt *SMT) renderNode(node trieNode, depth int, cfg *renderConfig) (*renderedNode, error) {
+	if _, ok := node.(*lazyNode); ok && cfg.resolve {
+		var err error
+		if node, err = smt.readNode(node); err != nil {
+			return nil, err
+		}
+	}
+	if node == nil {
+		return nil, nil
+	}
+	digest := smt.digest(node)
+	rendered := &renderedNode{
+		digest:    hex.EncodeToString(digest[:min(cfg.digestSize, smt.th.hashSize())]),
+		persisted: node.Persisted(),
+	}
+
+	switch n := node.(type) {
+	case *lazyNode:
+		rendered.nodeType = "lazy"
+	case *leafNode:
+		rendered.nodeType = string(NodeTypeLeaf)
+		rendered.details = append(rendered.details,
+			"path="+hex.EncodeToString(n.path[:min(cfg.digestSize, len(n.path))]))
+	case *extensionNode:
+		rendered.nodeType = string(NodeTypeExtension)
+		var bits strings.Builder
+		for i := n.pathStart(); i < n.pathEnd(); i++ {
+			fmt.Fprintf(&bits, "%d", getPathBit(n.path, i))
+		}
+		rendered.details = append(rendered.details,
+			fmt.Sprintf("bounds=[%d,%d)", n.pathStart(), n.pathEnd()), "bits="+bits.String())
+		child, err := smt.renderNode(n.child, n.pathEnd(), cfg)
+		if err != nil {
+			return nil, err
+		}
+		rendered.children = []renderedEdge{{child: child}}
+	case *innerNode:
+		rendered.nodeType = string(NodeTypeInner)
+		left, err := smt.renderNode(n.leftChild, depth+1, cfg)
+		if err != nil {
+			return nil, err
+		}
+		right, err := smt.renderNode(n.rightChild, depth+1, cfg)
+		if err != nil {
+			return nil, err
+		}
+		rendered.children = []renderedEdge{{label: "0", child: left}, {label: "1", child: right}}
+	}
+	if smt.sumTrie {
+		sum := MerkleSumRoot(digest)
+		rendered.details = append(rendered.details,
+			fmt.Sprintf("sum=%d", sum.sum()), fmt.Sprintf("count=%d", sum.count()))
+	}
+	return rendered, nil
+}
+
+// persistedState describes whether a node was written to the node store
+func persistedState(persisted bool) string {
+	if persisted {
+		return "persisted"
+	}
+	return "dirty"
+}
+
+// dotShape returns the Graphviz shape of the nodes of the given type
+func dotShape(nodeType string) string {
+	switch nodeType {
+	case string(NodeTypeInner):
+		return "ellipse"
+	case string(NodeTypeLeaf):
+		return "note"
+	}
+	return "box"
+}
+
+// dotStyle returns the Graphviz attributes showing the state of the node
+func dotStyle(node *renderedNode) string {
+	switch {
+	case node.nodeType == "lazy":
+		return " style=dotted"
+	case !node.persisted:
+		return " color=red"
+	}
+	return ""
+}
+
+// renderWriter writes formatted output, keeping the first error returned by
+// the underlying writer
+type renderWriter struct {
+	w   io.Writer
+	err error
+}
+
+// printf writes the formatted output unless a write failed before
+func (out *renderWriter) printf(format string, args ...any) {
+	if out.err != nil {
+		return
+	}
+	_, out.err = fmt.Fprintf(out.w, format, args...)
+}
@@ -0,0 +1,179 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// newRenderTestTrie returns a sum trie with one byte paths holding two
+// committed leaves below an extension node, and a dirty leaf
+func newRenderTestTrie(t *testing.T) *SMST {
+	t.Helper()
+	trie := NewSparseMerkleSumTrie(simplemap.NewSimpleMap(), sha256.New(), WithPathHasher(newNilPathHasher(1)))
+	require.NoError(t, trie.Update([]byte{0b00000000}, []byte("a"), 1))
+	require.NoError(t, trie.Update([]byte{0b00001000}, []byte("b"), 2))
+	require.NoError(t, trie.Commit())
+	require.NoError(t, trie.Update([]byte{0b10000000}, []byte("c"), 3))
+	return trie
+}
+
+// renderASCII returns the lines of the ASCII rendering of the trie
+func renderASCII(t *testing.T, trie *SMT, opts ...RenderOption) []string {
+	t.Helper()
+	var out strings.Builder
+	require.NoError(t, trie.WriteASCII(&out, opts...))
+	return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+}
+
+// requireLines checks that every line starts with the corresponding prefix
+// and ends with the corresponding properties
+func requireLines(t *testing.T, lines, prefixes, properties []string) {
+	t.Helper()
+	require.Len(t, lines, len(prefixes), strings.Join(lines, "\n"))
+	for i, line := range lines {
+		require.True(t, strings.HasPrefix(line, prefixes[i]), line)
+		require.True(t, strings.HasSuffix(line, properties[i]), line)
+	}
+}
+
+func TestWriteASCII(t *testing.T) {
+	trie := newRenderTestTrie(t)
+	root := []byte(trie.Root())
+	requireLines(t, renderASCII(t, trie.SMT), []string{
+		"inner " + hex.EncodeToString(root[:4]),
+		"|-- 0: extension ",
+		"|   `-- inner ",
+		"|       |-- 0: leaf ",
+		"|       `-- 1: leaf ",
+		"`-- 1: leaf ",
+	}, []string{
+		" dirty sum=6 count=3",
+		" dirty bounds=[1,4) bits=000 sum=3 count=2",
+		" persisted sum=3 count=2",
+		" persisted path=00 sum=1 count=1",
+		" persisted path=08 sum=2 count=1",
+		" dirty path=80 sum=3 count=1",
+	})
+
+	lines := renderASCII(t, trie.SMT, WithRenderDigestSize(8))
+	require.True(t, strings.HasPrefix(lines[0], "inner "+hex.EncodeToString(root[:8])+" "))
+
+	// Negative digest sizes hide the digests and paths
+	lines = renderASCII(t, trie.SMT, WithRenderDigestSize(-1))
+	require.True(t, strings.HasPrefix(lines[0], "inner  dirty "), lines[0])
+	require.True(t, strings.HasSuffix(lines[5], " dirty path= sum=3 count=1"), lines[5])
+}
+
+func TestWriteASCII_LazyNodes(t *testing.T) {
+	trie := newRenderTestTrie(t)
+	require.NoError(t, trie.Commit())
+	imported := ImportSparseMerkleSumTrie(trie.nodes, sha256.New(), trie.Root(), WithPathHasher(newNilPathHasher(1)))
+
+	requireLines(t, renderASCII(t, imported.SMT), []string{"lazy "}, []string{" persisted sum=6 count=3"})
+	lines := renderASCII(t, imported.SMT, WithRenderResolve())
+	requireLines(t, lines, []string{
+		"inner ",
+		"|-- 0: extension ",
+		"|   `-- inner ",
+		"|       |-- 0: leaf ",
+		"|       `-- 1: leaf ",
+		"`-- 1: leaf ",
+	}, []string{
+		" persisted sum=6 count=3",
+		" persisted bounds=[1,4) bits=000 sum=3 count=2",
+		" persisted sum=3 count=2",
+		" persisted path=00 sum=1 count=1",
+		" persisted path=08 sum=2 count=1",
+		" persisted path=80 sum=3 count=1",
+	})
+	// The nodes read from the node store are not cached
+	require.True(t, isLazyNode(imported.root))
+
+	// Missing nodes are reported when resolved
+	require.NoError(t, trie.nodes.Delete(trie.Root()))
+	err := imported.WriteASCII(&strings.Builder{}, WithRenderResolve())
+	require.ErrorIs(t, err, ErrMissingNode)
+}
+
+func TestWriteASCII_Prefix(t *testing.T) {
+	trie := newRenderTestTrie(t)
+
+	// A prefix ending within an extension node renders it entirely
+	requireLines(t, renderASCII(t, trie.SMT, WithRenderPrefix([]byte{0b00000000}, 2)), []string{
+		"extension ",
+		"`-- inner ",
+		"    |-- 0: leaf ",
+		"    `-- 1: leaf ",
+	}, []string{
+		" bounds=[1,4) bits=000 sum=3 count=2",
+		" sum=3 count=2",
+		" path=00 sum=1 count=1",
+		" path=08 sum=2 count=1",
+	})
+
+	// Prefixes leading below the extension node or to a leaf
+	requireLines(t, renderASCII(t, trie.SMT, WithRenderPrefix([]byte{0b00001000}, 5)),
+		[]string{"leaf "}, []string{" path=08 sum=2 count=1"})
+	requireLines(t, renderASCII(t, trie.SMT, WithRenderPrefix([]byte{0b10000001}, 7)),
+		[]string{"leaf "}, []string{" path=80 sum=3 count=1"})
+
+	// A prefix ending with an extension node renders its child
+	lines := renderASCII(t, trie.SMT, WithRenderPrefix([]byte{0b00001111}, 4))
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasPrefix(lines[0], "inner "), lines[0])
+
+	// Prefixes which do not match any leaf render an empty subtrie
+	for _, prefix := range [][]byte{{0b01000000}, {0b00100000}, {0b11000000}, {0b10100000}} {
+		require.Equal(t, []string{"empty"}, renderASCII(t, trie.SMT, WithRenderPrefix(prefix, 3)))
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	trie := newRenderTestTrie(t)
+	var out strings.Builder
+	require.NoError(t, trie.WriteDOT(&out))
+	dot := out.String()
+
+	require.True(t, strings.HasPrefix(dot, "digraph trie {\n"))
+	require.True(t, strings.HasSuffix(dot, "}\n"))
+	require.Equal(t, 6, strings.Count(dot, " shape="))
+	require.Equal(t, 5, strings.Count(dot, " -> "))
+	require.Equal(t, 2, strings.Count(dot, "shape=ellipse"))
+	require.Equal(t, 1, strings.Count(dot, "shape=box"))
+	require.Equal(t, 3, strings.Count(dot, "shape=note"))
+	require.Equal(t, 3, strings.Count(dot, "color=red"))
+	require.Contains(t, dot, `\nbounds=[1,4)\nbits=000\nsum=3\ncount=2" shape=box color=red];`)
+	require.Contains(t, dot, `n0 -> n1 [label="0"];`)
+
+	// Empty subtries are rendered as points and lazy nodes with a dotted outline
+	out.Reset()
+	empty := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	require.NoError(t, empty.WriteDOT(&out))
+	require.Contains(t, out.String(), `n0 [label="empty" shape=point];`)
+
+	out.Reset()
+	require.NoError(t, trie.Commit())
+	imported := ImportSparseMerkleSumTrie(trie.nodes, sha256.New(), trie.Root(), WithPathHasher(newNilPathHasher(1)))
+	require.NoError(t, imported.WriteDOT(&out))
+	require.Contains(t, out.String(), "shape=box style=dotted];")
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+var errFailingWrite = errors.New("write failed")
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errFailingWrite }
+
+func TestRender_WriteError(t *testing.T) {
+	trie := newRenderTestTrie(t)
+	require.ErrorIs(t, trie.WriteASCII(failingWriter{}), errFailingWrite)
+	require.ErrorIs(t, trie.WriteDOT(failingWriter{}), errFailingWrite)
+}
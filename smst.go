@@ -2,6 +2,7 @@ package smt
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"hash"
@@ -25,7 +26,13 @@ const (
 	countSizeBytes = 8
 )
 
-var _ SparseMerkleSumTrie = (*SMST)(nil)
+var (
+	_ SparseMerkleSumTrie = (*SMST)(nil)
+	_ BatchSumTrie        = (*SMST)(nil)
+	_ SubscribableTrie    = (*SMST)(nil)
+	_ ContextSumTrie      = (*SMST)(nil)
+	_ StatsTrie           = (*SMST)(nil)
+)
 
 // SMST is an object wrapping a Sparse Merkle Trie for custom encoding
 type SMST struct {
@@ -57,6 +64,10 @@ func NewSparseMerkleSumTrie(
 		ph:      trieSpec.ph,
 		vh:      trieSpec.vh,
 		sumTrie: trieSpec.sumTrie,
+
+		maxCachedNodes: trieSpec.maxCachedNodes,
+		maxDirtyNodes:  trieSpec.maxDirtyNodes,
+		observer:       trieSpec.observer,
 	}
 	smt := &SMT{
 		TrieSpec: smtSpec,
@@ -70,6 +81,10 @@ func NewSparseMerkleSumTrie(
 		ph:      trieSpec.ph,
 		vh:      trieSpec.vh,
 		sumTrie: trieSpec.sumTrie,
+
+		maxCachedNodes: trieSpec.maxCachedNodes,
+		maxDirtyNodes:  trieSpec.maxDirtyNodes,
+		observer:       trieSpec.observer,
 	}
 	return &SMST{
 		TrieSpec: smstSpec,
@@ -85,7 +100,7 @@ func ImportSparseMerkleSumTrie(
 	options ...TrieSpecOption,
 ) *SMST {
 	smst := NewSparseMerkleSumTrie(nodes, hasher, options...)
-	smst.root = &lazyNode{root}
+	smst.root = &lazyNode{digest: root}
 	smst.rootHash = root
 	return smst
 }
@@ -104,32 +119,27 @@ func (smst *SMST) Get(key []byte) (valueDigest []byte, weight uint64, err error)
 		return nil, 0, err
 	}
 
-	// Check if it is an empty branch
-	if bytes.Equal(value, defaultEmptyValue) {
-		return defaultEmptyValue, 0, nil
-	}
-
-	firstSumByteIdx, firstCountByteIdx := getFirstMetaByteIdx(value)
-
-	// Extract the value digest only
-	valueDigest = value[:firstSumByteIdx]
-
-	// Retrieve the node weight
-	var weightBz [sumSizeBytes]byte
-	copy(weightBz[:], value[firstSumByteIdx:firstCountByteIdx])
-	weight = binary.BigEndian.Uint64(weightBz[:])
-
-	// Retrieve the number of non-empty nodes in the sub trie
-	var countBz [countSizeBytes]byte
-	copy(countBz[:], value[firstCountByteIdx:])
-	count := binary.BigEndian.Uint64(countBz[:])
+	return decodeSumLeafValue(value)
+}
 
-	if count != 1 {
-		panic("count for leaf node should always be 1")
+// GetMany retrieves the value digests for the given keys, along with their
+// weights, in the same order as the keys provided. Keys that are not present
+// in the trie have the default placeholder values.
+func (smst *SMST) GetMany(keys [][]byte) (valueDigests [][]byte, weights []uint64, err error) {
+	values, err := smst.SMT.GetMany(keys)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Return the value digest and weight
-	return valueDigest, weight, nil
+	valueDigests = make([][]byte, len(values))
+	weights = make([]uint64, len(values))
+	for i, value := range values {
+		valueDigests[i], weights[i], err = decodeSumLeafValue(value)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return valueDigests, weights, nil
 }
 
 // Update inserts the value and weight into the trie for the given key.
@@ -140,21 +150,21 @@ func (smst *SMST) Get(key []byte) (valueDigest []byte, weight uint64, err error)
 // The weight is used to compute the interim sum of the node which then percolates
 // up to the total sum of the trie.
 func (smst *SMST) Update(key, value []byte, weight uint64) error {
-	// Convert the node weight to a byte slice
-	var weightBz [sumSizeBytes]byte
-	binary.BigEndian.PutUint64(weightBz[:], weight)
-
-	// Convert the node count (1 for a single leaf) to a byte slice
-	var countBz [countSizeBytes]byte
-	binary.BigEndian.PutUint64(countBz[:], 1)
-
-	// Compute the digest of the value and append the weight to it
-	valueDigest := smst.valueHash(value)
-	valueDigest = append(valueDigest, weightBz[:]...)
-	valueDigest = append(valueDigest, countBz[:]...)
-
 	// Return the result of the trie update
-	return smst.SMT.Update(key, valueDigest)
+	return smst.SMT.Update(key, smst.encodeSumLeafValue(value, weight))
+}
+
+// UpdateBatch inserts the values and weights into the trie for the given keys
+// in a single descent of the trie. See `SMT.UpdateBatch` for more details.
+func (smst *SMST) UpdateBatch(keys, values [][]byte, weights []uint64) error {
+	if len(keys) != len(values) || len(keys) != len(weights) {
+		return ErrBatchLengthMismatch
+	}
+	valueDigests := make([][]byte, len(values))
+	for i := range values {
+		valueDigests[i] = smst.encodeSumLeafValue(values[i], weights[i])
+	}
+	return smst.SMT.UpdateBatch(keys, valueDigests)
 }
 
 // Delete removes the node at the path corresponding to the given key
@@ -162,6 +172,12 @@ func (smst *SMST) Delete(key []byte) error {
 	return smst.SMT.Delete(key)
 }
 
+// DeleteBatch removes the nodes at the paths corresponding to the given keys
+// in a single descent of the trie
+func (smst *SMST) DeleteBatch(keys [][]byte) error {
+	return smst.SMT.DeleteBatch(keys)
+}
+
 // Prove generates a SparseMerkleProof for the given key
 func (smst *SMST) Prove(key []byte) (*SparseMerkleProof, error) {
 	return smst.SMT.Prove(key)
@@ -182,6 +198,45 @@ func (smst *SMST) Commit() error {
 	return smst.SMT.Commit()
 }
 
+// GetCtx is a variant of Get which can be cancelled with the context provided
+func (smst *SMST) GetCtx(ctx context.Context, key []byte) (valueDigest []byte, weight uint64, err error) {
+	value, err := smst.SMT.GetCtx(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return decodeSumLeafValue(value)
+}
+
+// UpdateCtx is a variant of Update which can be cancelled with the context
+// provided
+func (smst *SMST) UpdateCtx(ctx context.Context, key, value []byte, weight uint64) error {
+	return smst.SMT.UpdateCtx(ctx, key, smst.encodeSumLeafValue(value, weight))
+}
+
+// DeleteCtx is a variant of Delete which can be cancelled with the context
+// provided
+func (smst *SMST) DeleteCtx(ctx context.Context, key []byte) error {
+	return smst.SMT.DeleteCtx(ctx, key)
+}
+
+// ProveCtx is a variant of Prove which can be cancelled with the context
+// provided
+func (smst *SMST) ProveCtx(ctx context.Context, key []byte) (*SparseMerkleProof, error) {
+	return smst.SMT.ProveCtx(ctx, key)
+}
+
+// ProveClosestCtx is a variant of ProveClosest which can be cancelled with
+// the context provided
+func (smst *SMST) ProveClosestCtx(ctx context.Context, path []byte) (*SparseMerkleClosestProof, error) {
+	return smst.SMT.ProveClosestCtx(ctx, path)
+}
+
+// CommitCtx is a variant of Commit which can be cancelled with the context
+// provided
+func (smst *SMST) CommitCtx(ctx context.Context) error {
+	return smst.SMT.CommitCtx(ctx)
+}
+
 // Root returns the root hash of the trie with the total sum bytes appended
 func (smst *SMST) Root() MerkleSumRoot {
 	return MerkleSumRoot(smst.SMT.Root()) // [digest]+[binary sum]+[binary count]
@@ -227,6 +282,60 @@ func (smst *SMST) Count() (uint64, error) {
 	return smst.Root().Count()
 }
 
+// encodeSumLeafValue computes the digest of the value and appends the weight
+// and the count (1 for a single leaf) to it, producing the data stored in a
+// sum trie leaf.
+func (smst *SMST) encodeSumLeafValue(value []byte, weight uint64) []byte {
+	// Convert the node weight to a byte slice
+	var weightBz [sumSizeBytes]byte
+	binary.BigEndian.PutUint64(weightBz[:], weight)
+
+	// Convert the node count (1 for a single leaf) to a byte slice
+	var countBz [countSizeBytes]byte
+	binary.BigEndian.PutUint64(countBz[:], 1)
+
+	// Compute the digest of the value and append the weight to it
+	valueDigest := smst.valueHash(value)
+	valueDigest = append(valueDigest, weightBz[:]...)
+	valueDigest = append(valueDigest, countBz[:]...)
+	return valueDigest
+}
+
+// decodeSumLeafValue splits the data stored in a sum trie leaf into the value
+// digest and its weight. The default placeholder values are returned for an
+// empty branch, and ErrInvalidNodeEncoding if the value was not encoded by a
+// sum trie.
+func decodeSumLeafValue(value []byte) (valueDigest []byte, weight uint64, err error) {
+	// Check if it is an empty branch
+	if bytes.Equal(value, defaultEmptyValue) {
+		return defaultEmptyValue, 0, nil
+	}
+	if len(value) < sumSizeBytes+countSizeBytes {
+		return nil, 0, fmt.Errorf("%w: sum leaf value too short", ErrInvalidNodeEncoding)
+	}
+
+	firstSumByteIdx, firstCountByteIdx := getFirstMetaByteIdx(value)
+
+	// Extract the value digest only
+	valueDigest = value[:firstSumByteIdx]
+
+	// Retrieve the node weight
+	var weightBz [sumSizeBytes]byte
+	copy(weightBz[:], value[firstSumByteIdx:firstCountByteIdx])
+	weight = binary.BigEndian.Uint64(weightBz[:])
+
+	// Retrieve the number of non-empty nodes in the sub trie
+	var countBz [countSizeBytes]byte
+	copy(countBz[:], value[firstCountByteIdx:])
+	count := binary.BigEndian.Uint64(countBz[:])
+
+	if count != 1 {
+		return nil, 0, fmt.Errorf("%w: sum leaf value with a count of %d", ErrInvalidNodeEncoding, count)
+	}
+
+	return valueDigest, weight, nil
+}
+
 // getFirstMetaByteIdx returns the index of the first count byte and the first sum byte
 // in the data slice provided. This is useful metadata when parsing the data
 // of any node in the trie.
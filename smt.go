@@ -2,13 +2,24 @@ package smt
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"hash"
+	"time"
 
 	"github.com/pokt-network/smt/kvstore"
 )
 
-// Ensure the `SMT` struct implements the `SparseMerkleTrie` interface
-var _ SparseMerkleTrie = (*SMT)(nil)
+// Ensure the `SMT` struct implements the `SparseMerkleTrie` interface and the
+// optional interfaces of the tries
+var (
+	_ SparseMerkleTrie = (*SMT)(nil)
+	_ BatchTrie        = (*SMT)(nil)
+	_ SubscribableTrie = (*SMT)(nil)
+	_ ContextTrie      = (*SMT)(nil)
+	_ StatsTrie        = (*SMT)(nil)
+)
 
 // SMT is a Sparse Merkle Trie object that implements the SparseMerkleTrie interface
 type SMT struct {
@@ -21,6 +32,31 @@ type SMT struct {
 	root trieNode
 	// Lists of per-operation orphan sets
 	orphans []orphanNodes
+	// The digests of the orphans, mapped to false once they were deleted or
+	// a node with the same digest was written again since they were orphaned
+	pendingOrphans map[string]bool
+	// The number of operations performed on the trie, used to track how
+	// recently each node was accessed when evicting cached nodes
+	epoch uint64
+	// Estimated number of persisted nodes resolved from the node store held
+	// in memory since the cached nodes were last counted
+	cachedNodes int
+	// Estimated number of dirty nodes held in memory since the dirty nodes
+	// were last counted
+	dirtyNodes int
+	// The number of dirty nodes written to the node store ahead of the next
+	// commit since the last one
+	flushedNodes int
+	// The functions notified of the leaves changed by every commit, by
+	// subscription ID
+	subscribers      map[uint64]func(CommitChanges)
+	nextSubscriberID uint64
+	// The leaves changed since the last commit, by path, only tracked while
+	// the trie has subscribers
+	pendingChanges map[string]*pendingChange
+	// The context of the current operation, checked before every node store
+	// access, if it was started by one of the ...Ctx methods
+	ctx context.Context
 }
 
 // Hashes of persisted nodes deleted from trie
@@ -52,7 +88,7 @@ func ImportSparseMerkleTrie(
 	options ...TrieSpecOption,
 ) *SMT {
 	smt := NewSparseMerkleTrie(nodes, hasher, options...)
-	smt.root = &lazyNode{root}
+	smt.root = &lazyNode{digest: root}
 	smt.rootHash = root
 	return smt
 }
@@ -71,6 +107,7 @@ func (smt *SMT) Get(key []byte) ([]byte, error) {
 
 	// Loop throughout the entire trie to find the corresponding leaf for the
 	// given key.
+	defer smt.evictCachedNodes()
 	for currNode, depth := &smt.root, 0; ; depth++ {
 		*currNode, err = smt.resolveLazy(*currNode)
 		if err != nil {
@@ -95,6 +132,9 @@ func (smt *SMT) Get(key []byte) ([]byte, error) {
 			if err != nil {
 				return nil, err
 			}
+			if _, ok := (*currNode).(*innerNode); !ok {
+				return nil, smt.invalidExtensionChild(extNode)
+			}
 		}
 		inner := (*currNode).(*innerNode)
 		if getPathBit(path, depth) == leftChildBit {
@@ -117,6 +157,21 @@ func (smt *SMT) Update(key, value []byte) error {
 	// Convert the value into a hash by computing its digest
 	valueHash := smt.valueHash(value)
 
+	// Make room for the dirty nodes of the update before modifying the trie
+	if err := smt.flushDirtyNodes(); err != nil {
+		return err
+	}
+
+	// Resolve the nodes along the path before modifying any of them, so that
+	// a node store error cannot leave the trie partially updated
+	ops := []batchOp{{path: path, value: valueHash}}
+	if err := smt.resolvePaths(&smt.root, 0, ops, false); err != nil {
+		return err
+	}
+	if err := smt.trackChanges(ops); err != nil {
+		return err
+	}
+
 	// Update the trie with the new key-value pair
 	var orphans orphanNodes
 
@@ -127,9 +182,9 @@ func (smt *SMT) Update(key, value []byte) error {
 		return err
 	}
 	smt.root = newRoot
-	if len(orphans) > 0 {
-		smt.orphans = append(smt.orphans, orphans)
-	}
+	smt.recordChanges(ops, false)
+	smt.recordOrphans(orphans)
+	smt.evictCachedNodes()
 	return nil
 }
 
@@ -144,6 +199,7 @@ func (smt *SMT) update(
 	if err != nil {
 		return node, err
 	}
+	smt.dirtyNodes++
 
 	// Copy path to avoid retaining the entire input slice
 	pathCopy := make([]byte, len(path))
@@ -180,7 +236,7 @@ func (smt *SMT) update(
 		last := &node
 		if depth < prefixLen {
 			if depth > 0xff {
-				panic("invalid depth")
+				return node, ErrInvalidDepth
 			}
 			// Copy only the needed portion of path to avoid retaining the entire slice
 			pathCopy := make([]byte, len(path))
@@ -236,15 +292,28 @@ func (smt *SMT) update(
 // Delete removes the node at the path corresponding to the given key
 func (smt *SMT) Delete(key []byte) error {
 	path := smt.ph.Path(key)
+	// Make room for the dirty nodes of the deletion before modifying the trie
+	if err := smt.flushDirtyNodes(); err != nil {
+		return err
+	}
+	// Resolve the nodes along the path and their siblings, which may replace
+	// their parents, before modifying any of them
+	ops := []batchOp{{path: path}}
+	if err := smt.resolvePaths(&smt.root, 0, ops, true); err != nil {
+		return err
+	}
+	if err := smt.trackChanges(ops); err != nil {
+		return err
+	}
 	var orphans orphanNodes
 	trie, err := smt.delete(smt.root, 0, path, &orphans)
 	if err != nil {
 		return err
 	}
 	smt.root = trie
-	if len(orphans) > 0 {
-		smt.orphans = append(smt.orphans, orphans)
-	}
+	smt.recordChanges(ops, true)
+	smt.recordOrphans(orphans)
+	smt.evictCachedNodes()
 	return nil
 }
 
@@ -254,6 +323,7 @@ func (smt *SMT) delete(node trieNode, depth int, path []byte, orphans *orphanNod
 	if err != nil {
 		return node, err
 	}
+	smt.dirtyNodes++
 
 	if node == nil {
 		return node, ErrKeyNotFound
@@ -330,6 +400,11 @@ func (smt *SMT) Prove(key []byte) (proof *SparseMerkleProof, err error) {
 	path := smt.ph.Path(key)
 	var siblings []trieNode
 	var sib trieNode
+	defer smt.evictCachedNodes()
+	if smt.observer != nil {
+		start := time.Now()
+		defer func() { smt.observeProof(ProofTypeProve, path, start, proof, err) }()
+	}
 
 	node := smt.root
 	for depth := 0; depth < smt.depth(); depth++ {
@@ -355,6 +430,9 @@ func (smt *SMT) Prove(key []byte) (proof *SparseMerkleProof, err error) {
 				if err != nil {
 					return nil, err
 				}
+				if _, ok := node.(*innerNode); !ok {
+					return nil, smt.invalidExtensionChild(extNode)
+				}
 			} else {
 				node = extNode.expand()
 			}
@@ -371,8 +449,15 @@ func (smt *SMT) Prove(key []byte) (proof *SparseMerkleProof, err error) {
 	// Deal with non-membership proofs. If there is no leaf on this path,
 	// we do not need to add anything else to the proof.
 	var leafData []byte
+	node, err = smt.resolveLazy(node)
+	if err != nil {
+		return nil, err
+	}
 	if node != nil {
-		leaf := node.(*leafNode)
+		leaf, ok := node.(*leafNode)
+		if !ok {
+			return nil, smt.expectedLeaf(node)
+		}
 		if !bytes.Equal(leaf.path, path) {
 			// This is a non-membership proof that involves showing a different leaf.
 			// Add the leaf data to the proof.
@@ -422,6 +507,18 @@ func (smt *SMT) ProveClosest(path []byte) (
 		return nil, ErrInvalidClosestPath
 	}
 
+	defer smt.evictCachedNodes()
+	if smt.observer != nil {
+		start := time.Now()
+		defer func() {
+			var closest *SparseMerkleProof
+			if proof != nil {
+				closest = proof.ClosestProof
+			}
+			smt.observeProof(ProofTypeClosest, path, start, closest, err)
+		}()
+	}
+
 	workingPath := make([]byte, len(path))
 	copy(workingPath, path)
 	var siblings []trieNode
@@ -495,6 +592,9 @@ func (smt *SMT) ProveClosest(path []byte) (
 				if err != nil {
 					return nil, err
 				}
+				if _, ok := node.(*innerNode); !ok {
+					return nil, smt.invalidExtensionChild(extNode)
+				}
 			}
 		}
 		inner, ok := node.(*innerNode)
@@ -518,10 +618,14 @@ func (smt *SMT) ProveClosest(path []byte) (
 		proof.ClosestProof = &SparseMerkleProof{}
 		return proof, nil
 	}
+	node, err = smt.resolveLazy(node)
+	if err != nil {
+		return nil, err
+	}
 	leaf, ok := node.(*leafNode)
 	if !ok {
-		// if no leaf was found and the trie is not empty something went wrong
-		panic("expected leaf node")
+		// if no leaf was found and the trie is not empty the trie is corrupt
+		return nil, smt.expectedLeaf(node)
 	}
 	proof.ClosestPath, proof.ClosestValueHash = leaf.path, leaf.valueHash
 	// Hash siblings from bottom up.
@@ -548,36 +652,119 @@ func (smt *SMT) ProveClosest(path []byte) (
 
 // resolveLazy resolves a lazy note into a cached node depending on the tree type
 func (smt *SMT) resolveLazy(node trieNode) (trieNode, error) {
+	stub, ok := node.(*lazyNode)
+	if !ok {
+		smt.touch(node)
+		return node, nil
+	}
+	if err := smt.checkContext(); err != nil {
+		return node, err
+	}
+	var start time.Time
+	if smt.observer != nil {
+		start = time.Now()
+	}
+	var resolved trieNode
+	var err error
+	if smt.sumTrie {
+		resolved, err = smt.resolveSumNode(stub.digest, stub.depth)
+	} else {
+		resolved, err = smt.resolveNode(stub.digest, stub.depth)
+	}
+	// Empty subtries are resolved without reading the node store
+	if smt.observer != nil && (resolved != nil || err != nil) {
+		smt.observer.NodeResolved(NodeResolvedEvent{
+			Digest:   stub.digest,
+			Type:     nodeType(resolved),
+			Duration: time.Since(start),
+			Err:      err,
+		})
+	}
+	if err != nil {
+		// Keep the stub so that the trie is left untouched on failure
+		return node, err
+	}
+	if resolved != nil {
+		smt.cachedNodes++
+		smt.touch(resolved)
+	}
+	return resolved, nil
+}
+
+// readNode resolves a lazy node from the node store without caching it in the
+// trie, leaving the memory held by the trie and its access epochs untouched
+func (smt *SMT) readNode(node trieNode) (trieNode, error) {
 	stub, ok := node.(*lazyNode)
 	if !ok {
 		return node, nil
 	}
 	if smt.sumTrie {
-		return smt.resolveSumNode(stub.digest)
+		return smt.resolveSumNode(stub.digest, stub.depth)
+	}
+	return smt.resolveNode(stub.digest, stub.depth)
+}
+
+// resolvePaths resolves the nodes along the paths of the operations provided,
+// and the siblings of the inner nodes along them if requested, caching them in
+// the trie. Mutations resolve all the nodes they modify beforehand so that
+// their failure modes are limited to the node store errors raised here.
+func (smt *SMT) resolvePaths(node *trieNode, depth int, ops []batchOp, siblings bool) (err error) {
+	if len(ops) == 0 {
+		return nil
+	}
+	*node, err = smt.resolveLazy(*node)
+	if err != nil {
+		return err
 	}
-	return smt.resolveNode(stub.digest)
+
+	switch n := (*node).(type) {
+	case *extensionNode:
+		// Only the paths running through the entire extension reach its child
+		matched := make([]batchOp, 0, len(ops))
+		for _, op := range ops {
+			if _, fullMatch := n.boundsMatch(op.path, depth); fullMatch {
+				matched = append(matched, op)
+			}
+		}
+		return smt.resolvePaths(&n.child, depth+n.length(), matched, siblings)
+	case *innerNode:
+		if siblings {
+			if n.leftChild, err = smt.resolveLazy(n.leftChild); err != nil {
+				return err
+			}
+			if n.rightChild, err = smt.resolveLazy(n.rightChild); err != nil {
+				return err
+			}
+		}
+		split := splitBatchOps(ops, depth)
+		if err = smt.resolvePaths(&n.leftChild, depth+1, ops[:split], siblings); err != nil {
+			return err
+		}
+		return smt.resolvePaths(&n.rightChild, depth+1, ops[split:], siblings)
+	}
+	return nil
 }
 
 // resolveNode returns a trieNode (inner, leaf, or extension) based on what they
 // keyHash points to.
-func (smt *SMT) resolveNode(digest []byte) (trieNode, error) {
+func (smt *SMT) resolveNode(digest []byte, depth int) (trieNode, error) {
 	// Check if the keyHash is the empty zero value of an empty subtree
 	if bytes.Equal(smt.placeholder(), digest) {
 		return nil, nil
 	}
 
-	// Retrieve the encoded noe data
-	data, err := smt.nodes.Get(digest)
+	// Retrieve the encoded node data
+	data, err := smt.getNode(digest, depth)
 	if err != nil {
 		return nil, err
 	}
 
-	return smt.parseTrieNode(data, digest)
+	return smt.parseTrieNode(data, digest, depth)
 }
 
 // parseTrieNode returns a trieNode (inner, leaf, or extension) based on the
 // first byte of the data.
-func (smt *SMT) parseTrieNode(data, digest []byte) (trieNode, error) {
+func (smt *SMT) parseTrieNode(data, digest []byte, depth int) (trieNode, error) {
 	if isLeafNode(data) {
 		path, valueHash := smt.parseLeafNode(data)
 		return &leafNode{
@@ -591,43 +778,42 @@ func (smt *SMT) parseTrieNode(data, digest []byte) (trieNode, error) {
 		return &extensionNode{
 			path:       path,
 			pathBounds: [2]byte(pathBounds),
-			child:      &lazyNode{childData},
+			child:      &lazyNode{digest: childData, depth: int(pathBounds[1])},
 			persisted:  true,
 			digest:     digest,
 		}, nil
 	} else if isInnerNode(data) {
 		leftData, rightData := smt.th.parseInnerNode(data)
 		return &innerNode{
-			leftChild:  &lazyNode{leftData},
-			rightChild: &lazyNode{rightData},
+			leftChild:  &lazyNode{digest: leftData, depth: depth + 1},
+			rightChild: &lazyNode{digest: rightData, depth: depth + 1},
 			persisted:  true,
 			digest:     digest,
 		}, nil
-	} else {
-		panic("invalid node type")
 	}
+	return nil, &NodeError{Digest: digest, Err: ErrInvalidNodeEncoding}
 }
 
 // resolveNode returns a trieNode (inner, leaf, or extension) based on what they
 // keyHash points to.
-func (smt *SMT) resolveSumNode(digest []byte) (trieNode, error) {
+func (smt *SMT) resolveSumNode(digest []byte, depth int) (trieNode, error) {
 	// Check if the keyHash is the empty zero value of an empty subtree
 	if bytes.Equal(smt.placeholder(), digest) {
 		return nil, nil
 	}
 
-	// Retrieve the encoded noe data
-	data, err := smt.nodes.Get(digest)
+	// Retrieve the encoded node data
+	data, err := smt.getNode(digest, depth)
 	if err != nil {
 		return nil, err
 	}
 
-	return smt.parseSumTrieNode(data, digest)
+	return smt.parseSumTrieNode(data, digest, depth)
 }
 
 // parseTrieNode returns a trieNode (inner, leaf, or extension) based on the
 // first byte of the data.
-func (smt *SMT) parseSumTrieNode(data, digest []byte) (trieNode, error) {
+func (smt *SMT) parseSumTrieNode(data, digest []byte, depth int) (trieNode, error) {
 	if isLeafNode(data) {
 		path, valueHash := smt.parseLeafNode(data)
 		return &leafNode{
@@ -641,67 +827,218 @@ func (smt *SMT) parseSumTrieNode(data, digest []byte) (trieNode, error) {
 		return &extensionNode{
 			path:       path,
 			pathBounds: [2]byte(pathBounds),
-			child:      &lazyNode{childData},
+			child:      &lazyNode{digest: childData, depth: int(pathBounds[1])},
 			persisted:  true,
 			digest:     digest,
 		}, nil
 	} else if isInnerNode(data) {
 		leftData, rightData, _, _ := smt.th.parseSumInnerNode(data)
 		return &innerNode{
-			leftChild:  &lazyNode{leftData},
-			rightChild: &lazyNode{rightData},
+			leftChild:  &lazyNode{digest: leftData, depth: depth + 1},
+			rightChild: &lazyNode{digest: rightData, depth: depth + 1},
 			persisted:  true,
 			digest:     digest,
 		}, nil
-	} else {
-		panic("invalid node type")
+	}
+	return nil, &NodeError{Digest: digest, Err: ErrInvalidNodeEncoding}
+}
+
+// getNode reads the encoded node stored under the digest provided from the
+// node store, and checks that it can be parsed as a node at the given depth
+// of the trie. A NodeError is returned if the node is missing or corrupt.
+func (smt *SMT) getNode(digest []byte, depth int) ([]byte, error) {
+	data, err := smt.storeGet(smt.nodes, digest)
+	if errors.Is(err, kvstore.ErrKeyNotFound) {
+		return nil, &NodeError{Digest: digest, Err: fmt.Errorf("%w: %w", ErrMissingNode, err)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !smt.validEncoding(data) {
+		return nil, &NodeError{Digest: digest, Err: ErrInvalidNodeEncoding}
+	}
+	// Inner nodes branch on the bit of the path at their depth, and extension
+	// nodes must start at the depth they are found at
+	if isInnerNode(data) && depth >= smt.depth() {
+		return nil, &NodeError{
+			Digest: digest,
+			Err:    fmt.Errorf("%w: inner node at depth %d", ErrCorruptNode, depth),
+		}
+	}
+	if isExtNode(data) && int(data[prefixLen]) != depth {
+		return nil, &NodeError{
+			Digest: digest,
+			Err: fmt.Errorf("%w: extension node starting at depth %d found at depth %d",
+				ErrCorruptNode, data[prefixLen], depth),
+		}
+	}
+	return data, nil
+}
+
+// invalidExtensionChild returns the error raised when the child of an
+// extension node is not an inner node, which only happens if the node store
+// is corrupt
+func (smt *SMT) invalidExtensionChild(ext *extensionNode) error {
+	return &NodeError{
+		Digest: smt.digest(ext),
+		Err:    fmt.Errorf("%w: extension node child is not an inner node", ErrCorruptNode),
+	}
+}
+
+// expectedLeaf returns the error raised when a traversal does not end on a
+// leaf node, which only happens if the node store is corrupt
+func (smt *SMT) expectedLeaf(node trieNode) error {
+	return &NodeError{
+		Digest: smt.digest(node),
+		Err:    fmt.Errorf("%w: expected a leaf node", ErrCorruptNode),
 	}
 }
 
 // Commit persists all dirty nodes in the trie, deletes all orphaned
 // nodes from the database and then computes and saves the root hash
 func (smt *SMT) Commit() (err error) {
+	var start time.Time
+	if smt.observer != nil {
+		start = time.Now()
+	}
+	written, deleted, err := smt.flush()
+	// Report the nodes flushed early by mutations along with the commit
+	written += smt.flushedNodes
+	smt.flushedNodes = 0
+	if smt.observer != nil {
+		defer func() {
+			event := CommitEvent{
+				NodesWritten:   written,
+				OrphansDeleted: deleted,
+				Duration:       time.Since(start),
+				Err:            err,
+			}
+			if err == nil {
+				event.Root = smt.rootHash
+			}
+			smt.observer.Committed(event)
+		}()
+	}
+	if err != nil {
+		return
+	}
+	oldRoot := smt.rootHash
+	smt.rootHash = smt.Root()
+	smt.evictCachedNodes()
+	smt.publishChanges(oldRoot)
+	return
+}
+
+// flush persists all dirty nodes in the trie, then deletes all orphaned nodes
+// from the database, without updating the last persisted root hash. It returns
+// the number of nodes written and deleted. The orphans are only deleted once
+// every dirty node is written, so that the last committed trie remains intact
+// in the database if the flush fails.
+func (smt *SMT) flush() (written, deleted int, err error) {
+	if err = smt.writeDirtyNodes(&written); err != nil {
+		return
+	}
+	deleted, err = smt.deleteOrphans()
+	return
+}
+
+// writeDirtyNodes persists all dirty nodes in the trie, counting them in
+// written
+func (smt *SMT) writeDirtyNodes(written *int) error {
+	if err := smt.commit(smt.root, written); err != nil {
+		return err
+	}
+	smt.dirtyNodes = 0
+	return nil
+}
+
+// deleteOrphans deletes the orphaned nodes from the database, except those
+// written again since they were orphaned, which are part of the trie again.
+// The context of the operation is not checked, as the deletion only starts
+// once the new trie is entirely written and must then run to completion.
+func (smt *SMT) deleteOrphans() (deleted int, err error) {
 	// All orphans are persisted and have cached digests, so we don't need to check for null
 	for _, orphans := range smt.orphans {
 		for _, hash := range orphans {
-			if err = smt.nodes.Delete(hash); err != nil {
+			if !smt.pendingOrphans[string(hash)] {
+				continue
+			}
+			if err = smt.storeDelete(smt.nodes, hash); err != nil {
 				return
 			}
+			// Orphaned more than once, but only deleted once
+			smt.pendingOrphans[string(hash)] = false
+			deleted++
 		}
 	}
 	smt.orphans = nil
-	if err = smt.commit(smt.root); err != nil {
-		return
-	}
-	smt.rootHash = smt.Root()
+	smt.pendingOrphans = nil
 	return
 }
 
-func (smt *SMT) commit(node trieNode) error {
+// commit writes the dirty nodes of the subtrie to the node store, counting
+// them in written
+func (smt *SMT) commit(node trieNode, written *int) error {
 	if node != nil && node.Persisted() {
 		return nil
 	}
 	switch n := node.(type) {
 	case *leafNode:
-		n.persisted = true
 	case *innerNode:
-		n.persisted = true
-		if err := smt.commit(n.leftChild); err != nil {
+		if err := smt.commit(n.leftChild, written); err != nil {
 			return err
 		}
-		if err := smt.commit(n.rightChild); err != nil {
+		if err := smt.commit(n.rightChild, written); err != nil {
 			return err
 		}
 	case *extensionNode:
-		n.persisted = true
-		if err := smt.commit(n.child); err != nil {
+		if err := smt.commit(n.child, written); err != nil {
 			return err
 		}
 	default:
 		return nil
 	}
-	preimage := smt.encode(node)
-	return smt.nodes.Set(smt.digest(node), preimage)
+	if err := smt.checkContext(); err != nil {
+		return err
+	}
+	preimage, digest := smt.encode(node), smt.digest(node)
+	if err := smt.storeSet(smt.nodes, digest, preimage); err != nil {
+		return err
+	}
+	*written++
+	// A node identical to an orphan makes it part of the trie again
+	if _, ok := smt.pendingOrphans[string(digest)]; ok {
+		smt.pendingOrphans[string(digest)] = false
+	}
+	// Only mark the node as persisted once it is stored, so that a failed
+	// commit can be retried
+	switch n := node.(type) {
+	case *leafNode:
+		n.persisted = true
+	case *innerNode:
+		n.persisted = true
+	case *extensionNode:
+		n.persisted = true
+	}
+	// Persisted nodes are now clean and count towards the cached nodes
+	smt.cachedNodes++
+	smt.touch(node)
+	return nil
+}
+
+// recordOrphans records the nodes orphaned by a successful trie operation, to
+// be deleted from the database by the next commit
+func (smt *SMT) recordOrphans(orphans orphanNodes) {
+	if len(orphans) == 0 {
+		return
+	}
+	smt.orphans = append(smt.orphans, orphans)
+	if smt.pendingOrphans == nil {
+		smt.pendingOrphans = make(map[string]bool)
+	}
+	for _, hash := range orphans {
+		smt.pendingOrphans[string(hash)] = true
+	}
 }
 
 func (smt *SMT) addOrphan(orphans *[][]byte, node trieNode) {
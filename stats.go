@@ -0,0 +1,118 @@
+package smt
+
+import "github.com/pokt-network/smt/kvstore"
+
+// TrieStats describes the shape and size of a trie
+type TrieStats struct {
+	// LeafNodes, InnerNodes and ExtensionNodes are the number of nodes of
+	// each type in the trie
+	LeafNodes      int
+	InnerNodes     int
+	ExtensionNodes int
+	// LeafDepths maps the number of nodes traversed from the root to reach a
+	// leaf, excluding the leaf itself, to the number of leaves at that depth
+	LeafDepths map[int]int
+	// AvgProofLength and MaxProofLength are the average and maximum number of
+	// side nodes of the membership proofs of the leaves, i.e. the number of
+	// bits of their path leading to them
+	AvgProofLength float64
+	MaxProofLength int
+	// ExtensionLengths maps the number of bits of the path covered by an
+	// extension node to the number of extension nodes covering that many
+	// bits
+	ExtensionLengths map[int]int
+	// StoredBytes is the total size of the keys and values of the node
+	// records of the trie in the node store, the dirty nodes are not counted
+	StoredBytes int64
+	// DirtyNodes is the number of nodes modified since the last commit held
+	// in memory, always zero for a committed trie
+	DirtyNodes int
+	// PendingOrphans is the number of node records to be deleted from the
+	// node store by the next commit, always zero for a committed trie
+	PendingOrphans int
+}
+
+// Stats walks the entire trie, including the nodes held in memory which were
+// not committed yet, and returns its statistics. The nodes read from the node
+// store are not cached, so the memory held by the trie is left untouched.
+func (smt *SMT) Stats() (*TrieStats, error) {
+	stats := newTrieStats()
+	for _, pending := range smt.pendingOrphans {
+		if pending {
+			stats.PendingOrphans++
+		}
+	}
+	if err := smt.collectStats(stats, smt.root, 0, 0); err != nil {
+		return nil, err
+	}
+	return stats.finish(), nil
+}
+
+// Stats walks the committed trie with the given root in the node store and
+// returns its statistics. The spec must match the spec the trie was created
+// with. A NodeError is returned if a node is missing or corrupt.
+func Stats(nodes kvstore.MapStore, spec *TrieSpec, root []byte) (*TrieStats, error) {
+	smt := &SMT{TrieSpec: *spec, nodes: nodes}
+	stats := newTrieStats()
+	if err := smt.collectStats(stats, &lazyNode{digest: root}, 0, 0); err != nil {
+		return nil, err
+	}
+	return stats.finish(), nil
+}
+
+// newTrieStats returns empty trie statistics
+func newTrieStats() *TrieStats {
+	return &TrieStats{
+		LeafDepths:       make(map[int]int),
+		ExtensionLengths: make(map[int]int),
+	}
+}
+
+// collectStats adds the nodes of the subtrie found at the given depth to the
+// statistics, hops being the number of nodes traversed from the root to reach
+// it. Lazy nodes are read from the node store without being cached.
+func (smt *SMT) collectStats(stats *TrieStats, node trieNode, depth, hops int) error {
+	node, err := smt.readNode(node)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return nil
+	}
+	if node.Persisted() {
+		stats.StoredBytes += int64(len(node.CachedDigest()) + len(smt.encode(node)))
+	} else {
+		stats.DirtyNodes++
+	}
+
+	switch n := node.(type) {
+	case *leafNode:
+		stats.LeafNodes++
+		stats.LeafDepths[hops]++
+		// Proofs hold a side node for every bit of the path leading to the
+		// leaf, the average is computed once the trie is walked
+		stats.AvgProofLength += float64(depth)
+		if depth > stats.MaxProofLength {
+			stats.MaxProofLength = depth
+		}
+	case *extensionNode:
+		stats.ExtensionNodes++
+		stats.ExtensionLengths[n.length()]++
+		return smt.collectStats(stats, n.child, n.pathEnd(), hops+1)
+	case *innerNode:
+		stats.InnerNodes++
+		if err := smt.collectStats(stats, n.leftChild, depth+1, hops+1); err != nil {
+			return err
+		}
+		return smt.collectStats(stats, n.rightChild, depth+1, hops+1)
+	}
+	return nil
+}
+
+// finish computes the statistics derived from the nodes counted
+func (stats *TrieStats) finish() *TrieStats {
+	if stats.LeafNodes > 0 {
+		stats.AvgProofLength /= float64(stats.LeafNodes)
+	}
+	return stats
+}
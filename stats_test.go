@@ -0,0 +1,160 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// storedBytes returns the total size of the keys and values of the records of
+// the store
+func storedBytes(t *testing.T, nodes kvstore.IterableMapStore) int64 {
+	t.Helper()
+	var size int64
+	it, err := nodes.RangeIterator(kvstore.IterOptions{})
+	require.NoError(t, err)
+	defer it.Close()
+	for it.Next() {
+		size += int64(len(it.Key()) + len(it.Value()))
+	}
+	require.NoError(t, it.Err())
+	return size
+}
+
+func TestStats(t *testing.T) {
+	for _, sumTrie := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sumTrie=%t", sumTrie), func(t *testing.T) {
+			spec := NewTrieSpec(sha256.New(), sumTrie)
+			nodes := simplemap.NewSimpleMap()
+			keys, values := randomBatch(t, 300, 1)
+			var trie *SMT
+			if sumTrie {
+				smst := NewSparseMerkleSumTrie(nodes, sha256.New())
+				for i := range keys {
+					require.NoError(t, smst.Update(keys[i], values[i], uint64(i)))
+				}
+				trie = smst.SMT
+			} else {
+				trie = NewSparseMerkleTrie(nodes, sha256.New())
+				require.NoError(t, trie.UpdateBatch(keys, values))
+			}
+
+			// Nodes which were not committed yet are dirty
+			stats, err := trie.Stats()
+			require.NoError(t, err)
+			require.Equal(t, len(keys), stats.LeafNodes)
+			require.Equal(t, stats.LeafNodes+stats.InnerNodes+stats.ExtensionNodes, stats.DirtyNodes)
+			require.Zero(t, stats.StoredBytes)
+			require.NoError(t, trie.Commit())
+
+			stats, err = trie.Stats()
+			require.NoError(t, err)
+			stored, err := nodes.Len()
+			require.NoError(t, err)
+			require.Equal(t, len(keys), stats.LeafNodes)
+			require.Equal(t, len(keys)-1, stats.InnerNodes)
+			require.Equal(t, stored, stats.LeafNodes+stats.InnerNodes+stats.ExtensionNodes)
+			require.Equal(t, storedBytes(t, nodes), stats.StoredBytes)
+			require.Zero(t, stats.DirtyNodes)
+			require.Zero(t, stats.PendingOrphans)
+
+			leaves, extensions := 0, 0
+			for _, count := range stats.LeafDepths {
+				leaves += count
+			}
+			for _, count := range stats.ExtensionLengths {
+				extensions += count
+			}
+			require.Equal(t, stats.LeafNodes, leaves)
+			require.Equal(t, stats.ExtensionNodes, extensions)
+
+			// Proof lengths match the proofs generated by the trie
+			total, longest := 0, 0
+			for _, key := range keys {
+				proof, err := trie.Prove(key)
+				require.NoError(t, err)
+				total += len(proof.SideNodes)
+				longest = max(longest, len(proof.SideNodes))
+			}
+			require.Equal(t, longest, stats.MaxProofLength)
+			require.InDelta(t, float64(total)/float64(len(keys)), stats.AvgProofLength, 1e-9)
+
+			// The committed trie yields the same statistics from the node store
+			offline, err := Stats(nodes, &spec, trie.Root())
+			require.NoError(t, err)
+			require.Equal(t, stats, offline)
+		})
+	}
+}
+
+func TestStats_PendingChanges(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	keys, values := randomBatch(t, 50, 1)
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+	require.NoError(t, trie.UpdateBatch(keys, values))
+	require.NoError(t, trie.Commit())
+
+	trie = ImportSparseMerkleTrie(nodes, sha256.New(), trie.Root())
+	require.NoError(t, trie.Update(keys[0], []byte("updated")))
+	require.NoError(t, trie.Delete(keys[1]))
+
+	stats, err := trie.Stats()
+	require.NoError(t, err)
+	require.Equal(t, len(keys)-1, stats.LeafNodes)
+	require.Positive(t, stats.DirtyNodes)
+	require.Positive(t, stats.PendingOrphans)
+
+	require.NoError(t, trie.Commit())
+	stats, err = trie.Stats()
+	require.NoError(t, err)
+	require.Zero(t, stats.DirtyNodes)
+	require.Zero(t, stats.PendingOrphans)
+	require.Equal(t, storedBytes(t, nodes), stats.StoredBytes)
+}
+
+func TestStats_LazyNodesNotCached(t *testing.T) {
+	nodes := simplemap.NewSimpleMap()
+	keys, values := randomBatch(t, 50, 1)
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+	require.NoError(t, trie.UpdateBatch(keys, values))
+	require.NoError(t, trie.Commit())
+
+	trie = ImportSparseMerkleTrie(nodes, sha256.New(), trie.Root())
+	stats, err := trie.Stats()
+	require.NoError(t, err)
+	require.Equal(t, len(keys), stats.LeafNodes)
+	require.True(t, isLazyNode(trie.root))
+	require.Zero(t, trie.cachedNodes)
+}
+
+func TestStats_EmptyTrie(t *testing.T) {
+	spec := NewTrieSpec(sha256.New(), false)
+	trie := NewSparseMerkleTrie(simplemap.NewSimpleMap(), sha256.New())
+	stats, err := trie.Stats()
+	require.NoError(t, err)
+	require.Equal(t, newTrieStats(), stats)
+
+	offline, err := Stats(simplemap.NewSimpleMap(), &spec, trie.Root())
+	require.NoError(t, err)
+	require.Equal(t, newTrieStats(), offline)
+}
+
+func TestStats_CorruptTrie(t *testing.T) {
+	tt := newVerifyingTestTrie(t, false)
+	digests, _ := tt.nodesOfType(t, isLeafNode)
+	require.NoError(t, tt.nodes.Delete(digests[0]))
+
+	_, err := Stats(tt.nodes, tt.spec, tt.root)
+	var nodeErr *NodeError
+	require.ErrorAs(t, err, &nodeErr)
+	require.Equal(t, digests[0], nodeErr.Digest)
+	require.ErrorIs(t, err, ErrMissingNode)
+
+	_, err = tt.importTrie().Stats()
+	require.ErrorIs(t, err, ErrMissingNode)
+}
@@ -1,6 +1,7 @@
 package smt
 
 import (
+	"bytes"
 	"encoding/binary"
 	"hash"
 )
@@ -12,6 +13,14 @@ type TrieSpec struct {
 	ph      PathHasher
 	vh      ValueHasher
 	sumTrie bool
+	// The maximum number of clean nodes resolved from the node store to
+	// keep in memory, or zero if unbounded
+	maxCachedNodes int
+	// The maximum number of dirty nodes to keep in memory before flushing
+	// them to the node store, or zero if unbounded
+	maxDirtyNodes int
+	// The observer notified of the operations of the trie, if any
+	observer TrieObserver
 }
 
 // NewTrieSpec returns a new TrieSpec with the given hasher and sumTrie flag
@@ -104,7 +113,7 @@ func (spec *TrieSpec) hashPreimage(data []byte) []byte {
 func (spec *TrieSpec) hashSerialization(data []byte) []byte {
 	if isExtNode(data) {
 		pathBounds, path, childHash := spec.parseExtNode(data)
-		ext := extensionNode{path: path, child: &lazyNode{childHash}}
+		ext := extensionNode{path: path, child: &lazyNode{digest: childHash}}
 		copy(ext.pathBounds[:], pathBounds)
 		return spec.digestNode(&ext)
 	}
@@ -115,7 +124,7 @@ func (spec *TrieSpec) hashSerialization(data []byte) []byte {
 func (spec *TrieSpec) hashSumSerialization(data []byte) []byte {
 	if isExtNode(data) {
 		pathBounds, path, childHash, _, _ := spec.parseSumExtNode(data)
-		ext := extensionNode{path: path, child: &lazyNode{childHash}}
+		ext := extensionNode{path: path, child: &lazyNode{digest: childHash}}
 		copy(ext.pathBounds[:], pathBounds)
 		return spec.digestSumNode(&ext)
 	}
@@ -245,6 +254,62 @@ func (spec *TrieSpec) parseLeafNode(data []byte) (path, value []byte) {
 	return
 }
 
+// validEncoding returns true if the data provided has the length expected for
+// the type of node it encodes, so that it can be parsed without panicking, and
+// for sum tries if its sum and count are consistent
+func (spec *TrieSpec) validEncoding(data []byte) bool {
+	return spec.validLayout(data) && spec.validSumMeta(data)
+}
+
+// validLayout returns true if the data provided has the length expected for
+// the type of node it encodes and, for extension nodes, valid path bounds
+func (spec *TrieSpec) validLayout(data []byte) bool {
+	if len(data) < prefixLen {
+		return false
+	}
+	pathSize := spec.ph.PathSize()
+	childSize := spec.hashSize()
+	metaSize := 0
+	if spec.sumTrie {
+		metaSize = sumSizeBytes + countSizeBytes
+	}
+	switch {
+	case isLeafNode(data):
+		return len(data) >= prefixLen+pathSize+metaSize
+	case isInnerNode(data):
+		return len(data) == prefixLen+2*childSize+metaSize
+	case isExtNode(data):
+		// +2 represents the length of the pathBounds
+		if len(data) != prefixLen+2+pathSize+childSize+metaSize {
+			return false
+		}
+		start, end := int(data[prefixLen]), int(data[prefixLen+1])
+		return start < end && end <= spec.depth()
+	}
+	return false
+}
+
+// validSumMeta returns true if the sum and count of the sum trie node encoded
+// by the data provided, which must have a valid layout, are consistent with
+// the node itself. It always returns true for non-sum tries.
+func (spec *TrieSpec) validSumMeta(data []byte) bool {
+	if !spec.sumTrie {
+		return true
+	}
+	metaSize := sumSizeBytes + countSizeBytes
+	switch {
+	case isLeafNode(data):
+		// Every sum trie leaf counts as a single non-empty leaf
+		return binary.BigEndian.Uint64(data[len(data)-countSizeBytes:]) == 1
+	case isExtNode(data):
+		// The sum and count of a sum extension node are not part of its
+		// digest, so they must match the ones of its child
+		childEnd := len(data) - metaSize
+		return bytes.Equal(data[childEnd-metaSize:childEnd], data[childEnd:])
+	}
+	return true
+}
+
 // parseExtNode parses an extNode into its components
 func (spec *TrieSpec) parseExtNode(data []byte) (pathBounds, path, childData []byte) {
 	// panics if not an extension node
@@ -1,5 +1,9 @@
 package smt
 
+import (
+	"context"
+)
+
 // TODO_DISCUSS_CONSIDERIN_THE_FUTURE:
 // 1. Should we rename all instances of digest to hash?
 // 	> digest is the correct term for the output of a hashing function IIRC
@@ -90,3 +94,71 @@ type SparseMerkleSumTrie interface {
 	// Spec returns the TrieSpec for the trie
 	Spec() *TrieSpec
 }
+
+// BatchTrie is implemented by the tries which can apply many operations in a
+// single descent, such as the SMT.
+type BatchTrie interface {
+	// UpdateBatch inserts multiple values into the SMT in a single descent.
+	UpdateBatch(keys, values [][]byte) error
+	// DeleteBatch deletes multiple values from the SMT in a single descent.
+	// Raises an error if any of the keys is not present.
+	DeleteBatch(keys [][]byte) error
+	// GetMany descends the trie once to access the values of multiple keys.
+	GetMany(keys [][]byte) ([][]byte, error)
+}
+
+// BatchSumTrie is implemented by the sum tries which can apply many operations
+// in a single descent, such as the SMST.
+type BatchSumTrie interface {
+	// UpdateBatch inserts multiple values and their sums into the SMST in a
+	// single descent.
+	UpdateBatch(keys, values [][]byte, sums []uint64) error
+	// DeleteBatch deletes multiple values from the SMST in a single descent.
+	// Raises an error if any of the keys is not present.
+	DeleteBatch(keys [][]byte) error
+	// GetMany descends the trie once to access the values of multiple keys.
+	GetMany(keys [][]byte) (data [][]byte, sums []uint64, err error)
+}
+
+// SubscribableTrie is implemented by the tries reporting the leaves changed by
+// their commits, such as the SMT and SMST.
+type SubscribableTrie interface {
+	// Subscribe registers a function notified of the leaves changed by every
+	// commit.
+	Subscribe(subscriber func(CommitChanges)) (unsubscribe func())
+}
+
+// ContextTrie is implemented by the tries whose operations can be cancelled
+// with a context, such as the SMT.
+type ContextTrie interface {
+	// GetCtx, UpdateCtx, DeleteCtx, ProveCtx, ProveClosestCtx and CommitCtx
+	// are variants of the methods of SparseMerkleTrie which can be cancelled
+	// with a context.
+	GetCtx(ctx context.Context, key []byte) ([]byte, error)
+	UpdateCtx(ctx context.Context, key, value []byte) error
+	DeleteCtx(ctx context.Context, key []byte) error
+	ProveCtx(ctx context.Context, key []byte) (*SparseMerkleProof, error)
+	ProveClosestCtx(ctx context.Context, path []byte) (*SparseMerkleClosestProof, error)
+	CommitCtx(ctx context.Context) error
+}
+
+// ContextSumTrie is implemented by the sum tries whose operations can be
+// cancelled with a context, such as the SMST.
+type ContextSumTrie interface {
+	// GetCtx, UpdateCtx, DeleteCtx, ProveCtx, ProveClosestCtx and CommitCtx
+	// are variants of the methods of SparseMerkleSumTrie which can be
+	// cancelled with a context.
+	GetCtx(ctx context.Context, key []byte) (data []byte, sum uint64, err error)
+	UpdateCtx(ctx context.Context, key, value []byte, sum uint64) error
+	DeleteCtx(ctx context.Context, key []byte) error
+	ProveCtx(ctx context.Context, key []byte) (*SparseMerkleProof, error)
+	ProveClosestCtx(ctx context.Context, path []byte) (*SparseMerkleClosestProof, error)
+	CommitCtx(ctx context.Context) error
+}
+
+// StatsTrie is implemented by the tries reporting their shape and size, such
+// as the SMT and SMST.
+type StatsTrie interface {
+	// Stats walks the trie and returns its statistics.
+	Stats() (*TrieStats, error)
+}
@@ -0,0 +1,54 @@
+package smt
+
+import (
+	"bytes"
+	"hash"
+
+	"github.com/pokt-network/smt/kvstore"
+)
+
+var _ kvstore.MapStore = (*verifyingMapStore)(nil)
+
+// verifyingMapStore wraps a node store to verify the integrity of the nodes
+// read from it
+type verifyingMapStore struct {
+	kvstore.MapStore
+	spec *TrieSpec
+}
+
+// NewVerifyingMapStore returns a MapStore wrapping the node store provided
+// which re-hashes every node returned by `Get` using the TrieSpec provided, and
+// returns ErrCorruptNode if the node does not hash to the key it is stored
+// under. Since nodes are content-addressed, this detects any tampering with
+// the nodes of a trie in a store which may be written to externally, at the
+// cost of hashing every node resolved from it.
+//
+// The spec must match the spec of the trie using the store, and can be the
+// spec of that trie: the store hashes the nodes with its own hasher, returned
+// by `newHasher`, since hashers are not safe for concurrent use. All the keys
+// read through the store must be trie nodes.
+func NewVerifyingMapStore(nodes kvstore.MapStore, spec *TrieSpec, newHasher func() hash.Hash) kvstore.MapStore {
+	verifyingSpec := *spec
+	verifyingSpec.th = NewTrieHasher(newHasher())
+	return &verifyingMapStore{
+		MapStore: nodes,
+		spec:     &verifyingSpec,
+	}
+}
+
+// Get returns the node stored under the given digest, or a NodeError wrapping
+// ErrInvalidNodeEncoding or ErrCorruptNode if the node cannot be decoded or
+// does not hash to the digest
+func (store *verifyingMapStore) Get(digest []byte) ([]byte, error) {
+	data, err := store.MapStore.Get(digest)
+	if err != nil {
+		return nil, err
+	}
+	if !store.spec.validEncoding(data) {
+		return nil, &NodeError{Digest: digest, Err: ErrInvalidNodeEncoding}
+	}
+	if !bytes.Equal(store.spec.hashPreimage(data), digest) {
+		return nil, &NodeError{Digest: digest, Err: ErrCorruptNode}
+	}
+	return data, nil
+}
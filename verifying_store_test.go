@@ -0,0 +1,261 @@
+package smt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pokt-network/smt/kvstore"
+	"github.com/pokt-network/smt/kvstore/memdb"
+	"github.com/pokt-network/smt/kvstore/simplemap"
+)
+
+// verifyingTestTrie is a committed trie holding both random paths and paths
+// sharing long prefixes, so that every type of node is present in its store
+type verifyingTestTrie struct {
+	nodes  simplemap.SimpleMap
+	spec   *TrieSpec
+	opts   []TrieSpecOption
+	root   []byte
+	keys   [][]byte
+	values [][]byte
+}
+
+func newVerifyingTestTrie(t *testing.T, sumTrie bool) *verifyingTestTrie {
+	t.Helper()
+	keys, values := randomBatch(t, 32, 7)
+	for i := 0; i < 8; i++ {
+		key := make([]byte, 32)
+		key[31] = byte(i)
+		keys = append(keys, key)
+		values = append(values, []byte(fmt.Sprintf("value-%d", i)))
+	}
+
+	nodes := simplemap.NewSimpleMap()
+	opts := []TrieSpecOption{WithPathHasher(newNilPathHasher(32))}
+	var root []byte
+	if sumTrie {
+		trie := NewSparseMerkleSumTrie(nodes, sha256.New(), opts...)
+		for i := range keys {
+			require.NoError(t, trie.Update(keys[i], values[i], uint64(i)))
+		}
+		require.NoError(t, trie.Commit())
+		root = trie.Root()
+	} else {
+		trie := NewSparseMerkleTrie(nodes, sha256.New(), opts...)
+		require.NoError(t, trie.UpdateBatch(keys, values))
+		require.NoError(t, trie.Commit())
+		root = trie.Root()
+	}
+	spec := NewTrieSpec(sha256.New(), sumTrie, opts...)
+	return &verifyingTestTrie{
+		nodes:  nodes,
+		spec:   &spec,
+		opts:   opts,
+		root:   root,
+		keys:   keys,
+		values: values,
+	}
+}
+
+// storedNodes returns the digests and encodings of all the nodes in the store
+func (tt *verifyingTestTrie) storedNodes(t *testing.T) (digests, nodes [][]byte) {
+	t.Helper()
+	it, err := tt.nodes.RangeIterator(kvstore.IterOptions{})
+	require.NoError(t, err)
+	defer it.Close()
+	for it.Next() {
+		digests = append(digests, append([]byte{}, it.Key()...))
+		nodes = append(nodes, append([]byte{}, it.Value()...))
+	}
+	require.NoError(t, it.Err())
+	return digests, nodes
+}
+
+func TestVerifyingMapStore_HonestTrie(t *testing.T) {
+	t.Run("SMT", func(t *testing.T) {
+		tt := newVerifyingTestTrie(t, false)
+		store := NewVerifyingMapStore(tt.nodes, tt.spec, sha256.New)
+		trie := ImportSparseMerkleTrie(store, sha256.New(), tt.root, tt.opts...)
+		for i, key := range tt.keys {
+			value, err := trie.Get(key)
+			require.NoError(t, err)
+			require.Equal(t, trie.valueHash(tt.values[i]), value)
+			proof, err := trie.Prove(key)
+			require.NoError(t, err)
+			valid, err := VerifyProof(proof, tt.root, key, tt.values[i], trie.Spec())
+			require.NoError(t, err)
+			require.True(t, valid)
+		}
+
+		// Writes go through to the underlying store
+		require.NoError(t, trie.Delete(tt.keys[0]))
+		require.NoError(t, trie.Commit())
+		imported := ImportSparseMerkleTrie(store, sha256.New(), trie.Root(), tt.opts...)
+		value, err := imported.Get(tt.keys[1])
+		require.NoError(t, err)
+		require.Equal(t, trie.valueHash(tt.values[1]), value)
+	})
+
+	t.Run("SMST", func(t *testing.T) {
+		tt := newVerifyingTestTrie(t, true)
+		store := NewVerifyingMapStore(tt.nodes, tt.spec, sha256.New)
+		trie := ImportSparseMerkleSumTrie(store, sha256.New(), tt.root, tt.opts...)
+		for i, key := range tt.keys {
+			_, weight, err := trie.Get(key)
+			require.NoError(t, err)
+			require.Equal(t, uint64(i), weight)
+			proof, err := trie.Prove(key)
+			require.NoError(t, err)
+			valid, err := VerifySumProof(proof, tt.root, key, tt.values[i], uint64(i), 1, trie.Spec())
+			require.NoError(t, err)
+			require.True(t, valid)
+		}
+	})
+}
+
+func TestVerifyingMapStore_ConcurrentWithTrie(t *testing.T) {
+	nodes := memdb.NewKVStore()
+	trie := NewSparseMerkleTrie(nodes, sha256.New())
+	keys, values := randomBatch(t, 64, 11)
+	require.NoError(t, trie.UpdateBatch(keys, values))
+	require.NoError(t, trie.Commit())
+
+	// The store is created from the spec of the trie, and reads through it
+	// while the trie is written must not share the hasher of the trie
+	store := NewVerifyingMapStore(nodes, trie.Spec(), sha256.New)
+	var digests [][]byte
+	it, err := nodes.RangeIterator(kvstore.IterOptions{})
+	require.NoError(t, err)
+	for it.Next() {
+		digests = append(digests, append([]byte{}, it.Key()...))
+	}
+	require.NoError(t, it.Err())
+	it.Close()
+
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 10; i++ {
+			for _, digest := range digests {
+				// Nodes orphaned by the concurrent commits may be deleted
+				_, err := store.Get(digest)
+				if err != nil && !errors.Is(err, memdb.ErrMemDBKeyNotFound) {
+					done <- err
+					return
+				}
+			}
+		}
+		done <- nil
+	}()
+	newKeys, newValues := randomBatch(t, 64, 12)
+	for i := range newKeys {
+		require.NoError(t, trie.Update(newKeys[i], newValues[i]))
+		if i%8 == 0 {
+			require.NoError(t, trie.Commit())
+		}
+	}
+	require.NoError(t, <-done)
+}
+
+func TestVerifyingMapStore_Tampering(t *testing.T) {
+	for _, sumTrie := range []bool{false, true} {
+		t.Run(fmt.Sprintf("sumTrie=%t", sumTrie), func(t *testing.T) {
+			tt := newVerifyingTestTrie(t, sumTrie)
+			store := NewVerifyingMapStore(tt.nodes, tt.spec, sha256.New)
+			digests, nodes := tt.storedNodes(t)
+
+			seen := make(map[byte]bool)
+			for i, digest := range digests {
+				data := nodes[i]
+				seen[data[0]] = true
+				got, err := store.Get(digest)
+				require.NoError(t, err)
+				require.Equal(t, data, got)
+
+				tampered := [][]byte{
+					{},
+					data[:len(data)-1],
+					append(append([]byte{}, data...), 0),
+					// Another node of the trie stored under the wrong digest
+					nodes[(i+1)%len(nodes)],
+				}
+				for j := range data {
+					// Only the path bits within the bounds of an extension
+					// node contribute to its digest
+					if isExtNode(data) && j >= prefixLen+2 && j < prefixLen+2+tt.spec.ph.PathSize() {
+						continue
+					}
+					flipped := append([]byte{}, data...)
+					flipped[j] ^= 0x01
+					tampered = append(tampered, flipped)
+				}
+				for _, bad := range tampered {
+					require.NoError(t, tt.nodes.Set(digest, bad))
+					_, err := store.Get(digest)
+					require.ErrorIs(t, err, ErrCorruptNode)
+				}
+				require.NoError(t, tt.nodes.Set(digest, data))
+			}
+			// Ensure the trie contains every type of node
+			require.Len(t, seen, 3)
+
+			// Missing nodes are reported by the underlying store
+			_, err := store.Get([]byte("missing"))
+			require.ErrorIs(t, err, simplemap.ErrKVStoreKeyNotFound)
+		})
+	}
+}
+
+func TestVerifyingMapStore_ForgedLeaf(t *testing.T) {
+	tt := newVerifyingTestTrie(t, false)
+	key := tt.keys[0]
+	path := tt.spec.ph.Path(key)
+	forgedValue := tt.spec.valueHash([]byte("forged"))
+
+	// Overwrite the leaf of the key with a leaf holding a forged value
+	digests, nodes := tt.storedNodes(t)
+	for i, data := range nodes {
+		if isLeafNode(data) {
+			leafPath, _ := tt.spec.parseLeafNode(data)
+			if string(leafPath) == string(path) {
+				require.NoError(t, tt.nodes.Set(digests[i], encodeLeafNode(path, forgedValue)))
+			}
+		}
+	}
+
+	// A trie reading the store directly returns the forged value
+	trie := ImportSparseMerkleTrie(tt.nodes, sha256.New(), tt.root, tt.opts...)
+	value, err := trie.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, forgedValue, value)
+
+	// A trie reading through the verifying store detects the forgery
+	store := NewVerifyingMapStore(tt.nodes, tt.spec, sha256.New)
+	trie = ImportSparseMerkleTrie(store, sha256.New(), tt.root, tt.opts...)
+	_, err = trie.Get(key)
+	require.ErrorIs(t, err, ErrCorruptNode)
+	_, err = trie.Prove(key)
+	require.ErrorIs(t, err, ErrCorruptNode)
+}
+
+func TestVerifyingMapStore_InvalidExtensionBounds(t *testing.T) {
+	spec := NewTrieSpec(sha256.New(), false)
+	child := make([]byte, sha256.Size)
+	child[0] = 1
+	path := make([]byte, sha256.Size)
+
+	// An extension node with empty bounds has the digest of its child, so it
+	// could otherwise be stored under that digest and reference itself
+	data := encodeExtensionNode([2]byte{3, 3}, path, child)
+	require.Equal(t, child, spec.hashPreimage(data))
+	nodes := simplemap.NewSimpleMap()
+	require.NoError(t, nodes.Set(child, data))
+	_, err := NewVerifyingMapStore(nodes, &spec, sha256.New).Get(child)
+	require.ErrorIs(t, err, ErrCorruptNode)
+
+	require.False(t, spec.validEncoding(encodeExtensionNode([2]byte{4, 3}, path, child)))
+	require.True(t, spec.validEncoding(encodeExtensionNode([2]byte{3, 4}, path, child)))
+}